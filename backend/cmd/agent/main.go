@@ -0,0 +1,32 @@
+package main
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"panel-tool/internal/services"
+)
+
+// 计算节点代理：定时采集本机 /proc 指标、硬件信息和挂载点状态并上报到面板
+// 配置通过 PANEL_AGENT_* 环境变量提供，至少需要 PANEL_AGENT_URL 以及 PANEL_AGENT_TOKEN 或客户端证书
+func main() {
+	config, err := services.AgentConfigFromEnv()
+	if err != nil {
+		log.Fatal("Invalid agent configuration: ", err)
+	}
+	agent, err := services.NewAgent(config)
+	if err != nil {
+		log.Fatal("Failed to create agent: ", err)
+	}
+
+	stop := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-signals
+		close(stop)
+	}()
+	agent.Run(stop)
+}
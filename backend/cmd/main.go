@@ -8,13 +8,66 @@ import (
 )
 
 func main() {
+	// 集群配置无效（如只提供了 slurmrestd 端点）时拒绝启动，避免请求落到错误的集群
+	if err := api.ClusterConfigError(); err != nil {
+		log.Fatal("Invalid cluster configuration: ", err)
+	}
+
 	// 设置路由
 	http.HandleFunc("/api/management-node", api.HandleGetManagementNode)
+	http.HandleFunc("/api/management-node/telemetry", api.HandleGetManagementTelemetry)
 	http.HandleFunc("/api/compute-nodes", api.HandleGetComputeNodes)
+	http.HandleFunc("/api/agent/report", api.HandleAgentReport)
+	http.HandleFunc("/api/inventory", api.HandleGetInventory)
+	http.HandleFunc("/api/inventory/changes", api.HandleGetInventoryChanges)
+	http.HandleFunc("/api/inventory/{node}", api.HandleGetNodeInventory)
+	http.HandleFunc("/api/sensors", api.HandleGetSensors)
+	http.HandleFunc("/api/sensors/alerts", api.HandleGetSensorAlerts)
+	http.HandleFunc("/api/alerts", api.HandleGetAlerts)
+	http.HandleFunc("/api/alerts/rules", api.AuthMiddlewareForWrites(api.HandleAlertRules))
+	http.HandleFunc("/api/alerts/silences", api.AuthMiddlewareForWrites(api.HandleAlertSilences))
+	http.HandleFunc("/api/alerts/silences/{id}", api.AuthMiddleware(api.HandleDeleteAlertSilence))
+	http.HandleFunc("/api/slurm/nodes/state", api.AuthMiddleware(api.HandleUpdateNodeState))
+	http.HandleFunc("/api/audit", api.AuthMiddleware(api.HandleGetAuditLog))
+	http.HandleFunc("/api/services", api.HandleGetServices)
+	http.HandleFunc("/api/services/{name}", api.HandleGetService)
+	http.HandleFunc("/api/services/{name}/{action}", api.AuthMiddleware(api.HandleServiceAction))
 	http.HandleFunc("/api/slurm-jobs", api.HandleGetSlurmJobs)
+	http.HandleFunc("/api/events", api.HandleClusterEvents)
+	http.HandleFunc("/api/metrics", api.HandleGetMetricsCatalog)
+	http.HandleFunc("/api/metrics/query", api.HandleQueryMetrics)
+	http.HandleFunc("/api/slurm/jobs/history", api.HandleGetSlurmJobHistory)
+	http.HandleFunc("/api/slurm/jobs/graph", api.HandleGetJobDependencyGraph)
+	http.HandleFunc("/api/slurm/jobs/{id}", api.AuthMiddleware(api.HandleGetSlurmJobDetail))
+	http.HandleFunc("/api/slurm/jobs/{id}/output", api.AuthMiddleware(api.HandleSlurmJobOutput))
+	http.HandleFunc("/api/slurm/jobs/{id}/efficiency", api.HandleGetSlurmJobEfficiency)
+	http.HandleFunc("/api/slurm/efficiency", api.HandleGetEfficiencyReport)
+	http.HandleFunc("/api/slurm/partitions", api.HandleGetPartitions)
+	http.HandleFunc("/api/slurm/qos", api.HandleGetQOS)
+	http.HandleFunc("/api/slurm/licenses", api.HandleGetLicenses)
+	http.HandleFunc("/api/clusters", api.HandleGetClusters)
+	http.HandleFunc("/api/notifications", api.HandleNotifications)
+	http.HandleFunc("/api/notifications/read/{id}", api.HandleMarkNotificationsRead)
+	http.HandleFunc("/api/notifications/subscriptions", api.AuthMiddleware(api.HandleNotificationSubscriptions))
+	http.HandleFunc("/api/notifications/subscriptions/{id}", api.AuthMiddleware(api.HandleNotificationSubscription))
+	http.HandleFunc("/api/slurm/accounts", api.HandleGetAccountTree)
+	http.HandleFunc("/api/slurm/accounts/changes", api.AuthMiddleware(api.HandleAccountChange))
+	http.HandleFunc("/api/slurm/reservations", api.AuthMiddleware(api.HandleReservations))
+	http.HandleFunc("/api/slurm/reservations/conflicts", api.HandleReservationConflicts)
+	http.HandleFunc("/api/slurm/reservations/{name}", api.AuthMiddleware(api.HandleReservation))
+	http.HandleFunc("/api/slurm/diagnostics", api.HandleGetSchedulerDiagnostics)
+	http.HandleFunc("/api/slurm/diagnostics/history", api.HandleGetSchedulerDiagnosticsHistory)
+	http.HandleFunc("/api/slurm/config", api.HandleGetSlurmConfig)
+	http.HandleFunc("/api/slurm/config/file", api.AuthMiddleware(api.HandleSlurmConfigFile))
+	http.HandleFunc("/api/slurm/config/validate", api.AuthMiddleware(api.HandleValidateSlurmConfig))
+	http.HandleFunc("/api/slurm/config/reconfigure", api.AuthMiddleware(api.HandleReconfigureSlurm))
 	http.HandleFunc("/api/login", api.HandleLogin)
 	http.HandleFunc("/api/change-password", api.HandleChangePassword)
 	
+	// 用量报表相关路由
+	http.HandleFunc("/api/reports/usage", api.HandleGetUsageReport)
+	http.HandleFunc("/api/reports/rates", api.AuthMiddleware(api.HandleChargeRates))
+	
 	// 文件管理相关路由
 	http.HandleFunc("/api/file/upload", api.HandleFileUpload)
 	http.HandleFunc("/api/file/download", api.HandleFileDownload)
@@ -41,10 +94,29 @@ func main() {
 	// 提供静态文件服务
 	http.Handle("/", http.FileServer(http.Dir("./frontend/dist/")))
 	
-	// 启动服务器
+	// 启动后台集群状态采集、调度器诊断、作业通知、指标历史记录、硬件清单与传感器采集和告警规则评估
+	api.StartClusterCollector()
+	api.StartSchedulerDiagnostics()
+	api.StartNotifications()
+	api.StartMetrics()
+	api.StartInventory()
+	api.StartSensors()
+	api.StartAlerts()
+
+	// 启动服务器，配置了证书时使用 HTTPS（可选校验节点代理的客户端证书）
+	tlsConfig, err := api.ServerTLSConfig()
+	if err != nil {
+		log.Fatal("Invalid TLS configuration: ", err)
+	}
+	if tlsConfig != nil {
+		server := &http.Server{Addr: ":8080", TLSConfig: tlsConfig}
+		log.Println("Server starting on :8080 (HTTPS)")
+		log.Fatal("Server failed to start: ", server.ListenAndServeTLS("", ""))
+	}
+
 	log.Println("Server starting on :8080")
-	err := http.ListenAndServe(":8080", nil)
+	err = http.ListenAndServe(":8080", nil)
 	if err != nil {
 		log.Fatal("Server failed to start: ", err)
 	}
-}
\ No newline at end of file
+}
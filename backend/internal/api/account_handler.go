@@ -0,0 +1,115 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"panel-tool/internal/services"
+)
+
+// 全局账户变更预览存储，执行变更前必须先生成预览
+var accountPreviews = services.NewAccountChangePreviews()
+
+// AccountChangeRequest 账户变更请求结构体
+// Confirm 为 false 时生成预览并返回 preview_id；为 true 时执行 PreviewID 对应的预览，忽略请求中的变更内容
+type AccountChangeRequest struct {
+	services.AccountChangeRequest
+	Confirm   bool   `json:"confirm"`
+	PreviewID string `json:"preview_id,omitempty"`
+}
+
+// HandleGetAccountTree 获取账户关联树及公平共享信息
+func HandleGetAccountTree(w http.ResponseWriter, r *http.Request) {
+	cluster, ok := requestCluster(w, r)
+	if !ok {
+		return
+	}
+
+	tree, err := services.GetAssociationTree(cluster)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tree)
+}
+
+// HandleAccountChange 预览或执行账户变更（创建账户、添加用户、设置限制），需要管理员
+func HandleAccountChange(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	// 变更通过 root 执行 sacctmgr，普通用户可借此加入账户或放宽自己的限制
+	user, ok := requireAdmin(w, r)
+	if !ok {
+		return
+	}
+	cluster, ok := requestCluster(w, r)
+	if !ok {
+		return
+	}
+
+	var request AccountChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !request.Confirm {
+		plan, err := services.PlanAccountChange(request.AccountChangeRequest)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		id, err := accountPreviews.Save(request.AccountChangeRequest, plan, user, cluster.ClusterName())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"preview":    true,
+			"preview_id": id,
+			"summary":    plan.Summary,
+			"command":    plan.Command,
+		})
+		return
+	}
+
+	if request.PreviewID == "" {
+		http.Error(w, "preview_id is required", http.StatusBadRequest)
+		return
+	}
+	change, plan, err := accountPreviews.Take(request.PreviewID, user, cluster.ClusterName())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	output, err := services.ApplyAccountChange(cluster, plan)
+
+	entry := services.AuditEntry{
+		User:    user,
+		Action:  "account." + change.Operation,
+		Target:  change.Account,
+		Detail:  plan.Command,
+		Success: err == nil,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	auditService.Record(entry)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Account change applied successfully",
+		"summary": plan.Summary,
+		"output":  output,
+	})
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"panel-tool/internal/models"
+	"panel-tool/internal/services"
+)
+
+// maxAgentReportSize 单次代理上报的最大字节数
+const maxAgentReportSize = 4 << 20
+
+// 全局节点代理注册表，在各处理器的 init 之前创建，供集群采集器合并
+var agentRegistry = services.NewAgentRegistry()
+
+// ServerTLSConfig 根据 PANEL_TLS_CERT、PANEL_TLS_KEY 返回 HTTPS 配置，未配置证书时返回 nil
+// 设置 PANEL_AGENT_CA 后会校验节点代理的客户端证书（mTLS），浏览器等不带证书的客户端不受影响
+func ServerTLSConfig() (*tls.Config, error) {
+	certFile, keyFile := os.Getenv("PANEL_TLS_CERT"), os.Getenv("PANEL_TLS_KEY")
+	if certFile == "" || keyFile == "" {
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("加载服务器证书失败: %v", err)
+	}
+	config := &tls.Config{Certificates: []tls.Certificate{cert}}
+
+	if caFile := os.Getenv("PANEL_AGENT_CA"); caFile != "" {
+		data, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取代理 CA 证书失败: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("%s 中没有有效的证书", caFile)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return config, nil
+}
+
+// authenticateAgent 校验代理身份：已验证的客户端证书须签发给该节点（CN 或 DNS 名称），
+// 否则比较 Bearer token 与 PANEL_AGENT_TOKEN
+func authenticateAgent(r *http.Request, hostname string) bool {
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		cert := r.TLS.VerifiedChains[0][0]
+		names := append([]string{cert.Subject.CommonName}, cert.DNSNames...)
+		for _, name := range names {
+			if name == hostname || strings.SplitN(name, ".", 2)[0] == hostname {
+				return true
+			}
+		}
+		return false
+	}
+
+	token := os.Getenv("PANEL_AGENT_TOKEN")
+	if token == "" {
+		return false
+	}
+	provided := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1
+}
+
+// HandleAgentReport 接收计算节点代理的上报，附带的硬件清单交给硬件清单服务比较并保存
+func HandleAgentReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var report models.AgentReport
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAgentReportSize)).Decode(&report); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	if !authenticateAgent(r, report.Hostname) {
+		http.Error(w, "Unauthorized agent", http.StatusUnauthorized)
+		return
+	}
+	if report.Cluster != "" {
+		if _, err := clusterRegistry.Get(report.Cluster); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if err := agentRegistry.Report(&report); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if report.Inventory != nil {
+		// 清单中的节点以认证通过的上报为准
+		report.Inventory.Hostname, report.Inventory.Cluster = report.Hostname, report.Cluster
+		if _, err := inventoryService.Record(report.Inventory); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
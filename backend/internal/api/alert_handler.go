@@ -0,0 +1,152 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"panel-tool/internal/models"
+	"panel-tool/internal/services"
+)
+
+// 全局告警规则引擎实例，评估各集群快照、管理节点、服务、传感器和指标
+var alertEngine *services.AlertEngine
+
+func init() {
+	alertEngine = services.NewAlertEngine(services.AlertSources{
+		Snapshots: func() map[string]*services.ClusterSnapshot {
+			snapshots := make(map[string]*services.ClusterSnapshot)
+			for name, collector := range clusterCollectors {
+				snapshots[name] = collector.Snapshot()
+			}
+			return snapshots
+		},
+		Management: services.GetManagementTelemetry,
+		Services:   func() []models.ServiceStatus { return serviceManager.ListStatus() },
+		Sensors:    func() []models.NodeSensors { return sensorMonitor.Nodes("") },
+		Metrics:    func() []models.MetricSample { return metricsService.Latest() },
+	})
+}
+
+// StartAlerts 启动告警规则的定时评估
+func StartAlerts() {
+	alertEngine.Start()
+}
+
+// recordAlertAudit 记录告警规则和静默操作的审计日志
+func recordAlertAudit(user, action, target string, err error) {
+	entry := services.AuditEntry{
+		User:    user,
+		Action:  action,
+		Target:  target,
+		Success: err == nil,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	auditService.Record(entry)
+}
+
+// HandleGetAlerts 获取告警，默认返回 pending 和 firing 的告警
+// 参数：state（pending、firing、resolved）、limit（resolved 时返回的数量，默认 100）
+func HandleGetAlerts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 {
+		limit = 100
+	}
+	alerts, err := alertEngine.Alerts(r.URL.Query().Get("state"), limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(alerts)
+}
+
+// HandleAlertRules 获取（GET）或整体替换（PUT，需要管理员）告警规则
+func HandleAlertRules(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(alertEngine.Rules())
+
+	case http.MethodPut:
+		user, ok := requireAdmin(w, r)
+		if !ok {
+			return
+		}
+		var rules []models.AlertRule
+		if err := json.NewDecoder(r.Body).Decode(&rules); err != nil {
+			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+		err := alertEngine.SetRules(rules)
+		recordAlertAudit(user, "alert.rules.update", strconv.Itoa(len(rules))+" rules", err)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(alertEngine.Rules())
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleAlertSilences 获取未过期的静默（GET）或创建静默（POST，需要管理员）
+func HandleAlertSilences(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(alertEngine.Silences())
+
+	case http.MethodPost:
+		user, ok := requireAdmin(w, r)
+		if !ok {
+			return
+		}
+		var silence models.Silence
+		if err := json.NewDecoder(r.Body).Decode(&silence); err != nil {
+			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+		silence.CreatedBy = user
+		created, err := alertEngine.AddSilence(silence)
+		recordAlertAudit(user, "alert.silence.create", created.ID, err)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(created)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleDeleteAlertSilence 提前结束静默，需要管理员
+func HandleDeleteAlertSilence(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user, ok := requireAdmin(w, r)
+	if !ok {
+		return
+	}
+	id := r.PathValue("id")
+	err := alertEngine.DeleteSilence(id)
+	recordAlertAudit(user, "alert.silence.delete", id, err)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Silence deleted successfully"})
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"panel-tool/internal/services"
+)
+
+// 全局集群列表，在各处理器的 init 之前完成加载
+var clusterRegistry = services.NewClusterRegistry()
+
+// ClusterConfigError 返回集群配置文件的错误，服务启动时检查，配置无效时拒绝启动
+func ClusterConfigError() error {
+	return clusterRegistry.Err()
+}
+
+// ClusterInfo 集群列表接口返回的集群信息
+// 不返回 slurmrestd 的 token
+type ClusterInfo struct {
+	Name         string `json:"name"`
+	SlurmConf    string `json:"slurm_conf"`
+	SlurmCluster string `json:"slurm_cluster"`
+	RestURL      string `json:"rest_url,omitempty"`
+	Default      bool   `json:"default"`
+}
+
+// requestCluster 解析请求中的 cluster 参数，未指定时使用默认集群；参数无效时写入 400 并返回 false
+func requestCluster(w http.ResponseWriter, r *http.Request) (*services.Cluster, bool) {
+	cluster, err := clusterRegistry.Get(r.URL.Query().Get("cluster"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	return cluster, true
+}
+
+// requestClusters 解析请求中的 cluster 参数，cluster=all 时返回所有集群
+func requestClusters(w http.ResponseWriter, r *http.Request) ([]*services.Cluster, bool) {
+	clusters, err := clusterRegistry.Resolve(r.URL.Query().Get("cluster"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	return clusters, true
+}
+
+// requestHistoryClusters 解析作业历史类请求的集群参数
+// cluster=all 时在默认集群上执行 sacct --clusters，由共享的 slurmdbd 返回各集群的记录
+// --clusters 需要 Slurm 中的 ClusterName，而不是面板配置中的名称
+func requestHistoryClusters(w http.ResponseWriter, r *http.Request) (*services.Cluster, []string, bool) {
+	name := r.URL.Query().Get("cluster")
+	if name == services.AllClusters {
+		cluster, _ := clusterRegistry.Get("")
+		return cluster, clusterRegistry.SlurmNames(), true
+	}
+	cluster, ok := requestCluster(w, r)
+	return cluster, nil, ok
+}
+
+// HandleGetClusters 获取已配置的集群列表
+func HandleGetClusters(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	clusters := []ClusterInfo{}
+	for _, cluster := range clusterRegistry.List() {
+		clusters = append(clusters, ClusterInfo{
+			Name:         cluster.Name,
+			SlurmConf:    cluster.SlurmConf,
+			SlurmCluster: cluster.SlurmName(),
+			RestURL:      cluster.RestURL,
+			Default:      cluster.Default,
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(clusters)
+}
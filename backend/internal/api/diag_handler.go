@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"panel-tool/internal/services"
+)
+
+// 全局调度器诊断服务实例，每个集群一个
+var schedulerDiagServices map[string]*services.SchedulerDiagService
+
+func init() {
+	schedulerDiagServices = make(map[string]*services.SchedulerDiagService)
+	for _, cluster := range clusterRegistry.List() {
+		schedulerDiagServices[cluster.Name] = services.NewSchedulerDiagService(cluster)
+	}
+}
+
+// StartSchedulerDiagnostics 启动所有集群的后台 sdiag 采集
+func StartSchedulerDiagnostics() {
+	for _, service := range schedulerDiagServices {
+		service.Start()
+	}
+}
+
+// HandleGetSchedulerDiagnostics 获取最新的 sdiag 诊断数据及频繁调用 RPC 的用户
+func HandleGetSchedulerDiagnostics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cluster, ok := requestCluster(w, r)
+	if !ok {
+		return
+	}
+
+	diag, alerts, err := schedulerDiagServices[cluster.Name].Latest()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"diagnostics": diag,
+		"rpc_alerts":  alerts,
+	})
+}
+
+// HandleGetSchedulerDiagnosticsHistory 获取调度器诊断的历史趋势
+func HandleGetSchedulerDiagnosticsHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	cluster, ok := requestCluster(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schedulerDiagServices[cluster.Name].History())
+}
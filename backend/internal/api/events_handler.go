@@ -0,0 +1,133 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"panel-tool/internal/models"
+	"panel-tool/internal/services"
+)
+
+// eventsKeepAlive SSE 连接的心跳间隔，防止代理因空闲断开连接
+const eventsKeepAlive = 30 * time.Second
+
+// 全局集群状态采集器实例，每个集群一个
+var clusterCollectors map[string]*services.ClusterCollector
+
+func init() {
+	clusterCollectors = make(map[string]*services.ClusterCollector)
+	for _, cluster := range clusterRegistry.List() {
+		collector := services.NewClusterCollector(cluster)
+		collector.UseAgents(agentRegistry)
+		clusterCollectors[cluster.Name] = collector
+	}
+}
+
+// StartClusterCollector 启动所有集群的后台状态采集
+func StartClusterCollector() {
+	for _, collector := range clusterCollectors {
+		collector.Start()
+	}
+}
+
+// mergedSnapshot 合并多个集群的最新快照，时间取最近一次采集
+func mergedSnapshot(clusters []*services.Cluster) *services.ClusterSnapshot {
+	merged := &services.ClusterSnapshot{Jobs: []models.JobModel{}, Nodes: []models.NodeModel{}, Partitions: []models.PartitionModel{}}
+	for _, cluster := range clusters {
+		snapshot := clusterCollectors[cluster.Name].Snapshot()
+		if snapshot.Time.After(merged.Time) {
+			merged.Time = snapshot.Time
+		}
+		merged.Jobs = append(merged.Jobs, snapshot.Jobs...)
+		merged.Nodes = append(merged.Nodes, snapshot.Nodes...)
+		merged.Partitions = append(merged.Partitions, snapshot.Partitions...)
+	}
+	return merged
+}
+
+// subscribeClusters 订阅多个集群的变更事件并合并到同一通道，返回的取消函数会结束所有订阅
+func subscribeClusters(clusters []*services.Cluster) (<-chan services.ClusterEvent, func()) {
+	if len(clusters) == 1 {
+		return clusterCollectors[clusters[0].Name].Subscribe()
+	}
+
+	merged := make(chan services.ClusterEvent)
+	done := make(chan struct{})
+	var unsubscribes []func()
+	for _, cluster := range clusters {
+		events, unsubscribe := clusterCollectors[cluster.Name].Subscribe()
+		unsubscribes = append(unsubscribes, unsubscribe)
+		go func() {
+			for event := range events {
+				select {
+				case merged <- event:
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+
+	var once sync.Once
+	return merged, func() {
+		once.Do(func() {
+			close(done)
+			for _, unsubscribe := range unsubscribes {
+				unsubscribe()
+			}
+		})
+	}
+}
+
+// HandleClusterEvents 通过 Server-Sent Events 推送作业与节点的变更事件，cluster=all 时推送所有集群
+func HandleClusterEvents(w http.ResponseWriter, r *http.Request) {
+	clusters, ok := requestClusters(w, r)
+	if !ok {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	events, unsubscribe := subscribeClusters(clusters)
+	defer unsubscribe()
+
+	// 连接建立后先发送当前快照，客户端据此初始化页面
+	if data, err := json.Marshal(mergedSnapshot(clusters)); err == nil {
+		fmt.Fprintf(w, "event: snapshot\ndata: %s\n\n", data)
+		flusher.Flush()
+	}
+
+	ticker := time.NewTicker(eventsKeepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+			flusher.Flush()
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+			flusher.Flush()
+		}
+	}
+}
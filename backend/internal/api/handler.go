@@ -1,7 +1,6 @@
 package api
 
 import (
-	"panel-tool/internal/models"
 	"panel-tool/internal/services"
 	"encoding/json"
 	"fmt"
@@ -13,7 +12,6 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/creack/pty"
 	"github.com/gorilla/websocket"
@@ -26,15 +24,35 @@ func HandleGetManagementNode(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(node)
 }
 
+// HandleGetManagementTelemetry 获取管理节点的实时指标（CPU、负载、内存、磁盘、网络）
+func HandleGetManagementTelemetry(w http.ResponseWriter, r *http.Request) {
+	telemetry, err := services.GetManagementTelemetry()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(telemetry)
+}
+
 // HandleGetComputeNodes 处理获取计算节点信息请求
 func HandleGetComputeNodes(w http.ResponseWriter, r *http.Request) {
+	clusters, ok := requestClusters(w, r)
+	if !ok {
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
-	nodes := services.GetComputeNodes()
+	// 读取后台采集器缓存的节点信息，避免每次请求都执行 scontrol
+	nodes := mergedSnapshot(clusters).Nodes
 	json.NewEncoder(w).Encode(nodes)
 }
 
 // HandleGetSlurmJobs 获取Slurm作业信息
 func HandleGetSlurmJobs(w http.ResponseWriter, r *http.Request) {
+	clusters, ok := requestClusters(w, r)
+	if !ok {
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
 	
 	// 检查Slurm是否已安装
@@ -46,35 +64,8 @@ func HandleGetSlurmJobs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
-	// 执行squeue命令获取作业信息
-	cmd := exec.Command("squeue", "--all", "--states=all", "--format=%i|%j|%u|%t|%M|%l|%N", "--noheader")
-	output, err := cmd.Output()
-	if err != nil {
-		http.Error(w, "Failed to execute squeue command", http.StatusInternalServerError)
-		return
-	}
-	
-	// 解析输出
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	var jobs []models.JobModel
-	
-	for _, line := range lines {
-		parts := strings.Split(line, "|")
-		if len(parts) != 7 {
-			continue
-		}
-		
-		//jobID, _ := strconv.Atoi(parts[0])
-		job := models.JobModel{
-			JobID:       parts[0],
-			User:        parts[2],
-			Status:      parts[3],
-			ComputeTime: parts[4],
-			WaitTime:    parts[5],
-		}
-		jobs = append(jobs, job)
-	}
-	
+	// 读取后台采集器缓存的队列作业（含排队原因和排队位置）
+	jobs := mergedSnapshot(clusters).Jobs
 	json.NewEncoder(w).Encode(jobs)
 }
 
@@ -403,8 +394,13 @@ func HandleLogin(w http.ResponseWriter, r *http.Request) {
 	// 首先尝试使用默认凭据验证
 	if credentials.Username == validUsername && credentials.Password == validPassword {
 		// 登录成功，返回token和用户信息
+		token, err := newSession(credentials.Username)
+		if err != nil {
+			http.Error(w, "Failed to create session", http.StatusInternalServerError)
+			return
+		}
 		response := map[string]interface{}{
-			"token": fmt.Sprintf("token_%d", time.Now().Unix()),
+			"token": token,
 			"user": map[string]string{
 				"username": credentials.Username,
 			},
@@ -464,8 +460,13 @@ func HandleLogin(w http.ResponseWriter, r *http.Request) {
 	// 检查输出和错误码
 	if err == nil && strings.Contains(string(output), "authenticated") {
 		// 登录成功，返回token和用户信息
+		token, err := newSession(credentials.Username)
+		if err != nil {
+			http.Error(w, "Failed to create session", http.StatusInternalServerError)
+			return
+		}
 		response := map[string]interface{}{
-			"token": fmt.Sprintf("token_%d", time.Now().Unix()),
+			"token": token,
 			"user": map[string]string{
 				"username": credentials.Username,
 			},
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"panel-tool/internal/services"
+)
+
+// 全局硬件清单服务实例
+var inventoryService *services.InventoryService
+
+func init() {
+	inventoryService = services.NewInventoryService()
+}
+
+// StartInventory 开始定时采集管理节点的硬件清单，计算节点的清单由节点代理上报
+func StartInventory() {
+	inventoryService.StartLocal()
+}
+
+// HandleGetInventory 获取各节点最新的硬件清单，format=csv 时以附件形式下载
+func HandleGetInventory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	cluster := r.URL.Query().Get("cluster")
+	if cluster != "" {
+		if _, err := clusterRegistry.Get(cluster); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	inventories := inventoryService.Latest(cluster)
+	if r.URL.Query().Get("format") == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=inventory_%s.csv", time.Now().Format("20060102")))
+		services.WriteInventoryCSV(w, inventories)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(inventories)
+}
+
+// HandleGetNodeInventory 获取单个节点的硬件清单快照历史，由新到旧
+func HandleGetNodeInventory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	history := inventoryService.History(r.PathValue("node"))
+	if len(history) == 0 {
+		http.Error(w, "Inventory not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
+
+// HandleGetInventoryChanges 获取最近的硬件变更记录，可按 node 筛选
+func HandleGetInventoryChanges(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 {
+		limit = 100
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(inventoryService.Changes(r.URL.Query().Get("node"), limit))
+}
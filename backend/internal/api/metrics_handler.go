@@ -0,0 +1,92 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"panel-tool/internal/services"
+)
+
+// defaultMetricsRange 未指定开始时间时的查询范围
+const defaultMetricsRange = 24 * time.Hour
+
+// 全局指标服务实例
+var metricsService *services.MetricsService
+
+func init() {
+	metricsService = services.NewMetricsService()
+}
+
+// StartMetrics 注册管理节点和各集群的指标来源并启动采集
+func StartMetrics() {
+	metricsService.AddSource(services.ManagementMetrics)
+	for _, cluster := range clusterRegistry.List() {
+		metricsService.AddSource(services.ClusterMetrics(cluster, clusterCollectors[cluster.Name]))
+	}
+	metricsService.Start()
+}
+
+// HandleGetMetricsCatalog 获取已记录的指标及其对象（节点或集群）
+func HandleGetMetricsCatalog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(metricsService.Catalog())
+}
+
+// HandleQueryMetrics 查询指标的时间序列
+// 参数：metric（必填）、target（节点或集群，为空返回全部）、start、end、resolution（1m、5m、1h，为空时自动选择）
+// 指定 cluster 时，target 为该集群的计算节点主机名；target 为空时返回该集群的所有序列
+func HandleQueryMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	params := r.URL.Query()
+	metric := params.Get("metric")
+	if metric == "" {
+		http.Error(w, "metric is required", http.StatusBadRequest)
+		return
+	}
+	start, err := parseTimeParam(params.Get("start"))
+	if err != nil {
+		http.Error(w, "Invalid start time", http.StatusBadRequest)
+		return
+	}
+	end, err := parseTimeParam(params.Get("end"))
+	if err != nil {
+		http.Error(w, "Invalid end time", http.StatusBadRequest)
+		return
+	}
+	if end.IsZero() {
+		end = time.Now()
+	}
+	if start.IsZero() {
+		start = end.Add(-defaultMetricsRange)
+	}
+
+	cluster, target := params.Get("cluster"), params.Get("target")
+	if cluster != "" && target != "" {
+		target = services.NodeMetricTarget(cluster, target)
+	}
+	series, err := metricsService.Query(metric, target, start, end, params.Get("resolution"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if cluster != "" && target == "" {
+		filtered := series[:0]
+		for _, s := range series {
+			if s.Cluster == cluster {
+				filtered = append(filtered, s)
+			}
+		}
+		series = filtered
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(series)
+}
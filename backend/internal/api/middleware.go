@@ -1,46 +1,175 @@
 package api
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"net/http"
+	"os"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
 )
 
+// defaultSessionTTL 登录会话的有效期，可通过 PANEL_SESSION_TTL（秒）覆盖
+const defaultSessionTTL = 12 * time.Hour
+
+// session 登录 token 对应的用户和过期时间
+type session struct {
+	user    string
+	expires time.Time
+}
+
+// sessions 记录登录 token 对应的会话，只有登录时签发且未过期的 token 有效
+var sessions sync.Map
+
 // AuthMiddleware 是一个认证中间件，用于保护需要认证的API端点
 func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// 获取Authorization头
 		authHeader := r.Header.Get("Authorization")
-		
-		// 检查是否存在Authorization头
-		if authHeader == "" {
+
+		// 检查是否存在Authorization头（浏览器无法为 WebSocket 设置请求头，握手时允许使用 token 查询参数）
+		if authHeader == "" && !websocket.IsWebSocketUpgrade(r) {
 			http.Error(w, "Authorization header is required", http.StatusUnauthorized)
 			return
 		}
-		
+
 		// 检查Bearer token格式
-		if !strings.HasPrefix(authHeader, "Bearer ") {
+		if authHeader != "" && !strings.HasPrefix(authHeader, "Bearer ") {
 			http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
 			return
 		}
-		
-		// 提取token
-		token := strings.TrimPrefix(authHeader, "Bearer ")
-		
-		// 验证token（这里使用简单的验证，实际项目中应该使用JWT或其他安全机制）
-		if !isValidToken(token) {
+
+		// 验证token：必须是登录时签发且未过期的 token
+		if !isValidToken(requestToken(r)) {
 			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
 			return
 		}
-		
+
 		// Token有效，继续处理请求
 		next.ServeHTTP(w, r)
 	}
 }
 
-// isValidToken 验证token是否有效
+// AuthMiddlewareForWrites 只对修改类请求要求认证，GET 查询保持公开
+func AuthMiddlewareForWrites(next http.HandlerFunc) http.HandlerFunc {
+	protected := AuthMiddleware(next)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			next.ServeHTTP(w, r)
+			return
+		}
+		protected.ServeHTTP(w, r)
+	}
+}
+
+// isValidToken 验证token是否有效：必须是登录时签发且未过期的 token
 func isValidToken(token string) bool {
-	// 这里应该实现实际的token验证逻辑
-	// 例如验证JWT token或检查token是否在有效期内
-	// 为简化起见，我们只检查token是否以"token_"开头
-	return strings.HasPrefix(token, "token_")
-}
\ No newline at end of file
+	_, ok := sessionUser(token)
+	return ok
+}
+
+// sessionTTL 返回登录会话的有效期
+func sessionTTL() time.Duration {
+	if value := os.Getenv("PANEL_SESSION_TTL"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return defaultSessionTTL
+}
+
+// newSession 为登录用户签发随机 token 并记录会话，顺带清理已过期的会话
+func newSession(username string) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := "token_" + hex.EncodeToString(buf)
+
+	now := time.Now()
+	sessions.Range(func(key, value interface{}) bool {
+		if !now.Before(value.(session).expires) {
+			sessions.Delete(key)
+		}
+		return true
+	})
+	sessions.Store(token, session{user: username, expires: now.Add(sessionTTL())})
+	return token, nil
+}
+
+// sessionUser 返回 token 对应的登录用户，token 未签发或已过期时返回 false
+func sessionUser(token string) (string, bool) {
+	value, ok := sessions.Load(token)
+	if !ok {
+		return "", false
+	}
+	s := value.(session)
+	if !time.Now().Before(s.expires) {
+		sessions.Delete(token)
+		return "", false
+	}
+	return s.user, true
+}
+
+// requestToken 返回请求中的 Bearer token，WebSocket 握手没有 Authorization 头时取 token 查询参数
+func requestToken(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); header != "" {
+		return strings.TrimPrefix(header, "Bearer ")
+	}
+	if websocket.IsWebSocketUpgrade(r) {
+		return r.URL.Query().Get("token")
+	}
+	return ""
+}
+
+// requestUser 根据请求中的 token 获取当前用户，无法识别时返回 "unknown"
+func requestUser(r *http.Request) string {
+	if username, ok := sessionUser(requestToken(r)); ok {
+		return username
+	}
+	return "unknown"
+}
+
+// requireUser 获取当前登录用户，无法识别（未登录或会话已失效）时返回 401，保证审计日志记录到真实用户
+func requireUser(w http.ResponseWriter, r *http.Request) (string, bool) {
+	user := requestUser(r)
+	if user == "unknown" {
+		http.Error(w, "Login required", http.StatusUnauthorized)
+		return "", false
+	}
+	return user, true
+}
+
+// isAdmin 判断用户是否为面板管理员：ADMIN_USERNAME（默认 admin），或 PANEL_ADMIN_USERS 中以逗号分隔列出的系统用户
+func isAdmin(user string) bool {
+	admin := os.Getenv("ADMIN_USERNAME")
+	if admin == "" {
+		admin = "admin"
+	}
+	if user == admin {
+		return true
+	}
+	for _, name := range strings.Split(os.Getenv("PANEL_ADMIN_USERS"), ",") {
+		if strings.TrimSpace(name) == user {
+			return true
+		}
+	}
+	return false
+}
+
+// requireAdmin 获取当前登录用户并要求其为管理员，未登录返回 401，非管理员返回 403
+func requireAdmin(w http.ResponseWriter, r *http.Request) (string, bool) {
+	user, ok := requireUser(w, r)
+	if !ok {
+		return "", false
+	}
+	if !isAdmin(user) {
+		http.Error(w, "Administrator privileges required", http.StatusForbidden)
+		return "", false
+	}
+	return user, true
+}
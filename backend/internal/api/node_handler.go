@@ -0,0 +1,91 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"panel-tool/internal/services"
+)
+
+// 全局审计日志服务实例
+var auditService *services.AuditService
+
+func init() {
+	auditService = services.NewAuditService()
+}
+
+// NodeStateRequest 节点状态修改请求结构体
+type NodeStateRequest struct {
+	Nodes  string `json:"nodes"`  // 节点名或主机列表表达式，如 cn[001-004]
+	Action string `json:"action"` // drain、resume、down 或 undrain
+	Reason string `json:"reason"`
+}
+
+// HandleUpdateNodeState 修改计算节点状态并记录审计日志，需要管理员
+func HandleUpdateNodeState(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user, ok := requireAdmin(w, r)
+	if !ok {
+		return
+	}
+	cluster, ok := requestCluster(w, r)
+	if !ok {
+		return
+	}
+
+	var request NodeStateRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	if request.Nodes == "" || request.Action == "" || request.Reason == "" {
+		http.Error(w, "Nodes, action and reason are required", http.StatusBadRequest)
+		return
+	}
+
+	states, err := services.UpdateNodeState(cluster, request.Nodes, request.Action, request.Reason)
+
+	entry := services.AuditEntry{
+		User:    user,
+		Action:  "node." + request.Action,
+		Target:  request.Nodes,
+		Detail:  request.Reason,
+		Success: err == nil,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	auditService.Record(entry)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Node state updated successfully",
+		"nodes":   states,
+	})
+}
+
+// HandleGetAuditLog 获取最近的审计记录，需要登录
+func HandleGetAuditLog(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 {
+		limit = 100
+	}
+
+	entries, err := auditService.List(limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"panel-tool/internal/models"
+	"panel-tool/internal/services"
+)
+
+// 全局通知服务实例
+var notificationService *services.NotificationService
+
+func init() {
+	notificationService = services.NewNotificationService()
+}
+
+// StartNotifications 为每个集群的采集器挂载作业结束通知
+func StartNotifications() {
+	for _, cluster := range clusterRegistry.List() {
+		notificationService.Watch(cluster, clusterCollectors[cluster.Name])
+	}
+}
+
+// HandleNotifications 获取当前用户的站内信，unread=true 时只返回未读通知
+func HandleNotifications(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	notifications := notificationService.Inbox(requestUser(r), r.URL.Query().Get("unread") == "true")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(notifications)
+}
+
+// HandleMarkNotificationsRead 将通知标记为已读，路径中的 id 为 all 时标记全部
+func HandleMarkNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := r.PathValue("id")
+	if id == "all" {
+		id = ""
+	}
+	if err := notificationService.MarkRead(requestUser(r), id); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Notifications marked as read",
+	})
+}
+
+// HandleNotificationSubscriptions 获取（GET）或创建（POST）当前用户的通知订阅
+func HandleNotificationSubscriptions(w http.ResponseWriter, r *http.Request) {
+	user, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(notificationService.Subscriptions(user))
+
+	case http.MethodPost:
+		var request models.NotificationSubscription
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+		cluster, err := clusterRegistry.Get(request.Cluster)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		request.User = user
+
+		// 按作业 ID 订阅时确认作业属于当前用户
+		admin := isAdmin(user)
+		if request.JobID != "" && !admin {
+			owner, err := services.JobOwner(cluster, request.JobID)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if owner != user {
+				http.Error(w, "Forbidden: job belongs to another user", http.StatusForbidden)
+				return
+			}
+		}
+
+		sub, err := notificationService.AddSubscription(request, admin)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(sub)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleNotificationSubscription 删除当前用户的指定订阅
+func HandleNotificationSubscription(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+	if err := notificationService.DeleteSubscription(user, r.PathValue("id")); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Subscription deleted successfully",
+	})
+}
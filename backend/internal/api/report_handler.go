@@ -0,0 +1,105 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"panel-tool/internal/services"
+)
+
+// 全局报表服务实例
+var reportService *services.ReportService
+
+func init() {
+	reportService = services.NewReportService()
+}
+
+// HandleGetUsageReport 生成集群用量与计费报表，format=csv 时以附件形式下载
+func HandleGetUsageReport(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	cluster, clusterNames, ok := requestHistoryClusters(w, r)
+	if !ok {
+		return
+	}
+
+	start, err := parseTimeParam(params.Get("start"))
+	if err != nil {
+		http.Error(w, "Invalid start time", http.StatusBadRequest)
+		return
+	}
+	end, err := parseTimeParam(params.Get("end"))
+	if err != nil {
+		http.Error(w, "Invalid end time", http.StatusBadRequest)
+		return
+	}
+
+	report, err := reportService.GenerateUsageReport(services.UsageReportQuery{
+		Cluster:  cluster,
+		Clusters: clusterNames,
+		Start:    start,
+		End:      end,
+		GroupBy:  params.Get("group_by"),
+		Period:   params.Get("period"),
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filename := fmt.Sprintf("usage_%s_%s_%s", report.GroupBy, report.Start.Format("20060102"), report.End.Format("20060102"))
+	switch params.Get("format") {
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.csv", filename))
+		services.WriteUsageReportCSV(w, report)
+	case "json":
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.json", filename))
+		json.NewEncoder(w).Encode(report)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(report)
+	}
+}
+
+// HandleChargeRates 获取（GET）或更新（PUT，需要管理员）分区计费费率
+func HandleChargeRates(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(reportService.GetRates())
+	case http.MethodPut:
+		user, ok := requireAdmin(w, r)
+		if !ok {
+			return
+		}
+		var rates map[string]services.ChargeRate
+		if err := json.NewDecoder(r.Body).Decode(&rates); err != nil {
+			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+		err := reportService.SetRates(rates)
+		entry := services.AuditEntry{
+			User:    user,
+			Action:  "report.rates.update",
+			Target:  fmt.Sprintf("%d partitions", len(rates)),
+			Success: err == nil,
+		}
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		auditService.Record(entry)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Charge rates updated successfully",
+			"rates":   rates,
+		})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"panel-tool/internal/services"
+)
+
+// HandleReservations 获取预约列表（GET）或创建预约（POST，需要管理员）
+func HandleReservations(w http.ResponseWriter, r *http.Request) {
+	cluster, ok := requestCluster(w, r)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		reservations, err := services.GetReservations(cluster)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(reservations)
+
+	case http.MethodPost:
+		if _, ok := requireAdmin(w, r); !ok {
+			return
+		}
+		var request services.ReservationRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+
+		// 冲突检查只作为警告返回，是否忽略由 IGNORE_JOBS 等标志决定
+		conflicts, _ := services.FindReservationConflicts(cluster, request)
+		name, err := services.CreateReservation(cluster, request)
+		recordReservationAudit(r, "reservation.create", name, request.Name, err)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":   "Reservation created successfully",
+			"name":      name,
+			"conflicts": conflicts,
+		})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleReservation 更新（PUT）或删除（DELETE）指定预约，需要管理员
+func HandleReservation(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+	name := r.PathValue("name")
+	cluster, ok := requestCluster(w, r)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		var request services.ReservationRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+		err := services.UpdateReservation(cluster, name, request)
+		recordReservationAudit(r, "reservation.update", name, name, err)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"message": "Reservation updated successfully",
+		})
+
+	case http.MethodDelete:
+		err := services.DeleteReservation(cluster, name)
+		recordReservationAudit(r, "reservation.delete", name, name, err)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"message": "Reservation deleted successfully",
+		})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleReservationConflicts 检查预约时间窗口会与哪些运行中的作业冲突
+func HandleReservationConflicts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	cluster, ok := requestCluster(w, r)
+	if !ok {
+		return
+	}
+
+	var request services.ReservationRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	conflicts, err := services.FindReservationConflicts(cluster, request)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(conflicts)
+}
+
+// recordReservationAudit 记录预约操作的审计日志，target 为空时使用请求中的预约名
+func recordReservationAudit(r *http.Request, action, target, fallback string, err error) {
+	if target == "" {
+		target = fallback
+	}
+	entry := services.AuditEntry{
+		User:    requestUser(r),
+		Action:  action,
+		Target:  target,
+		Success: err == nil,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	auditService.Record(entry)
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"panel-tool/internal/services"
+)
+
+// 全局传感器监控实例，计算节点的读数由节点代理上报
+var sensorMonitor *services.SensorMonitor
+
+func init() {
+	sensorMonitor = services.NewSensorMonitor()
+	agentRegistry.UseSensors(sensorMonitor)
+}
+
+// StartSensors 开始定时读取管理节点的传感器
+func StartSensors() {
+	sensorMonitor.StartLocal()
+}
+
+// HandleGetSensors 获取各节点最新的温度、风扇和电压读数，可按 cluster 筛选
+func HandleGetSensors(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	cluster := r.URL.Query().Get("cluster")
+	if cluster != "" {
+		if _, err := clusterRegistry.Get(cluster); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sensorMonitor.Nodes(cluster))
+}
+
+// HandleGetSensorAlerts 获取传感器告警，默认只返回未恢复的告警，resolved=true 时附带最近已恢复的告警
+func HandleGetSensorAlerts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 {
+		limit = 100
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sensorMonitor.Alerts(r.URL.Query().Get("resolved") == "true", limit))
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"panel-tool/internal/services"
+)
+
+// 全局服务管理器实例
+var serviceManager *services.ServiceManager
+
+func init() {
+	serviceManager = services.NewServiceManager()
+}
+
+// HandleGetServices 获取所有受管理服务的状态
+func HandleGetServices(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(serviceManager.ListStatus())
+}
+
+// HandleGetService 获取单个服务的状态及最近日志，lines 指定日志行数
+// 日志可能包含 sshd、munge 等服务的敏感信息，只返回给管理员
+func HandleGetService(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	lines := 0
+	if value := r.URL.Query().Get("lines"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "Invalid lines parameter", http.StatusBadRequest)
+			return
+		}
+		if n > 0 {
+			lines = n
+		}
+	}
+	if !isAdmin(requestUser(r)) {
+		lines = -1
+	}
+
+	status, err := serviceManager.GetStatus(r.PathValue("name"), lines)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// HandleServiceAction 对服务执行 start/stop/restart/reload/enable/disable 操作
+func HandleServiceAction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := requireAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	name := r.PathValue("name")
+	action := r.PathValue("action")
+	output, err := serviceManager.Control(name, action)
+
+	entry := services.AuditEntry{
+		User:    user,
+		Action:  "service." + action,
+		Target:  name,
+		Success: err == nil,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	auditService.Record(entry)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Service " + action + " executed successfully",
+		"output":  output,
+	})
+}
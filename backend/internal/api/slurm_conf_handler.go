@@ -0,0 +1,170 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"panel-tool/internal/services"
+)
+
+// HandleGetSlurmConfig 获取 slurm.conf 的结构化视图及校验结果
+func HandleGetSlurmConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	cluster, ok := requestCluster(w, r)
+	if !ok {
+		return
+	}
+
+	cfg, err := services.GetSlurmConfig(cluster)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cfg)
+}
+
+// HandleSlurmConfigFile 读取（GET）或修改（PUT）slurm.conf 及其 Include 的文件
+func HandleSlurmConfigFile(w http.ResponseWriter, r *http.Request) {
+	user, ok := requireAdmin(w, r)
+	if !ok {
+		return
+	}
+	cluster, ok := requestCluster(w, r)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		path, content, err := services.ReadSlurmConfFile(cluster, r.URL.Query().Get("path"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"path":    path,
+			"content": content,
+		})
+
+	case http.MethodPut:
+		var request services.SlurmConfUpdate
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+
+		result, err := services.UpdateSlurmConfFile(cluster, request)
+		target := request.File
+		if result != nil {
+			target = result.File
+		}
+		entry := services.AuditEntry{
+			User:    user,
+			Action:  "slurm.conf.update",
+			Target:  target,
+			Success: err == nil,
+		}
+		if result != nil && result.Backup != "" {
+			entry.Detail = "backup: " + result.Backup
+		}
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		auditService.Record(entry)
+
+		if err != nil && result == nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// 校验未通过或 reconfigure 失败时仍返回结果，便于前端展示问题列表
+		w.Header().Set("Content-Type", "application/json")
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error":  err.Error(),
+				"result": result,
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Slurm configuration updated successfully",
+			"result":  result,
+		})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleValidateSlurmConfig 校验修改后的配置内容但不写入
+func HandleValidateSlurmConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+	cluster, ok := requestCluster(w, r)
+	if !ok {
+		return
+	}
+
+	var request services.SlurmConfUpdate
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	issues, err := services.ValidateSlurmConfChange(cluster, request)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(issues)
+}
+
+// HandleReconfigureSlurm 执行 scontrol reconfigure
+func HandleReconfigureSlurm(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user, ok := requireAdmin(w, r)
+	if !ok {
+		return
+	}
+	cluster, ok := requestCluster(w, r)
+	if !ok {
+		return
+	}
+
+	output, err := services.ReconfigureSlurm(cluster)
+	entry := services.AuditEntry{
+		User:    user,
+		Action:  "slurm.reconfigure",
+		Target:  cluster.Name,
+		Success: err == nil,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	auditService.Record(entry)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Slurm reconfigured successfully",
+		"output":  output,
+	})
+}
@@ -0,0 +1,351 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"panel-tool/internal/models"
+	"panel-tool/internal/services"
+
+	"github.com/gorilla/websocket"
+)
+
+// HandleGetSlurmJobDetail 获取单个作业的详细信息，只允许作业所有者和管理员访问
+func HandleGetSlurmJobDetail(w http.ResponseWriter, r *http.Request) {
+	jobID := r.PathValue("id")
+	if !services.ValidJobID(jobID) {
+		http.Error(w, "Invalid job id", http.StatusBadRequest)
+		return
+	}
+	user, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+	cluster, ok := requestCluster(w, r)
+	if !ok {
+		return
+	}
+
+	detail, err := services.GetJobDetail(cluster, jobID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	// 面板以 root 读取批处理脚本，只允许作业所有者和管理员查看
+	if detail.User != user && !isAdmin(user) {
+		http.Error(w, "Forbidden: job belongs to another user", http.StatusForbidden)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(detail)
+}
+
+// HandleSlurmJobOutput 通过 WebSocket 实时推送作业的 stdout/stderr，只允许作业所有者和管理员访问
+// 浏览器无法为 WebSocket 设置请求头，token 可通过 token 查询参数传递
+func HandleSlurmJobOutput(w http.ResponseWriter, r *http.Request) {
+	jobID := r.PathValue("id")
+	if !services.ValidJobID(jobID) {
+		http.Error(w, "Invalid job id", http.StatusBadRequest)
+		return
+	}
+
+	stream := r.URL.Query().Get("stream")
+	if stream == "" {
+		stream = "stdout"
+	}
+	if stream != "stdout" && stream != "stderr" {
+		http.Error(w, "stream must be stdout or stderr", http.StatusBadRequest)
+		return
+	}
+	user, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+	cluster, ok := requestCluster(w, r)
+	if !ok {
+		return
+	}
+	// 输出文件以 root 读取，只允许作业所有者和管理员查看
+	if !isAdmin(user) {
+		owner, err := services.JobOwner(cluster, jobID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if owner != user {
+			http.Error(w, "Forbidden: job belongs to another user", http.StatusForbidden)
+			return
+		}
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		http.Error(w, "Failed to upgrade connection to WebSocket", http.StatusInternalServerError)
+		return
+	}
+	defer conn.Close()
+
+	// 客户端断开时通知读取协程退出
+	stop := make(chan struct{})
+	go func() {
+		defer close(stop)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	logChan := make(chan string, 100)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- services.FollowJobOutput(cluster, jobID, stream, stop, logChan)
+	}()
+
+	for data := range logChan {
+		msg, _ := json.Marshal(WebSocketMessage{Type: "output", Data: data})
+		if err := conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+			// 关闭连接使读取协程退出，进而停止跟踪
+			conn.Close()
+			<-errChan
+			return
+		}
+	}
+
+	if err := <-errChan; err != nil {
+		msg, _ := json.Marshal(WebSocketMessage{Type: "error", Data: err.Error()})
+		conn.WriteMessage(websocket.TextMessage, msg)
+		return
+	}
+
+	msg, _ := json.Marshal(WebSocketMessage{Type: "end", Data: fmt.Sprintf("job %s output finished", jobID)})
+	conn.WriteMessage(websocket.TextMessage, msg)
+}
+
+// HandleGetSlurmJobHistory 查询历史作业，支持筛选、分页、排序和 CSV 导出
+// cluster=all 时通过 sacct --clusters 查询所有集群的记录
+func HandleGetSlurmJobHistory(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	cluster, clusterNames, ok := requestHistoryClusters(w, r)
+	if !ok {
+		return
+	}
+
+	start, err := parseTimeParam(params.Get("start"))
+	if err != nil {
+		http.Error(w, "Invalid start time", http.StatusBadRequest)
+		return
+	}
+	end, err := parseTimeParam(params.Get("end"))
+	if err != nil {
+		http.Error(w, "Invalid end time", http.StatusBadRequest)
+		return
+	}
+
+	page, _ := strconv.Atoi(params.Get("page"))
+	pageSize, _ := strconv.Atoi(params.Get("page_size"))
+	query := &services.JobHistoryQuery{
+		Cluster:   cluster,
+		Clusters:  clusterNames,
+		Start:     start,
+		End:       end,
+		User:      params.Get("user"),
+		Account:   params.Get("account"),
+		Partition: params.Get("partition"),
+		State:     params.Get("state"),
+		ExitCode:  params.Get("exit_code"),
+		SortBy:    params.Get("sort"),
+		Order:     params.Get("order"),
+		Page:      page,
+		PageSize:  pageSize,
+	}
+
+	if params.Get("format") == "csv" {
+		records, err := services.QueryJobHistory(query)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		filename := fmt.Sprintf("jobs_%s_%s.csv", query.Start.Format("20060102"), query.End.Format("20060102"))
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+		services.WriteJobHistoryCSV(w, records)
+		return
+	}
+
+	result, err := services.GetJobHistoryPage(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// parseTimeParam 解析查询参数中的时间，支持日期、Slurm 时间格式和 RFC3339，空值返回零值
+func parseTimeParam(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range []string{"2006-01-02", "2006-01-02T15:04:05", "2006-01-02 15:04:05"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// HandleGetSlurmJobEfficiency 获取单个已结束作业的资源使用效率
+func HandleGetSlurmJobEfficiency(w http.ResponseWriter, r *http.Request) {
+	jobID := r.PathValue("id")
+	if !services.ValidJobID(jobID) {
+		http.Error(w, "Invalid job id", http.StatusBadRequest)
+		return
+	}
+	cluster, ok := requestCluster(w, r)
+	if !ok {
+		return
+	}
+
+	eff, err := services.GetJobEfficiency(cluster, jobID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(eff)
+}
+
+// HandleGetEfficiencyReport 获取时间范围内已结束作业的效率明细和按用户汇总
+func HandleGetEfficiencyReport(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	cluster, clusterNames, ok := requestHistoryClusters(w, r)
+	if !ok {
+		return
+	}
+
+	start, err := parseTimeParam(params.Get("start"))
+	if err != nil {
+		http.Error(w, "Invalid start time", http.StatusBadRequest)
+		return
+	}
+	end, err := parseTimeParam(params.Get("end"))
+	if err != nil {
+		http.Error(w, "Invalid end time", http.StatusBadRequest)
+		return
+	}
+
+	jobs, users, err := services.GetEfficiencyReport(&services.JobHistoryQuery{
+		Cluster:   cluster,
+		Clusters:  clusterNames,
+		Start:     start,
+		End:       end,
+		User:      params.Get("user"),
+		Account:   params.Get("account"),
+		Partition: params.Get("partition"),
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// wasteful=true 时只返回被标记为浪费的作业
+	if params.Get("wasteful") == "true" {
+		filtered := jobs[:0]
+		for _, job := range jobs {
+			if job.Wasteful {
+				filtered = append(filtered, job)
+			}
+		}
+		jobs = filtered
+	}
+	if jobs == nil {
+		jobs = []services.JobEfficiency{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"jobs":  jobs,
+		"users": users,
+	})
+}
+
+// HandleGetPartitions 获取所有分区的容量和作业情况，cluster=all 时合并所有集群的分区
+func HandleGetPartitions(w http.ResponseWriter, r *http.Request) {
+	clusters, ok := requestClusters(w, r)
+	if !ok {
+		return
+	}
+
+	// 分区数据来自采集器的共享快照，不在每次请求时执行 Slurm 命令
+	partitions := []models.PartitionModel{}
+	for _, cluster := range clusters {
+		partitions = append(partitions, clusterCollectors[cluster.Name].Partitions()...)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(partitions)
+}
+
+// HandleGetQOS 获取所有 QoS 及其限制
+func HandleGetQOS(w http.ResponseWriter, r *http.Request) {
+	cluster, ok := requestCluster(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := services.GetQOSList(cluster)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// HandleGetJobDependencyGraph 获取作业依赖图，可按用户（user）或作业（job）筛选
+func HandleGetJobDependencyGraph(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	jobID := query.Get("job")
+	if jobID != "" && !services.ValidJobID(jobID) {
+		http.Error(w, "Invalid job id", http.StatusBadRequest)
+		return
+	}
+	cluster, ok := requestCluster(w, r)
+	if !ok {
+		return
+	}
+
+	graph, err := services.GetJobDependencyGraph(cluster, query.Get("user"), jobID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(graph)
+}
+
+// HandleGetLicenses 获取 Slurm 许可证使用情况
+func HandleGetLicenses(w http.ResponseWriter, r *http.Request) {
+	cluster, ok := requestCluster(w, r)
+	if !ok {
+		return
+	}
+
+	licenses, err := services.GetLicenses(cluster)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(licenses)
+}
@@ -0,0 +1,335 @@
+// Package hostlist 实现与 Slurm 语义兼容的主机列表表达式解析与压缩
+// 例如 cn[001-003,010] 展开为 cn001,cn002,cn003,cn010，
+// rack[1-2]-cn[01-02] 展开为四个节点（多个方括号做笛卡尔积），
+// 方括号可以嵌套，如 cn[0[1-3],10] 展开为 cn01,cn02,cn03,cn10
+package hostlist
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MaxHosts 单个表达式允许展开的最大主机数，防止恶意表达式耗尽内存
+const MaxHosts = 1 << 20
+
+// Expand 展开主机列表表达式，保留原始顺序和重复项
+func Expand(expr string) ([]string, error) {
+	items, err := splitTopLevel(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := []string{}
+	for _, item := range items {
+		expanded, err := expandItem(item, MaxHosts-len(hosts), false)
+		if err != nil {
+			return nil, err
+		}
+		hosts = append(hosts, expanded...)
+	}
+	return hosts, nil
+}
+
+// splitTopLevel 按方括号外的逗号拆分表达式，同时检查括号是否匹配
+func splitTopLevel(expr string) ([]string, error) {
+	var items []string
+	depth := 0
+	start := 0
+	for i, c := range expr {
+		switch c {
+		case '[':
+			depth++
+		case ']':
+			if depth == 0 {
+				return nil, fmt.Errorf("主机列表方括号不匹配: %q", expr)
+			}
+			depth--
+		case ',':
+			if depth == 0 {
+				items = appendItem(items, expr[start:i])
+				start = i + 1
+			}
+		case ' ', '\t', '\n':
+			if depth > 0 {
+				return nil, fmt.Errorf("主机列表方括号内不能包含空白: %q", expr)
+			}
+			// 方括号外的空白与逗号等价
+			items = appendItem(items, expr[start:i])
+			start = i + 1
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("主机列表方括号不匹配: %q", expr)
+	}
+	return appendItem(items, expr[start:]), nil
+}
+
+// splitBracketList 按最外层的逗号拆分方括号内的内容，保留空片段以便报错；调用方保证括号匹配
+func splitBracketList(content string) []string {
+	var parts []string
+	depth := 0
+	start := 0
+	for i := 0; i < len(content); i++ {
+		switch content[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, content[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, content[start:])
+}
+
+// matchingBracket 返回与 open 处左方括号匹配的右方括号位置；调用方保证括号匹配
+func matchingBracket(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// appendItem 追加非空的表达式片段
+func appendItem(items []string, item string) []string {
+	if item = strings.TrimSpace(item); item != "" {
+		items = append(items, item)
+	}
+	return items
+}
+
+// expandItem 展开不含顶层逗号的单个表达式，多个方括号按从左到右做笛卡尔积
+// nested 为 true 时表示位于方括号内，此时方括号外的字面量只能是数字
+func expandItem(item string, limit int, nested bool) ([]string, error) {
+	results := []string{""}
+	rest := item
+	for rest != "" {
+		open := strings.IndexByte(rest, '[')
+		literal := rest
+		if open >= 0 {
+			literal = rest[:open]
+		}
+		if nested && literal != "" && !isDigits(literal) {
+			return nil, fmt.Errorf("嵌套方括号内只能包含数字: %q", item)
+		}
+		results = appendSuffix(results, literal)
+		if open < 0 {
+			break
+		}
+
+		closing := matchingBracket(rest, open)
+		values, err := expandBracket(rest[open+1:closing], limit)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %q", err, item)
+		}
+		if len(results)*len(values) > limit {
+			return nil, fmt.Errorf("主机列表展开后超过 %d 个主机: %q", MaxHosts, item)
+		}
+
+		product := make([]string, 0, len(results)*len(values))
+		for _, prefix := range results {
+			for _, value := range values {
+				product = append(product, prefix+value)
+			}
+		}
+		results = product
+		rest = rest[closing+1:]
+	}
+	return results, nil
+}
+
+// appendSuffix 为每个结果追加相同的字面量
+func appendSuffix(results []string, suffix string) []string {
+	if suffix == "" {
+		return results
+	}
+	for i := range results {
+		results[i] += suffix
+	}
+	return results
+}
+
+// expandBracket 展开方括号内的范围列表，如 001-003,010；补零宽度取自每个范围的下界
+// 含方括号的片段（如 0[1-3]）递归展开
+func expandBracket(content string, limit int) ([]string, error) {
+	if content == "" {
+		return nil, fmt.Errorf("方括号内容为空")
+	}
+
+	var values []string
+	for _, part := range splitBracketList(content) {
+		if strings.Contains(part, "[") {
+			nested, err := expandItem(part, limit-len(values), true)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, nested...)
+			continue
+		}
+
+		lowStr, highStr, isRange := strings.Cut(part, "-")
+		if !isRange {
+			highStr = lowStr
+		}
+		if !isDigits(lowStr) || !isDigits(highStr) {
+			return nil, fmt.Errorf("无效的范围 %q", part)
+		}
+
+		low, err := strconv.ParseUint(lowStr, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("无效的范围 %q", part)
+		}
+		high, err := strconv.ParseUint(highStr, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("无效的范围 %q", part)
+		}
+		if low > high {
+			return nil, fmt.Errorf("范围下界大于上界 %q", part)
+		}
+		if high-low >= MaxHosts || len(values)+int(high-low) >= limit {
+			return nil, fmt.Errorf("范围过大 %q", part)
+		}
+
+		width := len(lowStr)
+		for n := low; ; n++ {
+			values = append(values, pad(n, width))
+			if n == high {
+				break
+			}
+		}
+	}
+	return values, nil
+}
+
+// isDigits 判断字符串是否全部由数字组成
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// pad 将数字补零到指定宽度
+func pad(n uint64, width int) string {
+	s := strconv.FormatUint(n, 10)
+	if len(s) < width {
+		s = strings.Repeat("0", width-len(s)) + s
+	}
+	return s
+}
+
+// host 拆分后的主机名：前缀 + 末尾数字
+type host struct {
+	name   string
+	prefix string
+	digits string
+	num    uint64
+}
+
+// splitHost 拆分主机名末尾的数字，没有数字后缀时 digits 为空
+func splitHost(name string) host {
+	i := len(name)
+	for i > 0 && name[i-1] >= '0' && name[i-1] <= '9' {
+		i--
+	}
+	h := host{name: name, prefix: name[:i], digits: name[i:]}
+	if h.digits != "" {
+		n, err := strconv.ParseUint(h.digits, 10, 64)
+		if err != nil {
+			// 数字过长无法压缩，整体视为普通名称
+			return host{name: name, prefix: name}
+		}
+		h.num = n
+	}
+	return h
+}
+
+// Compress 将主机名压缩为表达式，结果去重并按数字排序，前缀按首次出现的顺序排列
+// 只压缩末尾的数字，保证 Expand(Compress(names)) 与去重排序后的 names 一致
+func Compress(names []string) string {
+	// plain 为 true 表示无数字后缀的主机，各自成组，不能与同名前缀合并
+	type groupKey struct {
+		prefix string
+		plain  bool
+	}
+	groups := make(map[groupKey][]host)
+	var prefixes []groupKey
+	seen := make(map[string]bool)
+
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		h := splitHost(name)
+		key := groupKey{prefix: h.prefix, plain: h.digits == ""}
+		if _, ok := groups[key]; !ok {
+			prefixes = append(prefixes, key)
+		}
+		groups[key] = append(groups[key], h)
+	}
+
+	parts := make([]string, 0, len(prefixes))
+	for _, key := range prefixes {
+		hosts := groups[key]
+		if hosts[0].digits == "" {
+			parts = append(parts, hosts[0].name)
+			continue
+		}
+		parts = append(parts, compressGroup(hosts))
+	}
+	return strings.Join(parts, ",")
+}
+
+// compressGroup 压缩同一前缀的主机，只有当展开结果与原名完全一致时才合并为范围
+func compressGroup(hosts []host) string {
+	sort.Slice(hosts, func(i, j int) bool {
+		if hosts[i].num != hosts[j].num {
+			return hosts[i].num < hosts[j].num
+		}
+		return len(hosts[i].digits) < len(hosts[j].digits)
+	})
+
+	var ranges []string
+	for i := 0; i < len(hosts); {
+		start := hosts[i]
+		width := len(start.digits)
+		j := i + 1
+		for j < len(hosts) && hosts[j].num == hosts[j-1].num+1 && hosts[j].digits == pad(hosts[j].num, width) {
+			j++
+		}
+		if j-1 > i {
+			ranges = append(ranges, start.digits+"-"+hosts[j-1].digits)
+		} else {
+			ranges = append(ranges, start.digits)
+		}
+		i = j
+	}
+
+	prefix := hosts[0].prefix
+	if len(ranges) == 1 && !strings.Contains(ranges[0], "-") {
+		return prefix + ranges[0]
+	}
+	return prefix + "[" + strings.Join(ranges, ",") + "]"
+}
@@ -0,0 +1,147 @@
+package hostlist
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		want []string
+	}{
+		{"single", "cn001", []string{"cn001"}},
+		{"list", "cn1,cn3 gpu1", []string{"cn1", "cn3", "gpu1"}},
+		{"range", "cn[1-3]", []string{"cn1", "cn2", "cn3"}},
+		{"zero padding", "cn[008-011]", []string{"cn008", "cn009", "cn010", "cn011"}},
+		{"padding per range", "cn[01-02,7,010]", []string{"cn01", "cn02", "cn7", "cn010"}},
+		{"padding grows past width", "cn[98-100]", []string{"cn98", "cn99", "cn100"}},
+		{"multiple brackets", "rack[1-2]-cn[01-02]", []string{"rack1-cn01", "rack1-cn02", "rack2-cn01", "rack2-cn02"}},
+		{"suffix after bracket", "cn[1-2]-ib", []string{"cn1-ib", "cn2-ib"}},
+		{"mixed items", "cn[1-2],gpu[01-02]", []string{"cn1", "cn2", "gpu01", "gpu02"}},
+		{"nested", "cn[0[1-3],10]", []string{"cn01", "cn02", "cn03", "cn10"}},
+		{"nested twice", "cn[1[0,1[8-9]]]", []string{"cn10", "cn118", "cn119"}},
+		{"nested product", "cn[[1-2][7-8]]", []string{"cn17", "cn18", "cn27", "cn28"}},
+		{"duplicates kept", "cn1,cn1", []string{"cn1", "cn1"}},
+		{"empty", "", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Expand(tt.expr)
+			if err != nil {
+				t.Fatalf("Expand(%q) error: %v", tt.expr, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expand(%q) = %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandMalformed(t *testing.T) {
+	tests := []string{
+		"cn[1-3",
+		"cn1-3]",
+		"cn[]",
+		"cn[3-1]",
+		"cn[a-b]",
+		"cn[1,,2]",
+		"cn[1-]",
+		"cn[-1]",
+		"cn[1 - 2]",
+		"cn[[1-2]",
+		"cn[x[1-2]]",
+		"cn[1-99999999999999999999]",
+		"cn[0-2000000]",
+		"a[0-1023]b[0-1023]c[0-1]",
+	}
+	for _, expr := range tests {
+		if hosts, err := Expand(expr); err == nil {
+			t.Errorf("Expand(%q) = %d hosts, want error", expr, len(hosts))
+		}
+	}
+}
+
+func TestCompress(t *testing.T) {
+	tests := []struct {
+		name  string
+		names []string
+		want  string
+	}{
+		{"single", []string{"cn001"}, "cn001"},
+		{"range", []string{"cn3", "cn1", "cn2"}, "cn[1-3]"},
+		{"zero padding", []string{"cn008", "cn009", "cn010", "cn012"}, "cn[008-010,012]"},
+		{"mixed widths", []string{"cn1", "cn01", "cn2"}, "cn[1,01,2]"},
+		{"padding grows", []string{"cn98", "cn99", "cn100"}, "cn[98-100]"},
+		{"prefix order", []string{"gpu2", "cn1", "gpu1"}, "gpu[1-2],cn1"},
+		{"no digits", []string{"login", "cn1", "login"}, "login,cn1"},
+		{"empty", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Compress(tt.names); got != tt.want {
+				t.Errorf("Compress(%v) = %q, want %q", tt.names, got, tt.want)
+			}
+		})
+	}
+}
+
+// FuzzExpandCompress 检查压缩后再展开不丢失、不重复主机，且对展开结果再次压缩、展开得到完全相同的列表
+func FuzzExpandCompress(f *testing.F) {
+	for _, seed := range []string{
+		"cn[001-003,010]",
+		"rack[1-2]-cn[01-02]",
+		"cn[0[1-3],10]",
+		"cn1,cn01,cn001,login",
+		"gpu[8-12],cn[98-101]",
+		"cn18446744073709551615,cn99999999999999999999",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, expr string) {
+		names, err := Expand(expr)
+		if err != nil {
+			// 不是合法表达式时按普通主机名列表处理
+			names = strings.Split(expr, ",")
+		}
+		if len(names) > 1<<14 {
+			// 大规模展开已由 TestExpandMalformed 覆盖，跳过以保持模糊测试的速度
+			t.Skip()
+		}
+		var x []string
+		for _, name := range names {
+			name = strings.TrimSpace(name)
+			if name != "" && !strings.ContainsAny(name, "[], \t\n\r\v\f") {
+				x = append(x, name)
+			}
+		}
+
+		compressed := Compress(x)
+		expanded, err := Expand(compressed)
+		if err != nil {
+			t.Fatalf("Expand(Compress(%q)) = %q: %v", x, compressed, err)
+		}
+		seen := make(map[string]bool)
+		for _, name := range expanded {
+			if seen[name] {
+				t.Fatalf("Expand(%q) contains duplicate %q", compressed, name)
+			}
+			seen[name] = true
+		}
+		for _, name := range x {
+			if !seen[name] {
+				t.Fatalf("Expand(Compress(%q)) = %q, missing %q", x, expanded, name)
+			}
+		}
+		if len(seen) != len(expanded) || len(expanded) > len(x) {
+			t.Fatalf("Expand(Compress(%q)) = %q, has extra hosts", x, expanded)
+		}
+
+		again, err := Expand(Compress(expanded))
+		if err != nil || !reflect.DeepEqual(again, expanded) {
+			t.Fatalf("Expand(Compress(%q)) = %q, %v", expanded, again, err)
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// AgentHardware 计算节点代理上报的硬件信息（不含 GPU）
+type AgentHardware struct {
+	Vendor       string      `json:"vendor"`
+	Product      string      `json:"product"`
+	CPUModel     string      `json:"cpu_model"`
+	Architecture string      `json:"architecture"`
+	Topology     CPUTopology `json:"topology"`
+	MemoryTotal  int64       `json:"memory_total"` // 字节
+	OSVersion    string      `json:"os_version"`
+	Kernel       string      `json:"kernel"`
+}
+
+// MountHealth 挂载点健康检查结果，Healthy 为 false 时 Error 说明原因（如 NFS 无响应、只读）
+type MountHealth struct {
+	Mount    string  `json:"mount"`
+	Device   string  `json:"device,omitempty"`
+	FSType   string  `json:"fs_type,omitempty"`
+	Healthy  bool    `json:"healthy"`
+	ReadOnly bool    `json:"read_only"`
+	Latency  float64 `json:"latency"` // 毫秒
+	Error    string  `json:"error,omitempty"`
+}
+
+// AgentReport 计算节点代理的一次上报
+type AgentReport struct {
+	Hostname  string          `json:"hostname"`
+	Cluster   string          `json:"cluster,omitempty"`
+	Version   string          `json:"version"`
+	Time      time.Time       `json:"time"`
+	Interval  float64         `json:"interval"` // 上报间隔（秒）
+	Hardware  AgentHardware   `json:"hardware"`
+	Telemetry HostTelemetry   `json:"telemetry"`
+	Mounts    []MountHealth   `json:"mounts"`
+	Sensors   []SensorReading `json:"sensors"`
+	// Inventory 为硬件清单，仅在代理启动后的首次上报及之后每小时附带一次
+	Inventory *HardwareInventory `json:"inventory,omitempty"`
+}
+
+// NodeAgentStatus 节点代理的状态，Stale 表示超过时限未收到上报，此时节点指标回退为 Slurm 数据
+type NodeAgentStatus struct {
+	Version  string          `json:"version"`
+	LastSeen time.Time       `json:"last_seen"`
+	Stale    bool            `json:"stale"`
+	Hardware AgentHardware   `json:"hardware"`
+	Mounts   []MountHealth   `json:"mounts"`
+	Load     LoadAverage     `json:"load"`
+	Memory   MemoryStats     `json:"memory"`
+	Disks    []DiskUsage     `json:"disks"`
+	Network  []NetworkIO     `json:"network"`
+	Sensors  []SensorReading `json:"sensors"`
+}
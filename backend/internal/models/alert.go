@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// AlertRule 告警规则：对 Target 类型的每个对象计算 Expr，持续满足 For 后触发
+// Summary 中的 {{字段}} 会替换为对象的字段值
+type AlertRule struct {
+	Name     string `json:"name"`
+	Target   string `json:"target"` // node、partition、cluster、disk、mount、service、sensor、metric
+	Expr     string `json:"expr"`
+	For      string `json:"for,omitempty"` // 持续时间，如 5m、1h，为空时满足条件即触发
+	Severity string `json:"severity"`      // warning 或 critical
+	Summary  string `json:"summary"`
+	Disabled bool   `json:"disabled,omitempty"`
+}
+
+// Alert 一条告警实例，同一规则下标签相同的对象只对应一条告警（去重）
+// State 为 pending（条件已满足但未达到持续时间）、firing 或 resolved
+type Alert struct {
+	ID          string            `json:"id"`
+	Rule        string            `json:"rule"`
+	Severity    string            `json:"severity"`
+	State       string            `json:"state"`
+	Labels      map[string]string `json:"labels"`
+	Summary     string            `json:"summary"`
+	ActiveSince time.Time         `json:"active_since"`
+	FiredAt     *time.Time        `json:"fired_at,omitempty"`
+	ResolvedAt  *time.Time        `json:"resolved_at,omitempty"`
+	LastEval    time.Time         `json:"last_eval"`
+	Silenced    bool              `json:"silenced"`
+	SilencedBy  string            `json:"silenced_by,omitempty"`
+}
+
+// Silence 静默：时间范围内匹配所有条件的告警不发送通知
+// Matchers 的键为 rule、severity 或标签名，值支持 * 通配符
+type Silence struct {
+	ID        string            `json:"id"`
+	Matchers  map[string]string `json:"matchers"`
+	StartsAt  time.Time         `json:"starts_at"`
+	EndsAt    time.Time         `json:"ends_at"`
+	CreatedBy string            `json:"created_by"`
+	Comment   string            `json:"comment"`
+}
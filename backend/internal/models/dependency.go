@@ -0,0 +1,46 @@
+package models
+
+// JobDependency 作业的一条依赖，如 afterok:123
+type JobDependency struct {
+	Type   string `json:"type"`             // after、afterok、afternotok、afterany、aftercorr、singleton 等
+	JobID  string `json:"job_id,omitempty"` // 被依赖的作业，数组作业可能为 123_* 或 123_4
+	Status string `json:"status,omitempty"` // squeue 给出的状态，如 unfulfilled、failed
+}
+
+// JobGraphNode 依赖图中的作业节点，数组作业的所有任务合并为一个节点
+type JobGraphNode struct {
+	JobID        string          `json:"job_id"`
+	Name         string          `json:"name"`
+	User         string          `json:"user"`
+	Partition    string          `json:"partition"`
+	State        string          `json:"state"`
+	Reason       string          `json:"reason,omitempty"`
+	InQueue      bool            `json:"in_queue"` // false 表示作业已离开队列，信息来自 sacct
+	IsArray      bool            `json:"is_array"`
+	ArrayTasks   int             `json:"array_tasks,omitempty"`
+	ArrayStates  map[string]int  `json:"array_states,omitempty"` // 各状态的任务数
+	Dependencies []JobDependency `json:"dependencies"`
+	Blocked      bool            `json:"blocked"` // 依赖永远无法满足，或依赖链上游已被阻塞
+}
+
+// JobGraphEdge 依赖关系，From 为被依赖的作业，To 为等待它的作业
+type JobGraphEdge struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Type   string `json:"type"`
+	Status string `json:"status,omitempty"`
+}
+
+// BlockedChain 因依赖永远无法满足而停滞的作业链
+type BlockedChain struct {
+	JobID    string          `json:"job_id"`   // 处于 DependencyNeverSatisfied 的作业
+	Causes   []JobDependency `json:"causes"`   // 导致无法满足的依赖
+	Affected []string        `json:"affected"` // 下游同样无法开始的作业
+}
+
+// JobDependencyGraph 作业依赖图
+type JobDependencyGraph struct {
+	Nodes         []JobGraphNode `json:"nodes"`
+	Edges         []JobGraphEdge `json:"edges"`
+	BlockedChains []BlockedChain `json:"blocked_chains"`
+}
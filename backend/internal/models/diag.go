@@ -0,0 +1,74 @@
+package models
+
+import "time"
+
+// SchedulerDiag 定义 sdiag 输出的结构化数据，周期时间单位为微秒
+type SchedulerDiag struct {
+	Time              time.Time `json:"time"`
+	DataSince         time.Time `json:"data_since"`
+	ServerThreadCount int64     `json:"server_thread_count"`
+	AgentQueueSize    int64     `json:"agent_queue_size"`
+	AgentCount        int64     `json:"agent_count"`
+	AgentThreadCount  int64     `json:"agent_thread_count"`
+	DBDAgentQueueSize int64     `json:"dbd_agent_queue_size"`
+
+	JobsSubmitted int64 `json:"jobs_submitted"`
+	JobsStarted   int64 `json:"jobs_started"`
+	JobsCompleted int64 `json:"jobs_completed"`
+	JobsCanceled  int64 `json:"jobs_canceled"`
+	JobsFailed    int64 `json:"jobs_failed"`
+	JobsPending   int64 `json:"jobs_pending"`
+	JobsRunning   int64 `json:"jobs_running"`
+
+	MainSchedule SchedulerCycleStats `json:"main_schedule"`
+	Backfill     SchedulerCycleStats `json:"backfill"`
+	// TotalBackfilledJobs 自 slurmctld 启动以来通过回填调度启动的作业数
+	TotalBackfilledJobs int64 `json:"total_backfilled_jobs"`
+
+	RPCByType   []RPCStat `json:"rpc_by_type"`
+	RPCByUser   []RPCStat `json:"rpc_by_user"`
+	PendingRPCs int64     `json:"pending_rpcs"`
+}
+
+// SchedulerCycleStats 主调度或回填调度的周期统计
+type SchedulerCycleStats struct {
+	TotalCycles     int64 `json:"total_cycles"`
+	LastCycle       int64 `json:"last_cycle"`
+	MaxCycle        int64 `json:"max_cycle"`
+	MeanCycle       int64 `json:"mean_cycle"`
+	MeanDepthCycle  int64 `json:"mean_depth_cycle,omitempty"` // 仅主调度
+	LastDepthCycle  int64 `json:"last_depth_cycle,omitempty"` // 仅回填调度
+	CyclesPerMinute int64 `json:"cycles_per_minute,omitempty"`
+	LastQueueLength int64 `json:"last_queue_length"`
+}
+
+// RPCStat 按消息类型或用户统计的 RPC 次数，时间单位为微秒
+type RPCStat struct {
+	Name      string `json:"name"`
+	ID        int64  `json:"id"` // 消息类型编号或用户 UID
+	Count     int64  `json:"count"`
+	AveTime   int64  `json:"ave_time"`
+	TotalTime int64  `json:"total_time"`
+}
+
+// SchedulerDiagPoint 调度器诊断的历史趋势点
+type SchedulerDiagPoint struct {
+	Time              time.Time `json:"time"`
+	MainLastCycle     int64     `json:"main_last_cycle"`
+	BackfillLastCycle int64     `json:"backfill_last_cycle"`
+	JobsPending       int64     `json:"jobs_pending"`
+	JobsRunning       int64     `json:"jobs_running"`
+	AgentQueueSize    int64     `json:"agent_queue_size"`
+	ServerThreadCount int64     `json:"server_thread_count"`
+	RPCRate           float64   `json:"rpc_rate"` // 与上一个采样点之间的每秒 RPC 数
+}
+
+// RPCUserAlert 频繁调用 slurmctld 的用户
+type RPCUserAlert struct {
+	User   string  `json:"user"`
+	UID    int64   `json:"uid"`
+	Count  int64   `json:"count"`
+	Rate   float64 `json:"rate"`  // 每秒 RPC 数
+	Share  float64 `json:"share"` // 占全部用户 RPC 的百分比
+	Reason string  `json:"reason"`
+}
@@ -0,0 +1,19 @@
+package models
+
+// GresResource 定义通用资源（GRES）数量，如 gpu:a100:4、shard:8、mps:100
+type GresResource struct {
+	Name  string `json:"name"`           // gpu、shard、mps 等
+	Type  string `json:"type,omitempty"` // 如 a100，未区分型号时为空
+	Count int64  `json:"count"`
+	Used  int64  `json:"used"`
+}
+
+// LicenseModel 定义 Slurm 许可证使用情况
+type LicenseModel struct {
+	Name     string `json:"name"`
+	Total    int64  `json:"total"`
+	Used     int64  `json:"used"`
+	Free     int64  `json:"free"`
+	Reserved int64  `json:"reserved"`
+	Remote   bool   `json:"remote"`
+}
@@ -0,0 +1,76 @@
+package models
+
+import "time"
+
+// DMIInfo 主板与固件信息，来源于 /sys/devices/virtual/dmi/id（序列号需 root 权限读取）
+type DMIInfo struct {
+	Vendor      string `json:"vendor"`
+	Product     string `json:"product"`
+	Serial      string `json:"serial"`
+	BoardVendor string `json:"board_vendor"`
+	BoardName   string `json:"board_name"`
+	BIOSVendor  string `json:"bios_vendor"`
+	BIOSVersion string `json:"bios_version"`
+	BIOSDate    string `json:"bios_date"`
+}
+
+// MemoryModule 内存条，来源于 EDAC（/sys/devices/system/edac）或 dmidecode
+type MemoryModule struct {
+	Locator      string `json:"locator"`
+	Size         int64  `json:"size"` // 字节
+	Type         string `json:"type,omitempty"`
+	Speed        string `json:"speed,omitempty"`
+	Manufacturer string `json:"manufacturer,omitempty"`
+	Serial       string `json:"serial,omitempty"`
+	PartNumber   string `json:"part_number,omitempty"`
+}
+
+// BlockDevice 物理磁盘，来源于 /sys/block
+type BlockDevice struct {
+	Name       string `json:"name"`
+	Size       int64  `json:"size"` // 字节
+	Model      string `json:"model,omitempty"`
+	Vendor     string `json:"vendor,omitempty"`
+	Serial     string `json:"serial,omitempty"`
+	Rotational bool   `json:"rotational"`
+	Removable  bool   `json:"removable"`
+}
+
+// NetworkInterface 物理网卡，来源于 /sys/class/net
+type NetworkInterface struct {
+	Name      string `json:"name"`
+	MAC       string `json:"mac"`
+	Speed     int    `json:"speed"` // Mb/s，链路未连接时为 0
+	MTU       int    `json:"mtu"`
+	OperState string `json:"oper_state"`
+	Driver    string `json:"driver,omitempty"`
+}
+
+// HardwareInventory 节点硬件清单
+type HardwareInventory struct {
+	Hostname    string             `json:"hostname"`
+	Cluster     string             `json:"cluster,omitempty"`
+	CollectedAt time.Time          `json:"collected_at"`
+	BootTime    time.Time          `json:"boot_time"`
+	DMI         DMIInfo            `json:"dmi"`
+	CPUModel    string             `json:"cpu_model"`
+	Topology    CPUTopology        `json:"topology"`
+	MemoryTotal int64              `json:"memory_total"` // 字节，来自 /proc/meminfo
+	Memory      []MemoryModule     `json:"memory"`
+	Disks       []BlockDevice      `json:"disks"`
+	NICs        []NetworkInterface `json:"nics"`
+}
+
+// InventoryChange 两次硬件清单之间的差异，Kind 为 added、removed 或 changed
+type InventoryChange struct {
+	Time      time.Time `json:"time"`
+	Hostname  string    `json:"hostname"`
+	Cluster   string    `json:"cluster,omitempty"`
+	Component string    `json:"component"` // dmi、cpu、memory、disk、nic、nic_speed
+	Key       string    `json:"key"`       // 内存插槽、磁盘名、网卡名或字段名
+	Kind      string    `json:"kind"`
+	Old       string    `json:"old,omitempty"`
+	New       string    `json:"new,omitempty"`
+	// Reboot 表示变化发生在两次启动之间，如重启后少了一根内存条
+	Reboot bool `json:"reboot"`
+}
@@ -10,4 +10,80 @@ type JobModel struct {
 	ComputeTime    string    `json:"compute_time"`
 	User           string    `json:"user"`
 	Status         string    `json:"status"`
-}
\ No newline at end of file
+	Name           string    `json:"name"`
+	Partition      string    `json:"partition"`
+	NodeList       string    `json:"node_list"`
+	Priority       int64     `json:"priority"`
+	// Cluster 为作业所属集群，仅在多集群聚合时填写
+	Cluster string `json:"cluster,omitempty"`
+	// Gres 为作业请求（或已分配）的通用资源，GPUs 为 GPU 总数
+	Gres []GresResource `json:"gres,omitempty"`
+	GPUs int64          `json:"gpus,omitempty"`
+	// 以下字段仅对排队中的作业有效
+	Reason            string     `json:"reason,omitempty"`
+	ReasonDescription string     `json:"reason_description,omitempty"`
+	QueuePosition     int        `json:"queue_position,omitempty"`
+	ExpectedStart     *time.Time `json:"expected_start,omitempty"`
+}
+
+// JobDetail 定义单个作业的完整信息，来源于 scontrol show job 与 sacct
+type JobDetail struct {
+	JobID       string                `json:"job_id"`
+	ArrayJobID  string                `json:"array_job_id,omitempty"`
+	ArrayTaskID string                `json:"array_task_id,omitempty"`
+	Name        string                `json:"name"`
+	User        string                `json:"user"`
+	Group       string                `json:"group"`
+	Account     string                `json:"account"`
+	Partition   string                `json:"partition"`
+	QOS         string                `json:"qos"`
+	State       string                `json:"state"`
+	Reason      string                `json:"reason"`
+	Dependency  string                `json:"dependency"`
+	Priority    int64                 `json:"priority"`
+	ExitCode    string                `json:"exit_code"`
+	NodeList    string                `json:"node_list"`
+	BatchHost   string                `json:"batch_host"`
+	NumNodes    int64                 `json:"num_nodes"`
+	NumCPUs     int64                 `json:"num_cpus"`
+	NumTasks    int64                 `json:"num_tasks"`
+	TRES        string                `json:"tres"`
+	Gres        []GresResource        `json:"gres,omitempty"`
+	GPUs        int64                 `json:"gpus,omitempty"`
+	TimeLimit   string                `json:"time_limit"`
+	RunTime     string                `json:"run_time"`
+	SubmitTime  time.Time             `json:"submit_time"`
+	StartTime   time.Time             `json:"start_time"`
+	EndTime     time.Time             `json:"end_time"`
+	WorkDir     string                `json:"work_dir"`
+	Command     string                `json:"command"`
+	StdOut      string                `json:"stdout"`
+	StdErr      string                `json:"stderr"`
+	InQueue     bool                  `json:"in_queue"`
+	BatchScript string                `json:"batch_script,omitempty"`
+	Accounting  []JobAccountingRecord `json:"accounting,omitempty"`
+	Fields      map[string]string     `json:"fields,omitempty"`
+}
+
+// JobAccountingRecord 定义 sacct 返回的作业或作业步记录
+type JobAccountingRecord struct {
+	JobID     string    `json:"job_id"`
+	JobName   string    `json:"job_name"`
+	User      string    `json:"user"`
+	Account   string    `json:"account"`
+	Partition string    `json:"partition"`
+	State     string    `json:"state"`
+	ExitCode  string    `json:"exit_code"`
+	Submit    time.Time `json:"submit"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Elapsed   int64     `json:"elapsed"`   // 秒
+	TotalCPU  float64   `json:"total_cpu"` // 秒
+	AllocCPUs int64     `json:"alloc_cpus"`
+	AllocTRES string    `json:"alloc_tres"`
+	ReqMem    int64     `json:"req_mem"` // 字节
+	MaxRSS    int64     `json:"max_rss"` // 字节
+	NodeList  string    `json:"node_list"`
+	Cluster   string    `json:"cluster,omitempty"`
+	UID       string    `json:"uid,omitempty"` // 作业所有者的 UID，作业步记录为空
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// MetricSample 一次采集得到的指标值
+// Target 为计算节点的 "<集群>/<主机名>"、管理节点的主机名，或集群名（作业数指标）
+type MetricSample struct {
+	Metric string  `json:"metric"`
+	Target string  `json:"target"`
+	Value  float64 `json:"value"`
+}
+
+// MetricPoint 时间序列中的一个点，Time 为所在时间桶的起点
+type MetricPoint struct {
+	Time  time.Time `json:"t"`
+	Value float64   `json:"v"`
+}
+
+// MetricSeries 一个指标在某个对象上的时间序列，Cluster 与 Host 由 Target 拆分得到
+type MetricSeries struct {
+	Metric     string        `json:"metric"`
+	Target     string        `json:"target"`
+	Cluster    string        `json:"cluster,omitempty"`
+	Host       string        `json:"host,omitempty"`
+	Resolution string        `json:"resolution"`
+	Points     []MetricPoint `json:"points"`
+}
+
+// MetricTarget 指标目录中的一个对象
+type MetricTarget struct {
+	Target  string `json:"target"`
+	Cluster string `json:"cluster,omitempty"`
+	Host    string `json:"host,omitempty"`
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // NodeModel 定义节点数据结构
 type NodeModel struct {
 	Hostname     string  `json:"hostname"`
@@ -8,6 +10,40 @@ type NodeModel struct {
 	IP           string  `json:"ip"`
 	CPUUsage     float64 `json:"cpu_usage"`
 	MemoryUsage  float64 `json:"memory_usage"`
+
+	// Slurm 节点状态，如 idle、mixed、allocated、down；flags 为 DRAIN、NOT_RESPONDING 等附加标志
+	State      string   `json:"state"`
+	StateFlags []string `json:"state_flags,omitempty"`
+	Partitions []string `json:"partitions"`
+	NodeAddr   string   `json:"node_addr"`
+
+	CPUTotal int     `json:"cpu_total"`
+	CPUAlloc int     `json:"cpu_alloc"`
+	CPULoad  float64 `json:"cpu_load"`
+
+	// 内存单位均为 MB
+	RealMemory  int64 `json:"real_memory"`
+	AllocMemory int64 `json:"alloc_memory"`
+	FreeMemory  int64 `json:"free_memory"`
+	UsedMemory  int64 `json:"used_memory"`
+
+	Gres     string    `json:"gres,omitempty"`
+	Features []string  `json:"features,omitempty"`
+	Reason   string    `json:"reason,omitempty"`
+	BootTime time.Time `json:"boot_time"`
+
+	// GresList 为按名称和型号拆分后的通用资源及已使用数量
+	GresList []GresResource `json:"gres_list,omitempty"`
+	GPUTotal int64          `json:"gpu_total"`
+	GPUAlloc int64          `json:"gpu_alloc"`
+
+	// Cluster 为节点所属集群，仅在多集群聚合时填写
+	Cluster string `json:"cluster,omitempty"`
+
+	// MetricsSource 为 CPU 与内存使用率的来源：agent 为节点代理实测值，slurm 为分配比例
+	// Agent 为节点代理的上报状态，未部署代理时为空
+	MetricsSource string           `json:"metrics_source"`
+	Agent         *NodeAgentStatus `json:"agent,omitempty"`
 }
 
 type ManagementNode struct {
@@ -19,4 +55,6 @@ type ManagementNode struct {
 	KernelVersion string `json:"kernel_version"`
 	LocalTime    string `json:"local_time"`
 	Uptime       string `json:"uptime"`
+	// Telemetry 为实时指标，采集失败时为空
+	Telemetry *HostTelemetry `json:"telemetry,omitempty"`
 }
\ No newline at end of file
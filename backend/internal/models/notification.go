@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// NotificationSubscription 用户的作业通知订阅
+// JobID 不为空时只关注该作业，否则关注 JobUser 的所有作业
+type NotificationSubscription struct {
+	ID           string    `json:"id"`
+	User         string    `json:"user"`     // 面板用户，通知收件人
+	JobUser      string    `json:"job_user"` // 匹配的 Slurm 用户，默认与 User 相同
+	JobID        string    `json:"job_id,omitempty"`
+	Cluster      string    `json:"cluster,omitempty"` // 为空时匹配所有集群
+	FailuresOnly bool      `json:"failures_only"`
+	Channels     []string  `json:"channels"` // inbox、email、webhook
+	Email        string    `json:"email,omitempty"`
+	WebhookURL   string    `json:"webhook_url,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Notification 面板站内信中的一条通知
+type Notification struct {
+	ID      string    `json:"id"`
+	User    string    `json:"user"`
+	Time    time.Time `json:"time"`
+	Title   string    `json:"title"`
+	Message string    `json:"message"`
+	Cluster string    `json:"cluster,omitempty"`
+	JobID   string    `json:"job_id,omitempty"`
+	JobName string    `json:"job_name,omitempty"`
+	State   string    `json:"state,omitempty"`
+	Failed  bool      `json:"failed"`
+	Read    bool      `json:"read"`
+}
@@ -0,0 +1,49 @@
+package models
+
+// PartitionModel 定义Slurm分区数据结构
+type PartitionModel struct {
+	Name        string `json:"name"`
+	Cluster     string `json:"cluster,omitempty"`
+	State       string `json:"state"`
+	Default     bool   `json:"default"`
+	Nodes       string `json:"nodes"`
+	TotalNodes  int64  `json:"total_nodes"`
+	TotalCPUs   int64  `json:"total_cpus"`
+	IdleCPUs    int64  `json:"idle_cpus"`
+	AllocCPUs   int64  `json:"alloc_cpus"`
+	OtherCPUs   int64  `json:"other_cpus"`
+	MaxTime     string `json:"max_time"`
+	DefaultTime string `json:"default_time"`
+	// 内存限制单位为 MB，0 表示未限制；PerCPU 与 PerNode 二者通常只设置其一
+	DefMemPerCPU  int64    `json:"def_mem_per_cpu"`
+	DefMemPerNode int64    `json:"def_mem_per_node"`
+	MaxMemPerCPU  int64    `json:"max_mem_per_cpu"`
+	MaxMemPerNode int64    `json:"max_mem_per_node"`
+	AllowAccounts []string `json:"allow_accounts"`
+	DenyAccounts  []string `json:"deny_accounts,omitempty"`
+	AllowQOS      []string `json:"allow_qos"`
+	QOS           string   `json:"qos,omitempty"`
+	JobsPending   int      `json:"jobs_pending"`
+	JobsRunning   int      `json:"jobs_running"`
+	GPUTotal      int64    `json:"gpu_total"`
+	GPUAlloc      int64    `json:"gpu_alloc"`
+}
+
+// QOSModel 定义Slurm QoS数据结构
+type QOSModel struct {
+	Name             string  `json:"name"`
+	Priority         int64   `json:"priority"`
+	Preempt          string  `json:"preempt,omitempty"`
+	PreemptMode      string  `json:"preempt_mode"`
+	Flags            string  `json:"flags,omitempty"`
+	UsageFactor      float64 `json:"usage_factor"`
+	GrpTRES          string  `json:"grp_tres,omitempty"`
+	GrpJobs          string  `json:"grp_jobs,omitempty"`
+	GrpSubmitJobs    string  `json:"grp_submit_jobs,omitempty"`
+	MaxTRES          string  `json:"max_tres,omitempty"`
+	MaxTRESPerUser   string  `json:"max_tres_per_user,omitempty"`
+	MaxJobsPerUser   string  `json:"max_jobs_per_user,omitempty"`
+	MaxSubmitPerUser string  `json:"max_submit_per_user,omitempty"`
+	MaxWall          string  `json:"max_wall,omitempty"`
+	MinTRES          string  `json:"min_tres,omitempty"`
+}
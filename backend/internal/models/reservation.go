@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// ReservationModel 定义Slurm预约数据结构
+type ReservationModel struct {
+	Name      string    `json:"name"`
+	State     string    `json:"state"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	Duration  string    `json:"duration"`
+	Nodes     string    `json:"nodes"`
+	NodeCount int64     `json:"node_count"`
+	CoreCount int64     `json:"core_count"`
+	Partition string    `json:"partition,omitempty"`
+	Features  string    `json:"features,omitempty"`
+	Flags     []string  `json:"flags"`
+	Users     []string  `json:"users"`
+	Accounts  []string  `json:"accounts"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// SensorReading 硬件传感器读数，来源于 /sys/class/hwmon 或 /sys/class/thermal
+// 阈值为空表示芯片未提供；Status 为 ok、warning 或 critical
+type SensorReading struct {
+	Chip        string   `json:"chip"`   // hwmon 芯片名（如 coretemp）或温区类型（如 x86_pkg_temp）
+	Device      string   `json:"device"` // hwmon0、thermal_zone0 等，用于区分同名芯片
+	Label       string   `json:"label"`
+	Type        string   `json:"type"` // temperature、fan 或 voltage
+	Value       float64  `json:"value"`
+	Unit        string   `json:"unit"` // °C、RPM 或 V
+	Min         *float64 `json:"min,omitempty"`
+	Max         *float64 `json:"max,omitempty"`
+	LowCritical *float64 `json:"low_critical,omitempty"`
+	Critical    *float64 `json:"critical,omitempty"`
+	Status      string   `json:"status"`
+}
+
+// NodeSensors 节点的传感器读数
+type NodeSensors struct {
+	Hostname string          `json:"hostname"`
+	Cluster  string          `json:"cluster,omitempty"`
+	Time     time.Time       `json:"time"`
+	Stale    bool            `json:"stale"`
+	Sensors  []SensorReading `json:"sensors"`
+}
+
+// SensorAlert 传感器越过阈值的告警，恢复正常后 ResolvedAt 不为空
+type SensorAlert struct {
+	ID         string     `json:"id"`
+	Hostname   string     `json:"hostname"`
+	Cluster    string     `json:"cluster,omitempty"`
+	Sensor     string     `json:"sensor"` // 设备/标签，如 hwmon1/Package id 0
+	Type       string     `json:"type"`
+	Status     string     `json:"status"` // warning 或 critical
+	Value      float64    `json:"value"`
+	Threshold  float64    `json:"threshold"`
+	Unit       string     `json:"unit"`
+	Message    string     `json:"message"`
+	StartedAt  time.Time  `json:"started_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	// Expired 表示告警因读数不再上报（节点失联或传感器消失）而结束，并非读数恢复正常
+	Expired bool `json:"expired,omitempty"`
+}
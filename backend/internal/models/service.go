@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// ServiceStatus 定义 systemd 服务状态数据结构
+type ServiceStatus struct {
+	Name          string     `json:"name"`
+	Description   string     `json:"description"`
+	LoadState     string     `json:"load_state"`      // loaded、not-found 等，not-found 表示未安装
+	ActiveState   string     `json:"active_state"`    // active、inactive、failed 等
+	SubState      string     `json:"sub_state"`       // running、exited、dead 等
+	UnitFileState string     `json:"unit_file_state"` // enabled、disabled 等
+	Since         *time.Time `json:"since,omitempty"` // 进入当前状态的时间
+	MainPID       int        `json:"main_pid"`
+	Journal       []string   `json:"journal,omitempty"`
+}
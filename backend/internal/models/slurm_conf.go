@@ -0,0 +1,44 @@
+package models
+
+// SlurmConfParam slurm.conf 中的全局参数
+type SlurmConfParam struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	File  string `json:"file"`
+	Line  int    `json:"line"`
+}
+
+// SlurmConfNode slurm.conf 中的 NodeName 定义，Params 已合并 NodeName=DEFAULT 的默认值
+type SlurmConfNode struct {
+	NodeName string            `json:"node_name"`
+	Params   map[string]string `json:"params"`
+	File     string            `json:"file"`
+	Line     int               `json:"line"`
+}
+
+// SlurmConfPartition slurm.conf 中的 PartitionName 定义，Params 已合并 PartitionName=DEFAULT 的默认值
+type SlurmConfPartition struct {
+	PartitionName string            `json:"partition_name"`
+	Nodes         string            `json:"nodes"`
+	Params        map[string]string `json:"params"`
+	File          string            `json:"file"`
+	Line          int               `json:"line"`
+}
+
+// SlurmConfIssue 配置解析或校验发现的问题，Severity 为 error 或 warning
+type SlurmConfIssue struct {
+	Severity string `json:"severity"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Message  string `json:"message"`
+}
+
+// SlurmConfig slurm.conf 的结构化视图
+type SlurmConfig struct {
+	Path       string               `json:"path"`
+	Files      []string             `json:"files"` // 主配置文件及所有 Include 的文件
+	Parameters []SlurmConfParam     `json:"parameters"`
+	Nodes      []SlurmConfNode      `json:"nodes"`
+	Partitions []SlurmConfPartition `json:"partitions"`
+	Issues     []SlurmConfIssue     `json:"issues"`
+}
@@ -0,0 +1,92 @@
+package models
+
+import "time"
+
+// CPUTopology CPU 拓扑，来源于 /sys/devices/system/cpu
+type CPUTopology struct {
+	Sockets        int `json:"sockets"`
+	Cores          int `json:"cores"`   // 物理核心总数
+	Threads        int `json:"threads"` // 逻辑处理器总数
+	CoresPerSocket int `json:"cores_per_socket"`
+	ThreadsPerCore int `json:"threads_per_core"`
+}
+
+// CPUUsage CPU 使用率（百分比），由两次 /proc/stat 采样的差值计算
+type CPUUsage struct {
+	Total   float64   `json:"total"`
+	User    float64   `json:"user"`
+	System  float64   `json:"system"`
+	IOWait  float64   `json:"iowait"`
+	Steal   float64   `json:"steal"`
+	PerCore []float64 `json:"per_core"`
+}
+
+// LoadAverage 系统负载，来源于 /proc/loadavg
+type LoadAverage struct {
+	Load1     float64 `json:"load1"`
+	Load5     float64 `json:"load5"`
+	Load15    float64 `json:"load15"`
+	Running   int     `json:"running"`
+	Processes int     `json:"processes"`
+}
+
+// MemoryStats 内存与交换分区使用情况，单位为字节
+type MemoryStats struct {
+	Total       int64   `json:"total"`
+	Available   int64   `json:"available"`
+	Used        int64   `json:"used"`
+	Free        int64   `json:"free"`
+	Buffers     int64   `json:"buffers"`
+	Cached      int64   `json:"cached"`
+	UsedPercent float64 `json:"used_percent"`
+	SwapTotal   int64   `json:"swap_total"`
+	SwapUsed    int64   `json:"swap_used"`
+}
+
+// DiskUsage 挂载点的容量使用情况，单位为字节
+type DiskUsage struct {
+	Mount       string  `json:"mount"`
+	Device      string  `json:"device"`
+	FSType      string  `json:"fs_type"`
+	Total       int64   `json:"total"`
+	Used        int64   `json:"used"`
+	Free        int64   `json:"free"`
+	UsedPercent float64 `json:"used_percent"`
+	Inodes      int64   `json:"inodes"`
+	InodesUsed  int64   `json:"inodes_used"`
+}
+
+// DiskIO 块设备的读写速率，来源于 /proc/diskstats
+type DiskIO struct {
+	Device      string  `json:"device"`
+	ReadBytes   float64 `json:"read_bytes"`  // 字节/秒
+	WriteBytes  float64 `json:"write_bytes"` // 字节/秒
+	ReadOps     float64 `json:"read_ops"`    // 次/秒
+	WriteOps    float64 `json:"write_ops"`   // 次/秒
+	Utilization float64 `json:"utilization"` // 设备忙碌时间百分比
+	InProgress  int64   `json:"in_progress"`
+}
+
+// NetworkIO 网络接口的吞吐量，来源于 /proc/net/dev
+type NetworkIO struct {
+	Interface string  `json:"interface"`
+	RxBytes   float64 `json:"rx_bytes"`   // 字节/秒
+	TxBytes   float64 `json:"tx_bytes"`   // 字节/秒
+	RxPackets float64 `json:"rx_packets"` // 包/秒
+	TxPackets float64 `json:"tx_packets"` // 包/秒
+	RxErrors  int64   `json:"rx_errors"`  // 累计值
+	TxErrors  int64   `json:"tx_errors"`  // 累计值
+}
+
+// HostTelemetry 主机实时指标
+type HostTelemetry struct {
+	Time     time.Time   `json:"time"`
+	Interval float64     `json:"interval"` // 计算速率使用的采样间隔（秒）
+	Topology CPUTopology `json:"topology"`
+	CPU      CPUUsage    `json:"cpu"`
+	Load     LoadAverage `json:"load"`
+	Memory   MemoryStats `json:"memory"`
+	Disks    []DiskUsage `json:"disks"`
+	DiskIO   []DiskIO    `json:"disk_io"`
+	Network  []NetworkIO `json:"network"`
+}
@@ -0,0 +1,308 @@
+package services
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"panel-tool/internal/models"
+	"panel-tool/internal/utils"
+)
+
+// AgentVersion 计算节点代理的版本号，随上报一起发送
+const AgentVersion = "1.0.0"
+
+// 代理默认参数
+const (
+	defaultAgentInterval     = 15 * time.Second
+	defaultAgentMountTimeout = 3 * time.Second
+	agentRequestTimeout      = 10 * time.Second
+	agentInventoryInterval   = time.Hour
+)
+
+// AgentConfig 计算节点代理配置
+type AgentConfig struct {
+	PanelURL     string        // 面板地址，如 https://panel:8080
+	Token        string        // 共享 token，使用 mTLS 时可为空
+	Hostname     string        // 上报的节点名，需与 Slurm NodeName 一致
+	Cluster      string        // 所属集群，为空时匹配任意集群的同名节点
+	Interval     time.Duration // 上报间隔
+	Mounts       []string      // 需要检查的挂载点，为空时检查所有非虚拟文件系统
+	MountTimeout time.Duration // 单个挂载点的检查超时
+	CertFile     string        // mTLS 客户端证书
+	KeyFile      string        // mTLS 客户端私钥
+	CAFile       string        // 校验面板证书的 CA
+}
+
+// AgentConfigFromEnv 从 PANEL_AGENT_* 环境变量读取代理配置
+func AgentConfigFromEnv() (AgentConfig, error) {
+	config := AgentConfig{
+		PanelURL:     strings.TrimRight(os.Getenv("PANEL_AGENT_URL"), "/"),
+		Token:        os.Getenv("PANEL_AGENT_TOKEN"),
+		Hostname:     os.Getenv("PANEL_AGENT_NODE"),
+		Cluster:      os.Getenv("PANEL_AGENT_CLUSTER"),
+		Interval:     defaultAgentInterval,
+		MountTimeout: defaultAgentMountTimeout,
+		CertFile:     os.Getenv("PANEL_AGENT_CERT"),
+		KeyFile:      os.Getenv("PANEL_AGENT_KEY"),
+		CAFile:       os.Getenv("PANEL_AGENT_CA"),
+	}
+	if config.PanelURL == "" {
+		return config, fmt.Errorf("未设置 PANEL_AGENT_URL")
+	}
+	if config.Token == "" && config.CertFile == "" {
+		return config, fmt.Errorf("需要设置 PANEL_AGENT_TOKEN 或 PANEL_AGENT_CERT")
+	}
+	panelURL, err := url.Parse(config.PanelURL)
+	if err != nil || (panelURL.Scheme != "http" && panelURL.Scheme != "https") || panelURL.Host == "" {
+		return config, fmt.Errorf("PANEL_AGENT_URL 必须是 http 或 https URL: %s", config.PanelURL)
+	}
+	if panelURL.Scheme == "http" && config.Token != "" {
+		// token 以明文发送，只有显式设置 PANEL_AGENT_INSECURE=true 时才允许使用 http
+		insecure, _ := strconv.ParseBool(os.Getenv("PANEL_AGENT_INSECURE"))
+		if !insecure {
+			return config, fmt.Errorf("使用 token 时 PANEL_AGENT_URL 必须为 https，确需使用 http 请设置 PANEL_AGENT_INSECURE=true")
+		}
+	}
+	if config.Hostname == "" {
+		// Slurm 节点名通常为短主机名
+		config.Hostname = strings.SplitN(getHostname(), ".", 2)[0]
+	}
+	if value := os.Getenv("PANEL_AGENT_INTERVAL"); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds <= 0 || seconds > int(maxAgentReportInterval.Seconds()) {
+			return config, fmt.Errorf("PANEL_AGENT_INTERVAL 无效: %s", value)
+		}
+		config.Interval = time.Duration(seconds) * time.Second
+	}
+	if value := os.Getenv("PANEL_AGENT_MOUNTS"); value != "" {
+		for _, mount := range strings.Split(value, ",") {
+			if mount = strings.TrimSpace(mount); mount != "" {
+				config.Mounts = append(config.Mounts, mount)
+			}
+		}
+	}
+	return config, nil
+}
+
+// Agent 运行在计算节点上，定时采集本机指标、硬件信息、挂载点状态和传感器读数并推送到面板
+type Agent struct {
+	config   AgentConfig
+	client   *http.Client
+	sampler  *TelemetrySampler
+	hardware models.AgentHardware
+	logger   *utils.Logger
+
+	// inventorySent 为上一次成功上报硬件清单的时间
+	inventorySent time.Time
+}
+
+// NewAgent 创建计算节点代理，配置了证书时使用 mTLS
+func NewAgent(config AgentConfig) (*Agent, error) {
+	tlsConfig := &tls.Config{}
+	if config.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("加载客户端证书失败: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if config.CAFile != "" {
+		pool, err := loadCertPool(config.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return &Agent{
+		config: config,
+		client: &http.Client{
+			Timeout:   agentRequestTimeout,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		},
+		sampler:  NewTelemetrySampler(),
+		hardware: CollectAgentHardware(),
+		logger:   utils.NewLogger(),
+	}, nil
+}
+
+// loadCertPool 读取 PEM 格式的 CA 证书
+func loadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取 CA 证书失败: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("%s 中没有有效的证书", path)
+	}
+	return pool, nil
+}
+
+// Run 按间隔持续上报，直到 stop 关闭；上报失败只记录日志，下一轮继续
+func (a *Agent) Run(stop <-chan struct{}) {
+	a.logger.Info(fmt.Sprintf("节点代理 %s 启动，上报到 %s，间隔 %s", a.config.Hostname, a.config.PanelURL, a.config.Interval))
+	ticker := time.NewTicker(a.config.Interval)
+	defer ticker.Stop()
+	for {
+		if err := a.ReportOnce(); err != nil {
+			a.logger.Error(err.Error())
+		}
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ReportOnce 采集并上报一次，距上次上报硬件清单超过 agentInventoryInterval 时附带硬件清单
+func (a *Agent) ReportOnce() error {
+	report, err := a.Collect()
+	if err != nil {
+		return err
+	}
+	if time.Since(a.inventorySent) >= agentInventoryInterval {
+		report.Inventory = CollectInventory("/sys", "/proc")
+		report.Inventory.Hostname = a.config.Hostname
+		report.Inventory.Cluster = a.config.Cluster
+	}
+	if err := a.push(report); err != nil {
+		return err
+	}
+	if report.Inventory != nil {
+		a.inventorySent = time.Now()
+	}
+	return nil
+}
+
+// Collect 采集一次上报内容
+func (a *Agent) Collect() (*models.AgentReport, error) {
+	telemetry, err := a.sampler.Sample()
+	if err != nil {
+		return nil, err
+	}
+	return &models.AgentReport{
+		Hostname:  a.config.Hostname,
+		Cluster:   a.config.Cluster,
+		Version:   AgentVersion,
+		Time:      time.Now(),
+		Interval:  a.config.Interval.Seconds(),
+		Hardware:  a.hardware,
+		Telemetry: *telemetry,
+		Mounts:    CheckMounts("/proc/self/mounts", a.config.Mounts, a.config.MountTimeout),
+		Sensors:   ReadSensors("/sys"),
+	}, nil
+}
+
+// push 将上报内容发送到面板的 /api/agent/report
+func (a *Agent) push(report *models.AgentReport) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequest(http.MethodPost, a.config.PanelURL+"/api/agent/report", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if a.config.Token != "" {
+		request.Header.Set("Authorization", "Bearer "+a.config.Token)
+	}
+
+	response, err := a.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("上报失败: %v", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return fmt.Errorf("上报失败: %s %s", response.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// CollectAgentHardware 读取本机硬件信息：DMI 厂商与型号、CPU 型号与拓扑、内存总量、系统与内核版本
+func CollectAgentHardware() models.AgentHardware {
+	hardware := models.AgentHardware{
+		Vendor:       readSysValue("/sys/devices/virtual/dmi/id/sys_vendor"),
+		Product:      readSysValue("/sys/devices/virtual/dmi/id/product_name"),
+		CPUModel:     readCPUModel("/proc/cpuinfo"),
+		Architecture: getArchitecture(),
+		Topology:     ReadCPUTopology("/sys", "/proc"),
+		OSVersion:    getOSVersion(),
+		Kernel:       getKernelVersion(),
+	}
+	if memory, err := readMemoryStats("/proc/meminfo"); err == nil {
+		hardware.MemoryTotal = memory.Total
+	}
+	return hardware
+}
+
+// readSysValue 读取 /sys 下的单值文件，失败时返回空字符串
+func readSysValue(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// CheckMounts 检查挂载点是否可访问：在超时时间内 statfs 成功且未被只读挂载
+// mounts 为空时检查挂载表中的所有非虚拟文件系统；指定的挂载点不在挂载表中时视为未挂载
+func CheckMounts(mountsPath string, mounts []string, timeout time.Duration) []models.MountHealth {
+	results := []models.MountHealth{}
+	data, err := os.ReadFile(mountsPath)
+	if err != nil {
+		return results
+	}
+	entries := parseMounts(string(data))
+
+	byMount := make(map[string]mountEntry)
+	for _, entry := range entries {
+		byMount[entry.mount] = entry
+	}
+	if len(mounts) == 0 {
+		for _, entry := range entries {
+			mounts = append(mounts, entry.mount)
+		}
+	}
+
+	for _, mount := range mounts {
+		mount = filepath.Clean(mount)
+		entry, ok := byMount[mount]
+		if !ok {
+			results = append(results, models.MountHealth{Mount: mount, Error: "未挂载"})
+			continue
+		}
+		health := models.MountHealth{Mount: mount, Device: entry.device, FSType: entry.fsType, ReadOnly: entry.readOnly}
+		start := time.Now()
+		_, err := statfsTimeout(mount, timeout)
+		health.Latency = roundPercent(float64(time.Since(start).Microseconds()) / 1000)
+		switch {
+		case errors.Is(err, errStatfsInFlight):
+			// 上一次检查仍阻塞在该挂载点，不再启动新的检查
+			health.Error = "hung"
+		case err != nil:
+			health.Error = err.Error()
+		case entry.readOnly:
+			health.Error = "只读挂载"
+		default:
+			health.Healthy = true
+		}
+		results = append(results, health)
+	}
+	return results
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"panel-tool/internal/models"
+	"panel-tool/internal/utils"
+)
+
+// defaultAgentStaleAfter 超过该时间未收到上报即认为代理失联，可通过 PANEL_AGENT_STALE（秒）覆盖
+// 代理上报间隔较长时，以三个上报间隔为准
+const defaultAgentStaleAfter = 60 * time.Second
+
+// maxAgentReportInterval 代理上报间隔的上限，超出的值按上限计算失联时间
+const maxAgentReportInterval = 10 * time.Minute
+
+// 节点指标来源
+const (
+	MetricsSourceAgent = "agent"
+	MetricsSourceSlurm = "slurm"
+)
+
+// agentEntry 某个节点最近一次上报及面板收到的时间（不使用节点时钟，避免时钟偏差误判失联）
+type agentEntry struct {
+	report   *models.AgentReport
+	received time.Time
+	stale    bool
+}
+
+// AgentRegistry 保存各计算节点代理的最新上报，并合并到 Slurm 节点信息中
+type AgentRegistry struct {
+	logger     *utils.Logger
+	staleAfter time.Duration
+
+	// sensors 为空时不评估代理上报的传感器读数
+	sensors *SensorMonitor
+
+	mutex   sync.Mutex
+	entries map[string]*agentEntry
+}
+
+// NewAgentRegistry 创建节点代理注册表
+func NewAgentRegistry() *AgentRegistry {
+	staleAfter := defaultAgentStaleAfter
+	if value := os.Getenv("PANEL_AGENT_STALE"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+			staleAfter = time.Duration(seconds) * time.Second
+		}
+	}
+	return &AgentRegistry{
+		logger:     utils.NewLogger(),
+		staleAfter: staleAfter,
+		entries:    make(map[string]*agentEntry),
+	}
+}
+
+// UseSensors 设置传感器监控，代理上报的读数会结合上一次的状态评估并产生告警
+func (r *AgentRegistry) UseSensors(sensors *SensorMonitor) {
+	r.sensors = sensors
+}
+
+// agentKey 注册表索引键，代理未指定集群时只按节点名匹配
+func agentKey(cluster, hostname string) string {
+	return cluster + "/" + hostname
+}
+
+// Report 记录一次代理上报
+func (r *AgentRegistry) Report(report *models.AgentReport) error {
+	if report.Hostname == "" || !nodeNamePattern.MatchString(report.Hostname) {
+		return fmt.Errorf("无效的节点名: %q", report.Hostname)
+	}
+	// 上报间隔由代理提供，限制在合理范围内，避免失联判定时长被无限放大
+	if !(report.Interval > 0) {
+		report.Interval = 0
+	} else if report.Interval > maxAgentReportInterval.Seconds() {
+		report.Interval = maxAgentReportInterval.Seconds()
+	}
+	if r.sensors != nil {
+		report.Sensors = r.sensors.Observe(report.Hostname, report.Cluster, time.Now(), report.Sensors)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	key := agentKey(report.Cluster, report.Hostname)
+	if entry, ok := r.entries[key]; ok && entry.stale {
+		r.logger.Info(fmt.Sprintf("节点代理 %s 恢复上报", report.Hostname))
+	}
+	r.entries[key] = &agentEntry{report: report, received: time.Now()}
+	return nil
+}
+
+// isStale 判断代理是否失联，调用方需持有锁
+func (r *AgentRegistry) isStale(entry *agentEntry, now time.Time) bool {
+	limit := r.staleAfter
+	if interval := time.Duration(entry.report.Interval * 3 * float64(time.Second)); interval > limit {
+		limit = interval
+	}
+	return now.Sub(entry.received) > limit
+}
+
+// lookup 查找节点的上报，先匹配指定集群，再匹配未指定集群的代理，调用方需持有锁
+func (r *AgentRegistry) lookup(cluster, hostname string) *agentEntry {
+	if entry, ok := r.entries[agentKey(cluster, hostname)]; ok {
+		return entry
+	}
+	return r.entries[agentKey("", hostname)]
+}
+
+// Merge 将代理上报合并到节点列表，返回新的列表而不修改传入的节点
+// 代理在线时 CPU、内存使用率和负载取实测值；失联或未部署代理时保留 Slurm 数据
+func (r *AgentRegistry) Merge(cluster string, nodes []models.NodeModel) []models.NodeModel {
+	merged := make([]models.NodeModel, len(nodes))
+	copy(merged, nodes)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	for i := range merged {
+		node := &merged[i]
+		node.MetricsSource = MetricsSourceSlurm
+		entry := r.lookup(cluster, node.Hostname)
+		if entry == nil {
+			continue
+		}
+
+		stale := r.isStale(entry, now)
+		if stale && !entry.stale {
+			r.logger.Error(fmt.Sprintf("节点代理 %s 已 %s 未上报", node.Hostname, now.Sub(entry.received).Round(time.Second)))
+		}
+		entry.stale = stale
+
+		report := entry.report
+		node.Agent = &models.NodeAgentStatus{
+			Version:  report.Version,
+			LastSeen: entry.received,
+			Stale:    stale,
+			Hardware: report.Hardware,
+			Mounts:   report.Mounts,
+			Load:     report.Telemetry.Load,
+			Memory:   report.Telemetry.Memory,
+			Disks:    report.Telemetry.Disks,
+			Network:  report.Telemetry.Network,
+			Sensors:  report.Sensors,
+		}
+		if stale {
+			continue
+		}
+
+		node.MetricsSource = MetricsSourceAgent
+		node.CPUUsage = report.Telemetry.CPU.Total
+		node.CPULoad = report.Telemetry.Load.Load1
+		if memory := report.Telemetry.Memory; memory.Total > 0 {
+			node.MemoryUsage = memory.UsedPercent
+			node.UsedMemory = memory.Used / 1024 / 1024
+			if node.RealMemory == 0 {
+				node.RealMemory = memory.Total / 1024 / 1024
+			}
+		}
+	}
+	return merged
+}
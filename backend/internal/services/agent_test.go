@@ -0,0 +1,108 @@
+package services
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckMounts(t *testing.T) {
+	// /home 上一次的 statfs 仍未返回
+	statfsPendingMutex.Lock()
+	statfsPending["/home"] = true
+	statfsPendingMutex.Unlock()
+	t.Cleanup(func() {
+		statfsPendingMutex.Lock()
+		delete(statfsPending, "/home")
+		statfsPendingMutex.Unlock()
+	})
+
+	mountsPath := filepath.Join("testdata", "agent_mounts.txt")
+	results := CheckMounts(mountsPath, []string{"/", "/usr/", "/home", "/mnt/gone", "/proc", "/scratch"}, time.Second)
+
+	tests := []struct {
+		mount    string
+		fsType   string
+		healthy  bool
+		readOnly bool
+		err      string
+	}{
+		{"/", "ext4", true, false, ""},
+		{"/usr", "ext4", false, true, "只读挂载"},
+		{"/home", "nfs4", false, false, "hung"},
+		{"/mnt/gone", "nfs4", false, false, "no such file or directory"},
+		// 虚拟文件系统不在检查范围内
+		{"/proc", "", false, false, "未挂载"},
+		{"/scratch", "", false, false, "未挂载"},
+	}
+	if len(results) != len(tests) {
+		t.Fatalf("got %d results, want %d: %+v", len(results), len(tests), results)
+	}
+	for i, tt := range tests {
+		got := results[i]
+		if got.Mount != tt.mount || got.FSType != tt.fsType || got.Healthy != tt.healthy || got.ReadOnly != tt.readOnly || !strings.Contains(got.Error, tt.err) {
+			t.Errorf("CheckMounts()[%d] = %+v, want %s healthy=%v ro=%v error %q", i, got, tt.mount, tt.healthy, tt.readOnly, tt.err)
+		}
+		if tt.err == "" && got.Error != "" {
+			t.Errorf("CheckMounts()[%d] error = %q", i, got.Error)
+		}
+	}
+
+	// 未指定挂载点时检查挂载表中所有非虚拟文件系统
+	var mounts []string
+	for _, health := range CheckMounts(mountsPath, nil, time.Second) {
+		mounts = append(mounts, health.Mount)
+	}
+	if want := []string{"/", "/usr", "/home", "/mnt/gone"}; !reflect.DeepEqual(mounts, want) {
+		t.Errorf("CheckMounts(nil) mounts = %v, want %v", mounts, want)
+	}
+
+	if results := CheckMounts(filepath.Join("testdata", "missing"), []string{"/"}, time.Second); len(results) != 0 {
+		t.Errorf("CheckMounts() without mount table = %+v", results)
+	}
+}
+
+func TestAgentConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		err      string
+		interval time.Duration
+		mounts   []string
+	}{
+		{"defaults", map[string]string{"PANEL_AGENT_URL": "https://panel:8080/", "PANEL_AGENT_TOKEN": "secret"}, "", defaultAgentInterval, nil},
+		{"interval and mounts", map[string]string{"PANEL_AGENT_URL": "https://panel:8080", "PANEL_AGENT_TOKEN": "secret", "PANEL_AGENT_INTERVAL": "60", "PANEL_AGENT_MOUNTS": "/home, /scratch,,"}, "", time.Minute, []string{"/home", "/scratch"}},
+		{"missing url", map[string]string{"PANEL_AGENT_TOKEN": "secret"}, "PANEL_AGENT_URL", 0, nil},
+		{"missing credentials", map[string]string{"PANEL_AGENT_URL": "https://panel:8080"}, "PANEL_AGENT_TOKEN", 0, nil},
+		{"bad scheme", map[string]string{"PANEL_AGENT_URL": "ftp://panel", "PANEL_AGENT_TOKEN": "secret"}, "http 或 https", 0, nil},
+		// token 不能以明文发送
+		{"token over http", map[string]string{"PANEL_AGENT_URL": "http://panel:8080", "PANEL_AGENT_TOKEN": "secret"}, "PANEL_AGENT_INSECURE", 0, nil},
+		{"insecure http", map[string]string{"PANEL_AGENT_URL": "http://panel:8080", "PANEL_AGENT_TOKEN": "secret", "PANEL_AGENT_INSECURE": "true"}, "", defaultAgentInterval, nil},
+		{"mtls over http", map[string]string{"PANEL_AGENT_URL": "http://panel:8080", "PANEL_AGENT_CERT": "/etc/panel/agent.pem"}, "", defaultAgentInterval, nil},
+		{"interval too long", map[string]string{"PANEL_AGENT_URL": "https://panel:8080", "PANEL_AGENT_TOKEN": "secret", "PANEL_AGENT_INTERVAL": "3600"}, "PANEL_AGENT_INTERVAL", 0, nil},
+		{"interval not positive", map[string]string{"PANEL_AGENT_URL": "https://panel:8080", "PANEL_AGENT_TOKEN": "secret", "PANEL_AGENT_INTERVAL": "0"}, "PANEL_AGENT_INTERVAL", 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"PANEL_AGENT_URL", "PANEL_AGENT_TOKEN", "PANEL_AGENT_CERT", "PANEL_AGENT_INSECURE", "PANEL_AGENT_INTERVAL", "PANEL_AGENT_MOUNTS"} {
+				t.Setenv(key, tt.env[key])
+			}
+			t.Setenv("PANEL_AGENT_NODE", "cn001")
+			config, err := AgentConfigFromEnv()
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("AgentConfigFromEnv() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AgentConfigFromEnv() error: %v", err)
+			}
+			if config.PanelURL != strings.TrimRight(tt.env["PANEL_AGENT_URL"], "/") || config.Hostname != "cn001" || config.Interval != tt.interval || !reflect.DeepEqual(config.Mounts, tt.mounts) {
+				t.Errorf("AgentConfigFromEnv() = %+v", config)
+			}
+		})
+	}
+}
@@ -0,0 +1,401 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// 告警表达式语法：
+//
+//	expr    = or
+//	or      = and { ("||" | "or") and }
+//	and     = unary { ("&&" | "and") unary }
+//	unary   = ("!" | "not") unary | compare
+//	compare = operand [ ("==" | "!=" | ">" | ">=" | "<" | "<=" | "=~") operand ]
+//	operand = 字段名 | 数字 | "字符串" | true | false | "(" expr ")"
+//
+// 例如 state == "down" || not_responding、idle_cpus == 0 && pending_jobs > 0、mount == "/scratch" && used_percent > 90
+// 字段不存在或类型不匹配时比较结果为 false，单独的操作数按非零、非空判断真假
+
+// alertExpr 解析后的表达式
+type alertExpr interface {
+	eval(fields map[string]interface{}) interface{}
+}
+
+// alertField 字段引用
+type alertField string
+
+func (f alertField) eval(fields map[string]interface{}) interface{} {
+	return fields[string(f)]
+}
+
+// alertLiteral 字面量
+type alertLiteral struct {
+	value interface{}
+}
+
+func (l alertLiteral) eval(map[string]interface{}) interface{} {
+	return l.value
+}
+
+// alertNot 逻辑非
+type alertNot struct {
+	operand alertExpr
+}
+
+func (n alertNot) eval(fields map[string]interface{}) interface{} {
+	return !alertTruthy(n.operand.eval(fields))
+}
+
+// alertLogical 逻辑与、逻辑或，短路求值
+type alertLogical struct {
+	and         bool
+	left, right alertExpr
+}
+
+func (l alertLogical) eval(fields map[string]interface{}) interface{} {
+	left := alertTruthy(l.left.eval(fields))
+	if l.and && !left || !l.and && left {
+		return left
+	}
+	return alertTruthy(l.right.eval(fields))
+}
+
+// alertCompare 比较运算，=~ 的右侧须为字符串字面量，在解析时编译为正则表达式
+type alertCompare struct {
+	op          string
+	left, right alertExpr
+	pattern     *regexp.Regexp
+}
+
+func (c alertCompare) eval(fields map[string]interface{}) interface{} {
+	left, right := c.left.eval(fields), c.right.eval(fields)
+	if c.op == "=~" {
+		text, ok := left.(string)
+		return ok && c.pattern.MatchString(text)
+	}
+
+	if l, ok := alertNumber(left); ok {
+		r, ok := alertNumber(right)
+		if !ok {
+			return false
+		}
+		switch c.op {
+		case "==":
+			return l == r
+		case "!=":
+			return l != r
+		case ">":
+			return l > r
+		case ">=":
+			return l >= r
+		case "<":
+			return l < r
+		case "<=":
+			return l <= r
+		}
+	}
+	switch l := left.(type) {
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			return false
+		}
+		switch c.op {
+		case "==":
+			return l == r
+		case "!=":
+			return l != r
+		case ">":
+			return l > r
+		case ">=":
+			return l >= r
+		case "<":
+			return l < r
+		case "<=":
+			return l <= r
+		}
+	case bool:
+		r, ok := right.(bool)
+		if !ok {
+			return false
+		}
+		switch c.op {
+		case "==":
+			return l == r
+		case "!=":
+			return l != r
+		}
+	}
+	return false
+}
+
+// alertNumber 将数值字段统一转换为 float64
+func alertNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// alertTruthy 判断单个值的真假
+func alertTruthy(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case nil:
+		return false
+	}
+	number, ok := alertNumber(value)
+	return ok && number != 0
+}
+
+// alertToken 词法单元
+type alertToken struct {
+	kind  string // ident、number、string、op
+	value string
+}
+
+// tokenizeAlertExpr 将表达式拆分为词法单元
+func tokenizeAlertExpr(input string) ([]alertToken, error) {
+	var tokens []alertToken
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"' || r == '\'':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(runes) && runes[j] != r; j++ {
+				if runes[j] == '\\' && j+1 < len(runes) {
+					j++
+				}
+				b.WriteRune(runes[j])
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("字符串未结束")
+			}
+			tokens = append(tokens, alertToken{kind: "string", value: b.String()})
+			i = j + 1
+		case unicode.IsDigit(r) || r == '.' || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1]) && alertExpectsOperand(tokens)):
+			j := i + 1
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.' || runes[j] == 'e' || runes[j] == 'E') {
+				j++
+			}
+			tokens = append(tokens, alertToken{kind: "number", value: string(runes[i:j])})
+			i = j
+		case unicode.IsLetter(r) || r == '_':
+			j := i + 1
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_') {
+				j++
+			}
+			word := string(runes[i:j])
+			switch word {
+			case "and":
+				tokens = append(tokens, alertToken{kind: "op", value: "&&"})
+			case "or":
+				tokens = append(tokens, alertToken{kind: "op", value: "||"})
+			case "not":
+				tokens = append(tokens, alertToken{kind: "op", value: "!"})
+			default:
+				tokens = append(tokens, alertToken{kind: "ident", value: word})
+			}
+			i = j
+		default:
+			matched := false
+			for _, op := range []string{"&&", "||", "==", "!=", ">=", "<=", "=~", ">", "<", "!", "(", ")"} {
+				if strings.HasPrefix(string(runes[i:]), op) {
+					tokens = append(tokens, alertToken{kind: "op", value: op})
+					i += len([]rune(op))
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("无法识别的字符 %q", r)
+			}
+		}
+	}
+	return tokens, nil
+}
+
+// alertExpectsOperand 判断下一个词法单元是否应为操作数，用于区分负号
+func alertExpectsOperand(tokens []alertToken) bool {
+	if len(tokens) == 0 {
+		return true
+	}
+	last := tokens[len(tokens)-1]
+	return last.kind == "op" && last.value != ")"
+}
+
+// alertParser 递归下降解析器，fields 不为空时校验字段名
+type alertParser struct {
+	tokens []alertToken
+	pos    int
+	fields map[string]bool
+}
+
+// parseAlertExpr 解析告警表达式，fields 为该目标类型允许的字段
+func parseAlertExpr(input string, fields []string) (alertExpr, error) {
+	tokens, err := tokenizeAlertExpr(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("表达式为空")
+	}
+	p := &alertParser{tokens: tokens, fields: make(map[string]bool)}
+	for _, field := range fields {
+		p.fields[field] = true
+	}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("多余的内容: %s", p.tokens[p.pos].value)
+	}
+	return expr, nil
+}
+
+// peekOp 下一个词法单元为指定运算符时返回 true
+func (p *alertParser) peekOp(ops ...string) (string, bool) {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != "op" {
+		return "", false
+	}
+	for _, op := range ops {
+		if p.tokens[p.pos].value == op {
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *alertParser) parseOr() (alertExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.peekOp("||"); !ok {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = alertLogical{and: false, left: left, right: right}
+	}
+}
+
+func (p *alertParser) parseAnd() (alertExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.peekOp("&&"); !ok {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = alertLogical{and: true, left: left, right: right}
+	}
+}
+
+func (p *alertParser) parseUnary() (alertExpr, error) {
+	if _, ok := p.peekOp("!"); ok {
+		p.pos++
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return alertNot{operand: operand}, nil
+	}
+	return p.parseCompare()
+}
+
+func (p *alertParser) parseCompare() (alertExpr, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	op, ok := p.peekOp("==", "!=", ">=", "<=", ">", "<", "=~")
+	if !ok {
+		return left, nil
+	}
+	p.pos++
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	compare := alertCompare{op: op, left: left, right: right}
+	if op == "=~" {
+		literal, ok := right.(alertLiteral)
+		pattern, isString := literal.value.(string)
+		if !ok || !isString {
+			return nil, fmt.Errorf("=~ 右侧必须是字符串")
+		}
+		if compare.pattern, err = regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("无效的正则表达式 %q: %v", pattern, err)
+		}
+	}
+	return compare, nil
+}
+
+func (p *alertParser) parseOperand() (alertExpr, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("表达式不完整")
+	}
+	token := p.tokens[p.pos]
+	p.pos++
+	switch token.kind {
+	case "number":
+		value, err := strconv.ParseFloat(token.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("无效的数字: %s", token.value)
+		}
+		return alertLiteral{value: value}, nil
+	case "string":
+		return alertLiteral{value: token.value}, nil
+	case "ident":
+		switch token.value {
+		case "true":
+			return alertLiteral{value: true}, nil
+		case "false":
+			return alertLiteral{value: false}, nil
+		}
+		if len(p.fields) > 0 && !p.fields[token.value] {
+			return nil, fmt.Errorf("未知字段: %s", token.value)
+		}
+		return alertField(token.value), nil
+	}
+	if token.value == "(" {
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := p.peekOp(")"); !ok {
+			return nil, fmt.Errorf("缺少右括号")
+		}
+		p.pos++
+		return expr, nil
+	}
+	return nil, fmt.Errorf("意外的运算符: %s", token.value)
+}
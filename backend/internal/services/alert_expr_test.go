@@ -0,0 +1,101 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAlertExprEval(t *testing.T) {
+	object := map[string]interface{}{
+		"hostname":       "cn001",
+		"state":          "down",
+		"not_responding": false,
+		"reason":         "",
+		"idle_cpus":      0,
+		"pending_jobs":   int64(3),
+		"used_percent":   91.5,
+		"mount":          "/scratch",
+	}
+	fields := make([]string, 0, len(object)+1)
+	for field := range object {
+		fields = append(fields, field)
+	}
+	// 规则允许但对象中没有的字段
+	fields = append(fields, "temperature_max")
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`state == "down" || not_responding`, true},
+		{`state != "down"`, false},
+		{`idle_cpus == 0 && pending_jobs > 0`, true},
+		{`idle_cpus == 0 and pending_jobs > 5`, false},
+		{`not not_responding`, true},
+		{`!(used_percent > 90)`, false},
+		{`used_percent >= 91.5 && used_percent <= 91.5`, true},
+		{`used_percent > -1`, true},
+		{`used_percent < 1e2`, true},
+		{`mount =~ "^/scr"`, true},
+		{`hostname =~ "^gpu"`, false},
+		{`state < "e"`, true},
+		{`'it\'s' == "it's"`, true},
+		{`false == not_responding`, true},
+		// 单独的操作数按非零、非空判断
+		{`pending_jobs`, true},
+		{`idle_cpus`, false},
+		{`reason`, false},
+		// 字段不存在或类型不匹配时比较结果为 false
+		{`temperature_max > 80`, false},
+		{`!(temperature_max > 80)`, true},
+		{`state > 1`, false},
+		{`idle_cpus == "0"`, false},
+		{`not_responding == 0`, false},
+		{`used_percent =~ "91"`, false},
+		// && 优先于 ||
+		{`true || false && false`, true},
+		{`(true || false) && false`, false},
+		{`not true or true`, true},
+	}
+	for _, tt := range tests {
+		expr, err := parseAlertExpr(tt.expr, fields)
+		if err != nil {
+			t.Errorf("parseAlertExpr(%q) error: %v", tt.expr, err)
+			continue
+		}
+		if got := alertTruthy(expr.eval(object)); got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParseAlertExprErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		err  string
+	}{
+		{``, "表达式为空"},
+		{`   `, "表达式为空"},
+		{`state ==`, "表达式不完整"},
+		{`(state == "down"`, "缺少右括号"},
+		{`state == "down")`, "多余的内容"},
+		{`state == "down`, "字符串未结束"},
+		{`state # 1`, "无法识别的字符"},
+		{`mount =~ 1`, "=~ 右侧必须是字符串"},
+		{`mount =~ "("`, "无效的正则表达式"},
+		{`hostnme == "cn001"`, "未知字段: hostnme"},
+		{`&& state`, "意外的运算符"},
+		{`used_percent > 1.2.3`, "无效的数字"},
+	}
+	for _, tt := range tests {
+		_, err := parseAlertExpr(tt.expr, []string{"state", "mount", "hostname", "used_percent"})
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("parseAlertExpr(%q) error = %v, want %q", tt.expr, err, tt.err)
+		}
+	}
+
+	// 没有字段列表时不校验字段名
+	if _, err := parseAlertExpr(`anything > 1`, nil); err != nil {
+		t.Errorf("parseAlertExpr() without fields error: %v", err)
+	}
+}
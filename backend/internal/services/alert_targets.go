@@ -0,0 +1,342 @@
+package services
+
+import (
+	"sort"
+	"strings"
+
+	"panel-tool/internal/models"
+)
+
+// alertTarget 告警规则可作用的对象类型：Labels 为标识对象的字段（用于去重），Fields 为表达式可用的全部字段
+type alertTarget struct {
+	Labels []string
+	Fields []string
+}
+
+// alertTargets 各对象类型的字段
+var alertTargets = map[string]alertTarget{
+	"node": {
+		Labels: []string{"cluster", "hostname"},
+		Fields: []string{"cluster", "hostname", "state", "flags", "drain", "not_responding", "reason",
+			"cpu_usage", "memory_usage", "cpu_load", "cpu_total", "cpu_alloc", "gpu_total", "gpu_alloc",
+			"metrics_source", "agent", "agent_stale", "temperature_max", "unhealthy_mounts"},
+	},
+	"partition": {
+		Labels: []string{"cluster", "partition"},
+		Fields: []string{"cluster", "partition", "nodes", "down_nodes", "total_cpus", "alloc_cpus", "idle_cpus",
+			"running_jobs", "pending_jobs"},
+	},
+	"cluster": {
+		Labels: []string{"cluster"},
+		Fields: []string{"cluster", "nodes", "down_nodes", "drain_nodes", "total_cpus", "alloc_cpus",
+			"running_jobs", "pending_jobs"},
+	},
+	"disk": {
+		Labels: []string{"cluster", "hostname", "mount"},
+		Fields: []string{"cluster", "hostname", "mount", "device", "fs_type", "used_percent", "free_bytes",
+			"total_bytes", "inodes_used_percent"},
+	},
+	"mount": {
+		Labels: []string{"cluster", "hostname", "mount"},
+		Fields: []string{"cluster", "hostname", "mount", "fs_type", "healthy", "read_only", "latency", "error"},
+	},
+	"service": {
+		Labels: []string{"service"},
+		Fields: []string{"service", "installed", "load_state", "active_state", "sub_state"},
+	},
+	"sensor": {
+		Labels: []string{"cluster", "hostname", "sensor"},
+		Fields: []string{"cluster", "hostname", "sensor", "label", "type", "value", "unit", "status", "stale"},
+	},
+	"metric": {
+		Labels: []string{"metric", "target"},
+		Fields: []string{"metric", "target", "cluster", "host", "value"},
+	},
+}
+
+// AlertSources 规则引擎读取的数据来源，为空的来源对应的对象类型没有数据
+type AlertSources struct {
+	Snapshots  func() map[string]*ClusterSnapshot // 集群名 -> 最新快照
+	Management func() (*models.HostTelemetry, error)
+	Services   func() []models.ServiceStatus
+	Sensors    func() []models.NodeSensors
+	Metrics    func() []models.MetricSample // 各序列的最新值
+}
+
+// nodeSchedulable 判断节点是否可接收新作业
+func nodeSchedulable(node models.NodeModel) bool {
+	switch node.State {
+	case "idle", "mixed", "allocated", "completing":
+	default:
+		return false
+	}
+	for _, flag := range node.StateFlags {
+		switch flag {
+		case "DRAIN", "FAIL", "MAINT", "NOT_RESPONDING", "POWERED_DOWN":
+			return false
+		}
+	}
+	return true
+}
+
+// hasNodeFlag 判断节点是否带有某个状态标志
+func hasNodeFlag(node models.NodeModel, flag string) bool {
+	for _, f := range node.StateFlags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// clusterAlertTargets 由集群快照构造的对象类型
+var clusterAlertTargets = []string{"node", "partition", "cluster", "disk", "mount"}
+
+// alertScope 数据来源的范围：对象类型加集群名，来源不区分集群时集群名为空
+func alertScope(target, cluster string) string {
+	return target + "/" + cluster
+}
+
+// collectAlertObjects 从各来源构造每种类型的对象，对象为字段名到值的映射
+// observed 记录本次取得了数据的来源范围（见 alertScope），来源出错或没有返回数据时不记录
+func collectAlertObjects(sources AlertSources) (map[string][]map[string]interface{}, map[string]bool) {
+	objects := make(map[string][]map[string]interface{})
+	observed := make(map[string]bool)
+	add := func(target string, object map[string]interface{}) {
+		objects[target] = append(objects[target], object)
+	}
+
+	if sources.Snapshots != nil {
+		snapshots := sources.Snapshots()
+		names := make([]string, 0, len(snapshots))
+		for name := range snapshots {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			// slurmctld 无响应时节点列表为空，此时集群的状态未知而不是恢复正常
+			if snapshots[name] == nil || len(snapshots[name].Nodes) == 0 {
+				continue
+			}
+			collectClusterObjects(name, snapshots[name], add)
+			for _, target := range clusterAlertTargets {
+				observed[alertScope(target, name)] = true
+			}
+		}
+	}
+
+	if sources.Management != nil {
+		if telemetry, err := sources.Management(); err == nil && len(telemetry.Disks) > 0 {
+			observed[alertScope("disk", "")] = true
+			hostname := getHostname()
+			for _, disk := range telemetry.Disks {
+				add("disk", diskAlertObject("", hostname, disk))
+			}
+		}
+	}
+
+	if sources.Services != nil {
+		statuses := sources.Services()
+		if len(statuses) > 0 {
+			observed[alertScope("service", "")] = true
+		}
+		for _, status := range statuses {
+			add("service", map[string]interface{}{
+				"service":      status.Name,
+				"installed":    status.LoadState != "not-found",
+				"load_state":   status.LoadState,
+				"active_state": status.ActiveState,
+				"sub_state":    status.SubState,
+			})
+		}
+	}
+
+	if sources.Sensors != nil {
+		for _, node := range sources.Sensors() {
+			if len(node.Sensors) > 0 {
+				observed[alertScope("sensor", node.Cluster)] = true
+			}
+			for _, reading := range node.Sensors {
+				add("sensor", map[string]interface{}{
+					"cluster":  node.Cluster,
+					"hostname": node.Hostname,
+					"sensor":   sensorName(reading),
+					"label":    reading.Label,
+					"type":     reading.Type,
+					"value":    reading.Value,
+					"unit":     reading.Unit,
+					"status":   reading.Status,
+					"stale":    node.Stale,
+				})
+			}
+		}
+	}
+
+	if sources.Metrics != nil {
+		samples := sources.Metrics()
+		if len(samples) > 0 {
+			observed[alertScope("metric", "")] = true
+		}
+		for _, sample := range samples {
+			cluster, host := SplitMetricTarget(sample.Metric, sample.Target)
+			add("metric", map[string]interface{}{"metric": sample.Metric, "target": sample.Target, "cluster": cluster, "host": host, "value": sample.Value})
+		}
+	}
+	return objects, observed
+}
+
+// collectClusterObjects 由集群快照构造节点、分区、集群，以及节点代理上报的磁盘和挂载点对象
+func collectClusterObjects(cluster string, snapshot *ClusterSnapshot, add func(string, map[string]interface{})) {
+	type partitionStats struct {
+		nodes, down, total, alloc, idle, running, pending int
+	}
+	partitions := make(map[string]*partitionStats)
+	partition := func(name string) *partitionStats {
+		if partitions[name] == nil {
+			partitions[name] = &partitionStats{}
+		}
+		return partitions[name]
+	}
+	clusterStats := map[string]interface{}{"cluster": cluster}
+	nodes, down, drain, total, alloc := 0, 0, 0, 0, 0
+
+	for _, node := range snapshot.Nodes {
+		object := map[string]interface{}{
+			"cluster":        cluster,
+			"hostname":       node.Hostname,
+			"state":          node.State,
+			"flags":          strings.Join(node.StateFlags, ","),
+			"drain":          hasNodeFlag(node, "DRAIN"),
+			"not_responding": hasNodeFlag(node, "NOT_RESPONDING"),
+			"reason":         node.Reason,
+			"cpu_usage":      node.CPUUsage,
+			"memory_usage":   node.MemoryUsage,
+			"cpu_load":       node.CPULoad,
+			"cpu_total":      node.CPUTotal,
+			"cpu_alloc":      node.CPUAlloc,
+			"gpu_total":      node.GPUTotal,
+			"gpu_alloc":      node.GPUAlloc,
+			"metrics_source": node.MetricsSource,
+			"agent":          node.Agent != nil,
+			"agent_stale":    node.Agent != nil && node.Agent.Stale,
+		}
+		if node.Agent != nil && !node.Agent.Stale {
+			if temperature, ok := maxTemperature(node.Agent.Sensors); ok {
+				object["temperature_max"] = temperature
+			}
+			unhealthy := 0
+			for _, mount := range node.Agent.Mounts {
+				if !mount.Healthy {
+					unhealthy++
+				}
+				add("mount", map[string]interface{}{
+					"cluster":   cluster,
+					"hostname":  node.Hostname,
+					"mount":     mount.Mount,
+					"fs_type":   mount.FSType,
+					"healthy":   mount.Healthy,
+					"read_only": mount.ReadOnly,
+					"latency":   mount.Latency,
+					"error":     mount.Error,
+				})
+			}
+			object["unhealthy_mounts"] = unhealthy
+			for _, disk := range node.Agent.Disks {
+				add("disk", diskAlertObject(cluster, node.Hostname, disk))
+			}
+		}
+		add("node", object)
+
+		schedulable := nodeSchedulable(node)
+		nodes++
+		total += node.CPUTotal
+		alloc += node.CPUAlloc
+		if node.State == "down" || hasNodeFlag(node, "NOT_RESPONDING") {
+			down++
+		}
+		if hasNodeFlag(node, "DRAIN") {
+			drain++
+		}
+		for _, name := range node.Partitions {
+			stats := partition(name)
+			stats.nodes++
+			stats.total += node.CPUTotal
+			stats.alloc += node.CPUAlloc
+			if !schedulable {
+				stats.down++
+			} else if idle := node.CPUTotal - node.CPUAlloc; idle > 0 {
+				stats.idle += idle
+			}
+		}
+	}
+
+	running, pending := 0, 0
+	for _, job := range snapshot.Jobs {
+		switch job.Status {
+		case "running":
+			running++
+		case "pending":
+			pending++
+		default:
+			continue
+		}
+		// 排队作业可能同时提交到多个分区
+		for _, name := range strings.Split(job.Partition, ",") {
+			if name == "" {
+				continue
+			}
+			if job.Status == "running" {
+				partition(name).running++
+			} else {
+				partition(name).pending++
+			}
+		}
+	}
+
+	names := make([]string, 0, len(partitions))
+	for name := range partitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		stats := partitions[name]
+		add("partition", map[string]interface{}{
+			"cluster":      cluster,
+			"partition":    name,
+			"nodes":        stats.nodes,
+			"down_nodes":   stats.down,
+			"total_cpus":   stats.total,
+			"alloc_cpus":   stats.alloc,
+			"idle_cpus":    stats.idle,
+			"running_jobs": stats.running,
+			"pending_jobs": stats.pending,
+		})
+	}
+
+	clusterStats["nodes"] = nodes
+	clusterStats["down_nodes"] = down
+	clusterStats["drain_nodes"] = drain
+	clusterStats["total_cpus"] = total
+	clusterStats["alloc_cpus"] = alloc
+	clusterStats["running_jobs"] = running
+	clusterStats["pending_jobs"] = pending
+	add("cluster", clusterStats)
+}
+
+// diskAlertObject 构造磁盘对象
+func diskAlertObject(cluster, hostname string, disk models.DiskUsage) map[string]interface{} {
+	object := map[string]interface{}{
+		"cluster":      cluster,
+		"hostname":     hostname,
+		"mount":        disk.Mount,
+		"device":       disk.Device,
+		"fs_type":      disk.FSType,
+		"used_percent": disk.UsedPercent,
+		"free_bytes":   disk.Free,
+		"total_bytes":  disk.Total,
+	}
+	if disk.Inodes > 0 {
+		object["inodes_used_percent"] = roundPercent(float64(disk.InodesUsed) / float64(disk.Inodes) * 100)
+	}
+	return object
+}
@@ -0,0 +1,603 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"panel-tool/internal/models"
+	"panel-tool/internal/utils"
+)
+
+// 告警默认参数，可通过 PANEL_ALERT_RULES_FILE、PANEL_ALERTS_FILE、PANEL_ALERT_INTERVAL（秒）覆盖
+// 设置 PANEL_ALERT_WEBHOOK 后，告警触发和恢复时发送 Webhook 通知
+const (
+	defaultAlertRulesFile = "./config/alert_rules.json"
+	defaultAlertsFile     = "./data/alerts.json"
+	defaultAlertInterval  = 30 * time.Second
+	// alertHistoryLimit 保留的已恢复告警数量
+	alertHistoryLimit = 500
+)
+
+// 告警状态
+const (
+	AlertPending  = "pending"
+	AlertFiring   = "firing"
+	AlertResolved = "resolved"
+)
+
+// defaultAlertRules 规则文件不存在时使用的内置规则
+var defaultAlertRules = []models.AlertRule{
+	{Name: "NodeDown", Target: "node", Expr: `state == "down" || not_responding`, For: "5m", Severity: "critical",
+		Summary: "节点 {{hostname}} 已宕机或无响应 {{reason}}"},
+	{Name: "PartitionNoIdleCPUs", Target: "partition", Expr: `idle_cpus == 0 && pending_jobs > 0`, For: "1h", Severity: "warning",
+		Summary: "分区 {{partition}} 已持续 1 小时没有空闲 CPU，{{pending_jobs}} 个作业在排队"},
+	{Name: "DiskAlmostFull", Target: "disk", Expr: `used_percent > 90`, For: "10m", Severity: "warning",
+		Summary: "{{hostname}} 的 {{mount}} 已使用 {{used_percent}}%"},
+	{Name: "MountUnhealthy", Target: "mount", Expr: `!healthy`, For: "2m", Severity: "critical",
+		Summary: "{{hostname}} 的挂载点 {{mount}} 异常：{{error}}"},
+	{Name: "SlurmctldInactive", Target: "service", Expr: `service == "slurmctld" && installed && active_state != "active"`, For: "1m", Severity: "critical",
+		Summary: "slurmctld 状态为 {{active_state}}"},
+	{Name: "NodeAgentStale", Target: "node", Expr: `agent_stale`, For: "5m", Severity: "warning",
+		Summary: "节点 {{hostname}} 的代理已停止上报"},
+	{Name: "SensorCritical", Target: "sensor", Expr: `status == "critical" && !stale`, For: "1m", Severity: "critical",
+		Summary: "{{hostname}} 传感器 {{label}} 读数 {{value}}{{unit}} 超过严重阈值"},
+}
+
+// alertTemplatePattern 匹配摘要中的 {{字段}}
+var alertTemplatePattern = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+
+// compiledAlertRule 解析后的规则
+type compiledAlertRule struct {
+	rule models.AlertRule
+	expr alertExpr
+	hold time.Duration
+}
+
+// alertState 持久化到文件的告警状态与静默
+type alertState struct {
+	Active   []models.Alert   `json:"active"`
+	Resolved []models.Alert   `json:"resolved"`
+	Silences []models.Silence `json:"silences"`
+}
+
+// AlertEngine 定时按规则评估集群状态，维护告警的 pending、firing、resolved 状态，并处理静默
+type AlertEngine struct {
+	logger     *utils.Logger
+	rulesPath  string
+	statePath  string
+	interval   time.Duration
+	sources    AlertSources
+	webhookURL string
+	webhook    *WebhookNotifier
+
+	mutex    sync.Mutex
+	rules    []compiledAlertRule
+	active   map[string]*models.Alert
+	resolved []models.Alert
+	silences []models.Silence
+
+	startOnce sync.Once
+	stop      chan struct{}
+}
+
+// NewAlertEngine 创建新的告警规则引擎，加载规则（文件不存在时使用内置规则）和已保存的告警状态
+func NewAlertEngine(sources AlertSources) *AlertEngine {
+	e := &AlertEngine{
+		logger:     utils.NewLogger(),
+		rulesPath:  os.Getenv("PANEL_ALERT_RULES_FILE"),
+		statePath:  os.Getenv("PANEL_ALERTS_FILE"),
+		interval:   defaultAlertInterval,
+		sources:    sources,
+		webhookURL: os.Getenv("PANEL_ALERT_WEBHOOK"),
+		webhook:    NewWebhookNotifier(),
+		active:     make(map[string]*models.Alert),
+		resolved:   []models.Alert{},
+		silences:   []models.Silence{},
+		stop:       make(chan struct{}),
+	}
+	if e.rulesPath == "" {
+		e.rulesPath = defaultAlertRulesFile
+	}
+	if e.statePath == "" {
+		e.statePath = defaultAlertsFile
+	}
+	if value := os.Getenv("PANEL_ALERT_INTERVAL"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+			e.interval = time.Duration(seconds) * time.Second
+		}
+	}
+
+	rules := defaultAlertRules
+	if data, err := os.ReadFile(e.rulesPath); err == nil {
+		if err := json.Unmarshal(data, &rules); err != nil {
+			e.logger.Error(fmt.Sprintf("解析 %s 失败，使用内置告警规则: %v", e.rulesPath, err))
+			rules = defaultAlertRules
+		}
+	} else if !os.IsNotExist(err) {
+		e.logger.Error(fmt.Sprintf("读取告警规则失败: %v", err))
+	}
+	compiled, err := compileAlertRules(rules)
+	if err != nil {
+		e.logger.Error(fmt.Sprintf("告警规则无效，使用内置规则: %v", err))
+		compiled, _ = compileAlertRules(defaultAlertRules)
+	}
+	e.rules = compiled
+
+	if err := e.load(); err != nil && !os.IsNotExist(err) {
+		e.logger.Error(fmt.Sprintf("读取告警状态失败: %v", err))
+	}
+	return e
+}
+
+// compileAlertRules 校验并解析规则，规则名不能重复
+func compileAlertRules(rules []models.AlertRule) ([]compiledAlertRule, error) {
+	compiled := make([]compiledAlertRule, 0, len(rules))
+	names := make(map[string]bool)
+	for _, rule := range rules {
+		if rule.Name == "" {
+			return nil, fmt.Errorf("规则名不能为空")
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("规则名重复: %s", rule.Name)
+		}
+		names[rule.Name] = true
+
+		target, ok := alertTargets[rule.Target]
+		if !ok {
+			return nil, fmt.Errorf("规则 %s 的对象类型无效: %s", rule.Name, rule.Target)
+		}
+		switch rule.Severity {
+		case "warning", "critical":
+		case "":
+			rule.Severity = "warning"
+		default:
+			return nil, fmt.Errorf("规则 %s 的级别无效: %s", rule.Name, rule.Severity)
+		}
+		expr, err := parseAlertExpr(rule.Expr, target.Fields)
+		if err != nil {
+			return nil, fmt.Errorf("规则 %s 的表达式无效: %v", rule.Name, err)
+		}
+		var hold time.Duration
+		if rule.For != "" {
+			if hold, err = time.ParseDuration(rule.For); err != nil || hold < 0 {
+				return nil, fmt.Errorf("规则 %s 的持续时间无效: %s", rule.Name, rule.For)
+			}
+		}
+		compiled = append(compiled, compiledAlertRule{rule: rule, expr: expr, hold: hold})
+	}
+	return compiled, nil
+}
+
+// load 从文件读取告警状态与静默
+func (e *AlertEngine) load() error {
+	data, err := os.ReadFile(e.statePath)
+	if err != nil {
+		return err
+	}
+	var state alertState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("解析 %s 失败: %v", e.statePath, err)
+	}
+	for i := range state.Active {
+		alert := state.Active[i]
+		e.active[alert.ID] = &alert
+	}
+	if state.Resolved != nil {
+		e.resolved = state.Resolved
+	}
+	if state.Silences != nil {
+		e.silences = state.Silences
+	}
+	return nil
+}
+
+// save 将告警状态与静默写入文件，调用方需持有 mutex
+func (e *AlertEngine) save() error {
+	state := alertState{Active: e.sortedActive(), Resolved: e.resolved, Silences: e.silences}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(e.statePath), 0755); err != nil {
+		return fmt.Errorf("创建数据目录失败: %v", err)
+	}
+	if err := writeFileAtomic(e.statePath, data); err != nil {
+		return fmt.Errorf("保存告警状态失败: %v", err)
+	}
+	return nil
+}
+
+// Start 启动后台评估，重复调用无效
+func (e *AlertEngine) Start() {
+	e.startOnce.Do(func() {
+		e.logger.Info(fmt.Sprintf("告警规则引擎启动，%d 条规则，间隔 %s", len(e.rules), e.interval))
+		go e.run()
+	})
+}
+
+// Stop 停止后台评估
+func (e *AlertEngine) Stop() {
+	close(e.stop)
+}
+
+// run 评估循环，启动后等待一个间隔再开始，让采集器先完成首次采集
+func (e *AlertEngine) run() {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-e.stop:
+			return
+		case <-ticker.C:
+			e.Evaluate(time.Now())
+		}
+	}
+}
+
+// alertFingerprint 由规则名和标签计算告警 ID，同一对象的重复匹配得到相同的 ID
+func alertFingerprint(rule string, labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteString(rule)
+	for _, key := range keys {
+		fmt.Fprintf(&b, "\x00%s=%s", key, labels[key])
+	}
+	sum := sha1.Sum([]byte(b.String()))
+	return hex.EncodeToString(sum[:8])
+}
+
+// formatAlertValue 格式化摘要中的字段值
+func formatAlertValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return v
+	}
+	return fmt.Sprint(value)
+}
+
+// renderAlertSummary 替换摘要模板中的字段，没有模板时列出标签
+func renderAlertSummary(rule models.AlertRule, object map[string]interface{}, labels map[string]string) string {
+	if rule.Summary == "" {
+		parts := make([]string, 0, len(labels))
+		for _, key := range alertTargets[rule.Target].Labels {
+			if labels[key] != "" {
+				parts = append(parts, key+"="+labels[key])
+			}
+		}
+		return rule.Name + " " + strings.Join(parts, " ")
+	}
+	return alertTemplatePattern.ReplaceAllStringFunc(rule.Summary, func(match string) string {
+		return formatAlertValue(object[alertTemplatePattern.FindStringSubmatch(match)[1]])
+	})
+}
+
+// Evaluate 评估一次所有规则：
+// 新满足条件的对象进入 pending，持续满足 For 后转为 firing；不再满足时 pending 直接删除，firing 转为 resolved
+// 对象所在的来源本次出错或没有数据时状态未知，已有告警保持不变
+func (e *AlertEngine) Evaluate(now time.Time) {
+	objects, observed := collectAlertObjects(e.sources)
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	var notifications []models.Alert
+	matched := make(map[string]bool)
+	ruleTargets := make(map[string]string)
+	for _, compiled := range e.rules {
+		if compiled.rule.Disabled {
+			continue
+		}
+		ruleTargets[compiled.rule.Name] = compiled.rule.Target
+		target := alertTargets[compiled.rule.Target]
+		for _, object := range objects[compiled.rule.Target] {
+			if !alertTruthy(compiled.expr.eval(object)) {
+				continue
+			}
+			labels := make(map[string]string, len(target.Labels))
+			for _, key := range target.Labels {
+				if value := formatAlertValue(object[key]); value != "" {
+					labels[key] = value
+				}
+			}
+			id := alertFingerprint(compiled.rule.Name, labels)
+			matched[id] = true
+
+			alert, ok := e.active[id]
+			if !ok {
+				alert = &models.Alert{
+					ID:          id,
+					Rule:        compiled.rule.Name,
+					State:       AlertPending,
+					Labels:      labels,
+					ActiveSince: now,
+				}
+				e.active[id] = alert
+			}
+			alert.Severity = compiled.rule.Severity
+			alert.Summary = renderAlertSummary(compiled.rule, object, labels)
+			alert.LastEval = now
+			if alert.State == AlertPending && now.Sub(alert.ActiveSince) >= compiled.hold {
+				firedAt := now
+				alert.State = AlertFiring
+				alert.FiredAt = &firedAt
+				e.applySilences(alert, now)
+				notifications = append(notifications, *alert)
+			}
+		}
+	}
+
+	for id, alert := range e.active {
+		if matched[id] {
+			e.applySilences(alert, now)
+			continue
+		}
+		if target, ok := ruleTargets[alert.Rule]; ok && !observed[alertScope(target, alert.Labels["cluster"])] {
+			e.applySilences(alert, now)
+			continue
+		}
+		delete(e.active, id)
+		// 规则被删除、停用或对象从仍有数据的来源中消失也视为恢复
+		if alert.State != AlertFiring {
+			continue
+		}
+		resolvedAt := now
+		alert.State = AlertResolved
+		alert.ResolvedAt = &resolvedAt
+		e.applySilences(alert, now)
+		e.resolved = append(e.resolved, *alert)
+		notifications = append(notifications, *alert)
+	}
+	if len(e.resolved) > alertHistoryLimit {
+		e.resolved = e.resolved[len(e.resolved)-alertHistoryLimit:]
+	}
+
+	if err := e.save(); err != nil {
+		e.logger.Error(err.Error())
+	}
+	for _, alert := range notifications {
+		e.notify(alert)
+	}
+}
+
+// applySilences 根据当前生效的静默设置告警的静默标记，调用方需持有 mutex
+func (e *AlertEngine) applySilences(alert *models.Alert, now time.Time) {
+	alert.Silenced, alert.SilencedBy = false, ""
+	for _, silence := range e.silences {
+		if now.Before(silence.StartsAt) || !now.Before(silence.EndsAt) {
+			continue
+		}
+		if silenceMatches(silence, alert) {
+			alert.Silenced, alert.SilencedBy = true, silence.ID
+			return
+		}
+	}
+}
+
+// silenceMatches 判断告警是否满足静默的所有条件
+func silenceMatches(silence models.Silence, alert *models.Alert) bool {
+	for key, pattern := range silence.Matchers {
+		var value string
+		switch key {
+		case "rule":
+			value = alert.Rule
+		case "severity":
+			value = alert.Severity
+		default:
+			value = alert.Labels[key]
+		}
+		if ok, err := filepath.Match(pattern, value); err != nil || !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// notify 记录日志，未被静默且配置了 Webhook 时发送通知
+func (e *AlertEngine) notify(alert models.Alert) {
+	title := fmt.Sprintf("[%s] %s", strings.ToUpper(alert.State), alert.Rule)
+	if alert.Silenced {
+		e.logger.Info(fmt.Sprintf("告警 %s（已静默）: %s", title, alert.Summary))
+		return
+	}
+	e.logger.Error(fmt.Sprintf("告警 %s: %s", title, alert.Summary))
+	if e.webhookURL == "" {
+		return
+	}
+
+	notification := models.Notification{
+		ID:      alert.ID,
+		Time:    alert.LastEval,
+		Title:   title,
+		Message: alert.Summary,
+		Cluster: alert.Labels["cluster"],
+		State:   alert.State,
+		Failed:  alert.State == AlertFiring,
+	}
+	if alert.ResolvedAt != nil {
+		notification.Time = *alert.ResolvedAt
+	}
+	go func() {
+		sub := models.NotificationSubscription{ID: "alerts", WebhookURL: e.webhookURL}
+		if err := e.webhook.Notify(sub, notification); err != nil {
+			e.logger.Error(fmt.Sprintf("发送告警通知失败: %v", err))
+		}
+	}()
+}
+
+// sortedActive 返回未恢复的告警，firing 在前，再按级别和开始时间排序，调用方需持有 mutex
+func (e *AlertEngine) sortedActive() []models.Alert {
+	alerts := make([]models.Alert, 0, len(e.active))
+	for _, alert := range e.active {
+		alerts = append(alerts, *alert)
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		a, b := alerts[i], alerts[j]
+		if a.State != b.State {
+			return a.State == AlertFiring
+		}
+		if a.Severity != b.Severity {
+			return a.Severity == "critical"
+		}
+		if !a.ActiveSince.Equal(b.ActiveSince) {
+			return a.ActiveSince.Before(b.ActiveSince)
+		}
+		return a.ID < b.ID
+	})
+	return alerts
+}
+
+// Alerts 返回告警；state 为空时返回 pending 与 firing，为 resolved 时返回最近恢复的告警（由新到旧）
+func (e *AlertEngine) Alerts(state string, limit int) ([]models.Alert, error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	switch state {
+	case "":
+		return e.sortedActive(), nil
+	case AlertPending, AlertFiring:
+		alerts := []models.Alert{}
+		for _, alert := range e.sortedActive() {
+			if alert.State == state {
+				alerts = append(alerts, alert)
+			}
+		}
+		return alerts, nil
+	case AlertResolved:
+		alerts := []models.Alert{}
+		for i := len(e.resolved) - 1; i >= 0 && len(alerts) < limit; i-- {
+			alerts = append(alerts, e.resolved[i])
+		}
+		return alerts, nil
+	}
+	return nil, fmt.Errorf("无效的告警状态: %s", state)
+}
+
+// Rules 返回当前的规则
+func (e *AlertEngine) Rules() []models.AlertRule {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	rules := make([]models.AlertRule, 0, len(e.rules))
+	for _, compiled := range e.rules {
+		rules = append(rules, compiled.rule)
+	}
+	return rules
+}
+
+// SetRules 校验并替换全部规则，写入规则文件；已删除规则的告警在下一次评估时恢复
+func (e *AlertEngine) SetRules(rules []models.AlertRule) error {
+	compiled, err := compileAlertRules(rules)
+	if err != nil {
+		return err
+	}
+	normalized := make([]models.AlertRule, 0, len(compiled))
+	for _, rule := range compiled {
+		normalized = append(normalized, rule.rule)
+	}
+	data, err := json.MarshalIndent(normalized, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if err := os.MkdirAll(filepath.Dir(e.rulesPath), 0755); err != nil {
+		return fmt.Errorf("创建配置目录失败: %v", err)
+	}
+	if err := writeFileAtomic(e.rulesPath, data); err != nil {
+		return fmt.Errorf("保存告警规则失败: %v", err)
+	}
+	e.rules = compiled
+	return nil
+}
+
+// Silences 返回未过期的静默，按结束时间排序
+func (e *AlertEngine) Silences() []models.Silence {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	now := time.Now()
+	silences := []models.Silence{}
+	for _, silence := range e.silences {
+		if now.Before(silence.EndsAt) {
+			silences = append(silences, silence)
+		}
+	}
+	sort.Slice(silences, func(i, j int) bool { return silences[i].EndsAt.Before(silences[j].EndsAt) })
+	return silences
+}
+
+// AddSilence 校验并添加静默，同时清理已过期的静默
+func (e *AlertEngine) AddSilence(silence models.Silence) (models.Silence, error) {
+	if len(silence.Matchers) == 0 {
+		return silence, fmt.Errorf("至少需要一个匹配条件")
+	}
+	for key, pattern := range silence.Matchers {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return silence, fmt.Errorf("匹配条件 %s 无效: %v", key, err)
+		}
+	}
+	if silence.StartsAt.IsZero() {
+		silence.StartsAt = time.Now()
+	}
+	if !silence.EndsAt.After(silence.StartsAt) {
+		return silence, fmt.Errorf("结束时间必须晚于开始时间")
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return silence, err
+	}
+	silence.ID = hex.EncodeToString(id)
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	now := time.Now()
+	kept := []models.Silence{}
+	for _, existing := range e.silences {
+		if now.Before(existing.EndsAt) {
+			kept = append(kept, existing)
+		}
+	}
+	e.silences = append(kept, silence)
+	for _, alert := range e.active {
+		e.applySilences(alert, now)
+	}
+	return silence, e.save()
+}
+
+// DeleteSilence 删除静默
+func (e *AlertEngine) DeleteSilence(id string) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	for i, silence := range e.silences {
+		if silence.ID == id {
+			e.silences = append(e.silences[:i], e.silences[i+1:]...)
+			now := time.Now()
+			for _, alert := range e.active {
+				e.applySilences(alert, now)
+			}
+			return e.save()
+		}
+	}
+	return fmt.Errorf("静默不存在: %s", id)
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"panel-tool/internal/models"
+)
+
+// newTestAlertEngine 创建只包含 NodeDown 规则的告警引擎，集群快照由 snapshot 提供
+func newTestAlertEngine(t *testing.T, snapshot func() *ClusterSnapshot) *AlertEngine {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("PANEL_ALERT_RULES_FILE", filepath.Join(dir, "alert_rules.json"))
+	t.Setenv("PANEL_ALERTS_FILE", filepath.Join(dir, "alerts.json"))
+	t.Setenv("PANEL_ALERT_WEBHOOK", "")
+
+	engine := NewAlertEngine(AlertSources{
+		Snapshots: func() map[string]*ClusterSnapshot { return map[string]*ClusterSnapshot{"alpha": snapshot()} },
+	})
+	err := engine.SetRules([]models.AlertRule{
+		{Name: "NodeDown", Target: "node", Expr: `state == "down" || not_responding`, For: "5m", Severity: "critical",
+			Summary: "节点 {{hostname}} 已宕机 {{reason}}"},
+	})
+	if err != nil {
+		t.Fatalf("SetRules() error: %v", err)
+	}
+	return engine
+}
+
+func TestAlertEngineEvaluate(t *testing.T) {
+	nodes := []models.NodeModel{
+		{Hostname: "cn001", State: "down", Reason: "Not responding"},
+		{Hostname: "cn002", State: "idle"},
+	}
+	engine := newTestAlertEngine(t, func() *ClusterSnapshot { return &ClusterSnapshot{Nodes: nodes} })
+	start := time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC)
+
+	active := func() []models.Alert {
+		alerts, err := engine.Alerts("", 0)
+		if err != nil {
+			t.Fatalf("Alerts() error: %v", err)
+		}
+		return alerts
+	}
+
+	steps := []struct {
+		name    string
+		offset  time.Duration
+		nodes   []models.NodeModel
+		states  []string // 各未恢复告警的状态
+		resolve int      // 已恢复告警的数量
+	}{
+		{"pending", 0, nil, []string{AlertPending}, 0},
+		{"still pending", 2 * time.Minute, nil, []string{AlertPending}, 0},
+		{"firing after hold", 5 * time.Minute, nil, []string{AlertFiring}, 0},
+		// slurmctld 无响应时节点列表为空，告警保持不变
+		{"source unavailable", 6 * time.Minute, []models.NodeModel{}, []string{AlertFiring}, 0},
+		{"resolved", 7 * time.Minute, []models.NodeModel{{Hostname: "cn001", State: "idle"}, {Hostname: "cn002", State: "down", StateFlags: []string{"NOT_RESPONDING"}}}, []string{AlertPending}, 1},
+		// 未达到持续时间就恢复的 pending 告警直接删除，不记入已恢复
+		{"pending cleared", 8 * time.Minute, []models.NodeModel{{Hostname: "cn001", State: "idle"}, {Hostname: "cn002", State: "idle"}}, []string{}, 1},
+	}
+	var firstID string
+	for _, step := range steps {
+		if step.nodes != nil {
+			nodes = step.nodes
+		}
+		now := start.Add(step.offset)
+		engine.Evaluate(now)
+
+		alerts := active()
+		states := []string{}
+		for _, alert := range alerts {
+			states = append(states, alert.State)
+			// 来源没有数据时告警保持上一次评估的结果
+			if len(nodes) > 0 && alert.LastEval != now {
+				t.Errorf("%s: alert %s last eval = %v, want %v", step.name, alert.Rule, alert.LastEval, now)
+			}
+		}
+		if len(states) != len(step.states) || (len(states) > 0 && states[0] != step.states[0]) {
+			t.Fatalf("%s: states = %v, want %v", step.name, states, step.states)
+		}
+		resolved, _ := engine.Alerts(AlertResolved, 10)
+		if len(resolved) != step.resolve {
+			t.Fatalf("%s: %d resolved alerts, want %d", step.name, len(resolved), step.resolve)
+		}
+
+		if step.offset > 5*time.Minute || len(alerts) == 0 {
+			continue
+		}
+		// 同一节点的重复匹配对应同一条告警
+		alert := alerts[0]
+		if firstID == "" {
+			firstID = alert.ID
+		}
+		if alert.ID != firstID || !alert.ActiveSince.Equal(start) || alert.Labels["hostname"] != "cn001" || alert.Labels["cluster"] != "alpha" {
+			t.Errorf("%s: alert = %+v", step.name, alert)
+		}
+		if alert.Summary != "节点 cn001 已宕机 Not responding" {
+			t.Errorf("%s: summary = %q", step.name, alert.Summary)
+		}
+		if fired := alert.FiredAt != nil; fired != (alert.State == AlertFiring) {
+			t.Errorf("%s: fired at = %v in state %s", step.name, alert.FiredAt, alert.State)
+		}
+	}
+
+	resolved, _ := engine.Alerts(AlertResolved, 10)
+	if alert := resolved[0]; alert.ID != firstID || alert.State != AlertResolved || alert.ResolvedAt == nil || !alert.ResolvedAt.Equal(start.Add(7*time.Minute)) {
+		t.Errorf("resolved alert = %+v", alert)
+	}
+	if !resolved[0].FiredAt.Equal(start.Add(5 * time.Minute)) {
+		t.Errorf("resolved alert fired at %v", resolved[0].FiredAt)
+	}
+}
+
+func TestAlertEngineSilenceAndState(t *testing.T) {
+	nodes := []models.NodeModel{{Hostname: "cn001", State: "down"}, {Hostname: "gpu001", State: "down"}}
+	engine := newTestAlertEngine(t, func() *ClusterSnapshot { return &ClusterSnapshot{Nodes: nodes} })
+	now := time.Now()
+
+	silence, err := engine.AddSilence(models.Silence{Matchers: map[string]string{"rule": "NodeDown", "hostname": "cn*"}, EndsAt: now.Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("AddSilence() error: %v", err)
+	}
+	engine.Evaluate(now)
+	engine.Evaluate(now.Add(5 * time.Minute))
+
+	alerts, _ := engine.Alerts(AlertFiring, 0)
+	if len(alerts) != 2 {
+		t.Fatalf("got %d firing alerts, want 2", len(alerts))
+	}
+	for _, alert := range alerts {
+		if silenced := alert.Labels["hostname"] == "cn001"; alert.Silenced != silenced || (silenced && alert.SilencedBy != silence.ID) {
+			t.Errorf("alert %s silenced = %v by %q", alert.Labels["hostname"], alert.Silenced, alert.SilencedBy)
+		}
+	}
+
+	// 删除静默后立即取消标记
+	if err := engine.DeleteSilence(silence.ID); err != nil {
+		t.Fatalf("DeleteSilence() error: %v", err)
+	}
+	alerts, _ = engine.Alerts(AlertFiring, 0)
+	for _, alert := range alerts {
+		if alert.Silenced {
+			t.Errorf("alert %s still silenced", alert.Labels["hostname"])
+		}
+	}
+
+	// 重启后从状态文件恢复未恢复的告警
+	reloaded := NewAlertEngine(AlertSources{})
+	if alerts, _ := reloaded.Alerts(AlertFiring, 0); len(alerts) != 2 {
+		t.Errorf("reloaded %d firing alerts, want 2", len(alerts))
+	}
+
+	for _, matchers := range []map[string]string{nil, {"hostname": "["}} {
+		if _, err := engine.AddSilence(models.Silence{Matchers: matchers, EndsAt: now.Add(time.Hour)}); err == nil {
+			t.Errorf("AddSilence(%v) = nil error", matchers)
+		}
+	}
+	if _, err := engine.AddSilence(models.Silence{Matchers: map[string]string{"rule": "*"}, StartsAt: now, EndsAt: now}); err == nil {
+		t.Error("AddSilence() with empty time range = nil error")
+	}
+}
+
+func TestCompileAlertRules(t *testing.T) {
+	if _, err := compileAlertRules(defaultAlertRules); err != nil {
+		t.Fatalf("default rules: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		rule models.AlertRule
+	}{
+		{"empty name", models.AlertRule{Target: "node", Expr: "drain"}},
+		{"unknown target", models.AlertRule{Name: "X", Target: "rack", Expr: "drain"}},
+		{"bad severity", models.AlertRule{Name: "X", Target: "node", Expr: "drain", Severity: "info"}},
+		{"unknown field", models.AlertRule{Name: "X", Target: "node", Expr: "used_percent > 90"}},
+		{"bad duration", models.AlertRule{Name: "X", Target: "node", Expr: "drain", For: "5 minutes"}},
+		{"negative duration", models.AlertRule{Name: "X", Target: "node", Expr: "drain", For: "-1m"}},
+	}
+	for _, tt := range tests {
+		if _, err := compileAlertRules([]models.AlertRule{tt.rule}); err == nil {
+			t.Errorf("%s: compileAlertRules() = nil error", tt.name)
+		}
+	}
+
+	duplicate := []models.AlertRule{{Name: "X", Target: "node", Expr: "drain"}, {Name: "X", Target: "cluster", Expr: "down_nodes > 0"}}
+	if _, err := compileAlertRules(duplicate); err == nil {
+		t.Error("compileAlertRules() with duplicate names = nil error")
+	}
+	compiled, err := compileAlertRules(duplicate[:1])
+	if err != nil || compiled[0].rule.Severity != "warning" || compiled[0].hold != 0 {
+		t.Errorf("compileAlertRules() = %+v, %v, want default severity warning", compiled, err)
+	}
+}
@@ -0,0 +1,404 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// assocFields sacctmgr show assoc 使用的字段，顺序与 BuildAccountTree 对应
+var assocFields = []string{
+	"Account", "User", "Partition", "ParentName", "Share",
+	"GrpTRES", "GrpJobs", "GrpSubmit", "MaxTRES", "MaxJobs", "MaxSubmit", "MaxWall",
+	"QOS", "DefaultQOS",
+}
+
+// shareFields sshare 使用的字段，顺序与 parseShares 对应
+var shareFields = []string{
+	"Account", "User", "RawShares", "NormShares", "RawUsage", "EffectvUsage", "FairShare", "LevelFS",
+}
+
+// 账户名、用户名及限制取值的合法格式，防止参数注入
+var (
+	assocNamePattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.\-]*$`)
+	tresLimitPattern = regexp.MustCompile(`^([A-Za-z0-9/:_\-]+=(-1|\d+[KMGTP]?)(,|$))+$`)
+	assocTextPattern = regexp.MustCompile(`^[^\n\r'"=]*$`)
+)
+
+// AssociationLimits 关联上的资源限制，空字符串表示未设置
+type AssociationLimits struct {
+	GrpTRES   string `json:"grp_tres,omitempty"`
+	GrpJobs   string `json:"grp_jobs,omitempty"`
+	GrpSubmit string `json:"grp_submit,omitempty"`
+	MaxTRES   string `json:"max_tres,omitempty"`
+	MaxJobs   string `json:"max_jobs,omitempty"`
+	MaxSubmit string `json:"max_submit,omitempty"`
+	MaxWall   string `json:"max_wall,omitempty"`
+}
+
+// FairshareInfo sshare 返回的共享与用量信息
+type FairshareInfo struct {
+	RawShares      string  `json:"raw_shares"`
+	NormShares     float64 `json:"norm_shares"`
+	RawUsage       int64   `json:"raw_usage"`
+	EffectiveUsage float64 `json:"effective_usage"`
+	FairShare      float64 `json:"fair_share"`
+	LevelFS        string  `json:"level_fs,omitempty"`
+}
+
+// UserAssociation 用户在某个账户下的关联
+type UserAssociation struct {
+	User       string            `json:"user"`
+	Partition  string            `json:"partition,omitempty"`
+	QOS        string            `json:"qos,omitempty"`
+	DefaultQOS string            `json:"default_qos,omitempty"`
+	Limits     AssociationLimits `json:"limits"`
+	Fairshare  *FairshareInfo    `json:"fairshare,omitempty"`
+}
+
+// AccountNode 账户树节点
+type AccountNode struct {
+	Name      string            `json:"name"`
+	Parent    string            `json:"parent,omitempty"`
+	QOS       string            `json:"qos,omitempty"`
+	Limits    AssociationLimits `json:"limits"`
+	Fairshare *FairshareInfo    `json:"fairshare,omitempty"`
+	Users     []UserAssociation `json:"users"`
+	Children  []*AccountNode    `json:"children"`
+}
+
+// GetAssociationTree 读取 sacctmgr 关联与 sshare 公平共享数据，构建以 root 为根的账户树
+func GetAssociationTree(cluster *Cluster) ([]*AccountNode, error) {
+	assocOutput, err := runSlurmCommand(cluster, "sacctmgr", "show", "assoc", "--parsable2", "--noheader",
+		"format="+strings.Join(assocFields, ","))
+	if err != nil {
+		return nil, err
+	}
+
+	// sshare 失败时（如未启用优先级插件）仍返回关联结构
+	shares := map[string]FairshareInfo{}
+	if shareOutput, err := runSlurmCommand(cluster, "sshare", "--all", "--long", "--parsable2", "--noheader",
+		"--format="+strings.Join(shareFields, ",")); err == nil {
+		shares = parseShares(shareOutput)
+	}
+
+	return BuildAccountTree(assocOutput, shares), nil
+}
+
+// parseShares 解析 sshare 输出，键为 "账户/用户"（账户行的用户为空）
+func parseShares(output string) map[string]FairshareInfo {
+	shares := make(map[string]FairshareInfo)
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		parts := strings.Split(line, "|")
+		if len(parts) < len(shareFields) {
+			continue
+		}
+		normShares, _ := strconv.ParseFloat(parts[3], 64)
+		effUsage, _ := strconv.ParseFloat(parts[5], 64)
+		fairShare, _ := strconv.ParseFloat(parts[6], 64)
+		// sshare 以前导空格表示账户层级
+		key := strings.TrimSpace(parts[0]) + "/" + strings.TrimSpace(parts[1])
+		shares[key] = FairshareInfo{
+			RawShares:      parts[2],
+			NormShares:     normShares,
+			RawUsage:       parseSlurmInt(parts[4]),
+			EffectiveUsage: effUsage,
+			FairShare:      fairShare,
+			LevelFS:        parts[7],
+		}
+	}
+	return shares
+}
+
+// BuildAccountTree 由 sacctmgr show assoc 输出和 sshare 数据构建账户树
+func BuildAccountTree(assocOutput string, shares map[string]FairshareInfo) []*AccountNode {
+	accounts := make(map[string]*AccountNode)
+	var order []string
+
+	getAccount := func(name string) *AccountNode {
+		node, ok := accounts[name]
+		if !ok {
+			node = &AccountNode{Name: name, Users: []UserAssociation{}, Children: []*AccountNode{}}
+			accounts[name] = node
+			order = append(order, name)
+		}
+		return node
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(assocOutput), "\n") {
+		parts := strings.Split(line, "|")
+		if len(parts) < len(assocFields) || parts[0] == "" {
+			continue
+		}
+		limits := AssociationLimits{
+			GrpTRES:   parts[5],
+			GrpJobs:   parts[6],
+			GrpSubmit: parts[7],
+			MaxTRES:   parts[8],
+			MaxJobs:   parts[9],
+			MaxSubmit: parts[10],
+			MaxWall:   parts[11],
+		}
+
+		account := getAccount(parts[0])
+		if parts[1] == "" {
+			// 账户自身的关联
+			account.Parent = parts[3]
+			account.QOS = parts[12]
+			account.Limits = limits
+			if info, ok := shares[parts[0]+"/"]; ok {
+				account.Fairshare = &info
+			}
+			continue
+		}
+
+		user := UserAssociation{
+			User:       parts[1],
+			Partition:  parts[2],
+			QOS:        parts[12],
+			DefaultQOS: parts[13],
+			Limits:     limits,
+		}
+		if info, ok := shares[parts[0]+"/"+parts[1]]; ok {
+			user.Fairshare = &info
+		}
+		account.Users = append(account.Users, user)
+	}
+
+	var roots []*AccountNode
+	for _, name := range order {
+		node := accounts[name]
+		if parent, ok := accounts[node.Parent]; ok && node.Parent != name {
+			parent.Children = append(parent.Children, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+	for _, node := range accounts {
+		sort.Slice(node.Children, func(i, j int) bool { return node.Children[i].Name < node.Children[j].Name })
+		sort.Slice(node.Users, func(i, j int) bool { return node.Users[i].User < node.Users[j].User })
+	}
+	return roots
+}
+
+// AccountChangeRequest 账户变更请求
+type AccountChangeRequest struct {
+	Operation    string            `json:"operation"` // create_account、add_user 或 set_limits
+	Account      string            `json:"account"`
+	Parent       string            `json:"parent,omitempty"`
+	Description  string            `json:"description,omitempty"`
+	Organization string            `json:"organization,omitempty"`
+	User         string            `json:"user,omitempty"`
+	Limits       AssociationLimits `json:"limits"`
+}
+
+// AccountChangePlan 变更预览，确认后按 Args 执行 sacctmgr
+type AccountChangePlan struct {
+	Summary string   `json:"summary"`
+	Command string   `json:"command"`
+	Args    []string `json:"-"`
+}
+
+// PlanAccountChange 校验变更请求并生成预览，不做任何修改
+func PlanAccountChange(req AccountChangeRequest) (*AccountChangePlan, error) {
+	if !assocNamePattern.MatchString(req.Account) {
+		return nil, fmt.Errorf("无效的账户名: %q", req.Account)
+	}
+
+	var args []string
+	var summary string
+	switch req.Operation {
+	case "create_account":
+		args = []string{"add", "account", req.Account}
+		if req.Parent != "" {
+			if !assocNamePattern.MatchString(req.Parent) {
+				return nil, fmt.Errorf("无效的父账户名: %q", req.Parent)
+			}
+			args = append(args, "parent="+req.Parent)
+		}
+		if !assocTextPattern.MatchString(req.Description) || !assocTextPattern.MatchString(req.Organization) {
+			return nil, fmt.Errorf("描述或组织名包含非法字符")
+		}
+		if req.Description != "" {
+			args = append(args, "description="+req.Description)
+		}
+		if req.Organization != "" {
+			args = append(args, "organization="+req.Organization)
+		}
+		limitArgs, err := limitsArgs(req.Limits)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, limitArgs...)
+		summary = fmt.Sprintf("创建账户 %s", req.Account)
+		if req.Parent != "" {
+			summary += fmt.Sprintf("（父账户 %s）", req.Parent)
+		}
+
+	case "add_user":
+		if !assocNamePattern.MatchString(req.User) {
+			return nil, fmt.Errorf("无效的用户名: %q", req.User)
+		}
+		args = []string{"add", "user", req.User, "account=" + req.Account}
+		limitArgs, err := limitsArgs(req.Limits)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, limitArgs...)
+		summary = fmt.Sprintf("将用户 %s 加入账户 %s", req.User, req.Account)
+
+	case "set_limits":
+		limitArgs, err := limitsArgs(req.Limits)
+		if err != nil {
+			return nil, err
+		}
+		if len(limitArgs) == 0 {
+			return nil, fmt.Errorf("未指定任何限制")
+		}
+		if req.User != "" {
+			if !assocNamePattern.MatchString(req.User) {
+				return nil, fmt.Errorf("无效的用户名: %q", req.User)
+			}
+			args = []string{"modify", "user", "where", "name=" + req.User, "account=" + req.Account, "set"}
+			summary = fmt.Sprintf("修改用户 %s 在账户 %s 下的限制", req.User, req.Account)
+		} else {
+			args = []string{"modify", "account", "where", "name=" + req.Account, "set"}
+			summary = fmt.Sprintf("修改账户 %s 的限制", req.Account)
+		}
+		args = append(args, limitArgs...)
+
+	default:
+		return nil, fmt.Errorf("不支持的操作: %s", req.Operation)
+	}
+
+	// -i 跳过 sacctmgr 自身的交互确认，确认由面板完成
+	args = append([]string{"-i"}, args...)
+	return &AccountChangePlan{
+		Summary: summary,
+		Command: "sacctmgr " + strings.Join(args, " "),
+		Args:    args,
+	}, nil
+}
+
+// ApplyAccountChange 执行已确认的变更
+func ApplyAccountChange(cluster *Cluster, plan *AccountChangePlan) (string, error) {
+	output, err := runSlurmCommand(cluster, "sacctmgr", plan.Args...)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// accountChangePreviewTTL 预览的有效期，超时后需要重新预览
+const accountChangePreviewTTL = 10 * time.Minute
+
+// accountChangePreview 已生成的预览，只能由生成它的用户在同一集群上执行一次
+type accountChangePreview struct {
+	request AccountChangeRequest
+	plan    *AccountChangePlan
+	user    string
+	cluster string
+	expires time.Time
+}
+
+// AccountChangePreviews 保存已生成的账户变更预览，执行变更时必须提供预览 ID，不能跳过预览直接执行
+type AccountChangePreviews struct {
+	mutex    sync.Mutex
+	previews map[string]accountChangePreview
+}
+
+// NewAccountChangePreviews 创建新的预览存储
+func NewAccountChangePreviews() *AccountChangePreviews {
+	return &AccountChangePreviews{previews: make(map[string]accountChangePreview)}
+}
+
+// Save 保存预览并返回预览 ID，同时清理已过期的预览
+func (p *AccountChangePreviews) Save(req AccountChangeRequest, plan *AccountChangePlan, user, cluster string) (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	now := time.Now()
+	for key, preview := range p.previews {
+		if now.After(preview.expires) {
+			delete(p.previews, key)
+		}
+	}
+	key := hex.EncodeToString(id)
+	p.previews[key] = accountChangePreview{
+		request: req,
+		plan:    plan,
+		user:    user,
+		cluster: cluster,
+		expires: now.Add(accountChangePreviewTTL),
+	}
+	return key, nil
+}
+
+// Take 取出预览，取出后即失效；预览不存在、已过期或用户、集群不一致时返回错误
+func (p *AccountChangePreviews) Take(id, user, cluster string) (AccountChangeRequest, *AccountChangePlan, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	preview, ok := p.previews[id]
+	if !ok || time.Now().After(preview.expires) {
+		delete(p.previews, id)
+		return AccountChangeRequest{}, nil, fmt.Errorf("预览不存在或已过期，请重新预览")
+	}
+	if preview.user != user || preview.cluster != cluster {
+		return AccountChangeRequest{}, nil, fmt.Errorf("预览不属于当前用户或集群")
+	}
+	delete(p.previews, id)
+	return preview.request, preview.plan, nil
+}
+
+// limitsArgs 校验限制并转换为 sacctmgr 参数，-1 表示清除限制
+func limitsArgs(limits AssociationLimits) ([]string, error) {
+	var args []string
+	tres := []struct{ key, value string }{
+		{"GrpTRES", limits.GrpTRES},
+		{"MaxTRES", limits.MaxTRES},
+	}
+	for _, item := range tres {
+		if item.value == "" {
+			continue
+		}
+		if !tresLimitPattern.MatchString(item.value) {
+			return nil, fmt.Errorf("%s 格式无效: %q，应形如 cpu=100,gres/gpu=4", item.key, item.value)
+		}
+		args = append(args, item.key+"="+item.value)
+	}
+
+	counts := []struct{ key, value string }{
+		{"GrpJobs", limits.GrpJobs},
+		{"GrpSubmit", limits.GrpSubmit},
+		{"MaxJobs", limits.MaxJobs},
+		{"MaxSubmit", limits.MaxSubmit},
+	}
+	for _, item := range counts {
+		if item.value == "" {
+			continue
+		}
+		if n, err := strconv.Atoi(item.value); err != nil || n < -1 {
+			return nil, fmt.Errorf("%s 必须为非负整数或 -1: %q", item.key, item.value)
+		}
+		args = append(args, item.key+"="+item.value)
+	}
+
+	if limits.MaxWall != "" {
+		if _, err := parseSlurmDuration(limits.MaxWall); err != nil && limits.MaxWall != "-1" {
+			return nil, fmt.Errorf("MaxWall 格式无效: %q", limits.MaxWall)
+		}
+		args = append(args, "MaxWall="+limits.MaxWall)
+	}
+	return args, nil
+}
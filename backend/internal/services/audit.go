@@ -0,0 +1,119 @@
+package services
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"panel-tool/internal/utils"
+)
+
+// defaultAuditLogFile 审计日志的默认路径，可通过 PANEL_AUDIT_LOG 覆盖
+const defaultAuditLogFile = "./logs/audit.log"
+
+// AuditEntry 审计记录
+type AuditEntry struct {
+	Time    time.Time `json:"time"`
+	User    string    `json:"user"`
+	Action  string    `json:"action"`
+	Target  string    `json:"target"`
+	Detail  string    `json:"detail,omitempty"`
+	Success bool      `json:"success"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// AuditService 审计日志服务，以 JSON Lines 格式追加写入文件
+type AuditService struct {
+	logger *utils.Logger
+	path   string
+	mutex  sync.Mutex
+}
+
+// NewAuditService 创建新的审计日志服务实例
+func NewAuditService() *AuditService {
+	path := os.Getenv("PANEL_AUDIT_LOG")
+	if path == "" {
+		path = defaultAuditLogFile
+	}
+	return &AuditService{
+		logger: utils.NewLogger(),
+		path:   path,
+	}
+}
+
+// Record 写入一条审计记录，写入失败只记录错误日志，不影响调用方
+func (s *AuditService) Record(entry AuditEntry) {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	s.logger.Info(fmt.Sprintf("审计: 用户 %s 执行 %s %s，成功: %v", entry.User, entry.Action, entry.Target, entry.Success))
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("序列化审计记录失败: %v", err))
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		s.logger.Error(fmt.Sprintf("创建审计日志目录失败: %v", err))
+		return
+	}
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0640)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("打开审计日志失败: %v", err))
+		return
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		s.logger.Error(fmt.Sprintf("写入审计日志失败: %v", err))
+	}
+}
+
+// List 返回最近的审计记录（按时间倒序），limit 不大于 0 时返回全部
+func (s *AuditService) List(limit int) ([]AuditEntry, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	file, err := os.Open(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []AuditEntry{}, nil
+		}
+		return nil, fmt.Errorf("打开审计日志失败: %v", err)
+	}
+	defer file.Close()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取审计日志失败: %v", err)
+	}
+
+	// 倒序，最新的记录在前
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	if entries == nil {
+		entries = []AuditEntry{}
+	}
+	return entries, nil
+}
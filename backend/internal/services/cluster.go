@@ -0,0 +1,261 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"panel-tool/internal/utils"
+)
+
+// defaultClustersFile 集群配置文件的默认路径，可通过 PANEL_CLUSTERS_FILE 覆盖
+const defaultClustersFile = "./config/clusters.json"
+
+// AllClusters 请求中表示聚合所有集群的取值
+const AllClusters = "all"
+
+// Cluster 一个 Slurm 集群，命令通过 SLURM_CONF 指向该集群的配置文件
+// 为 nil 时表示使用本机默认配置
+// 只配置 rest_url 的集群通过 slurmrestd 读取作业、节点和分区，依赖命令行工具的操作（作业详情、sacct、scontrol 等）返回错误
+// SlurmCluster 为 slurm.conf 中的 ClusterName，用于 sacct --clusters，为空时从 slurm_conf 读取，仍为空时与 Name 相同
+type Cluster struct {
+	Name         string `json:"name"`
+	SlurmConf    string `json:"slurm_conf,omitempty"`
+	SlurmCluster string `json:"slurm_cluster,omitempty"`
+	RestURL      string `json:"rest_url,omitempty"`
+	// RestToken 为 slurmrestd 的 JWT，为空时使用 SLURM_JWT 环境变量；RestUser 为 X-SLURM-USER-NAME
+	RestToken   string `json:"rest_token,omitempty"`
+	RestUser    string `json:"rest_user,omitempty"`
+	RestVersion string `json:"rest_version,omitempty"`
+	Default     bool   `json:"default"`
+}
+
+// ClusterRegistry 已配置的集群列表
+type ClusterRegistry struct {
+	logger   *utils.Logger
+	clusters []*Cluster
+	err      error
+}
+
+// NewClusterRegistry 从配置文件加载集群列表，文件不存在时只包含本机默认集群
+// 配置文件格式: [{"name": "alpha", "slurm_conf": "/etc/slurm/slurm.conf", "default": true},
+// {"name": "beta", "rest_url": "http://beta-ctl:6820", "rest_user": "slurm"}, ...]
+func NewClusterRegistry() *ClusterRegistry {
+	r := &ClusterRegistry{logger: utils.NewLogger()}
+
+	path := os.Getenv("PANEL_CLUSTERS_FILE")
+	if path == "" {
+		path = defaultClustersFile
+	}
+	clusters, err := loadClusters(path)
+	if err != nil && !os.IsNotExist(err) {
+		r.logger.Error(fmt.Sprintf("读取集群配置失败: %v", err))
+		r.err = err
+		clusters = nil
+	}
+
+	for _, cluster := range clusters {
+		if cluster.Name == "" || cluster.Name == AllClusters {
+			r.logger.Error(fmt.Sprintf("忽略无效的集群名称: %q", cluster.Name))
+			continue
+		}
+		if _, err := r.Get(cluster.Name); err == nil {
+			r.logger.Error(fmt.Sprintf("集群 %s 重复配置，已忽略", cluster.Name))
+			continue
+		}
+		if cluster.SlurmCluster == "" {
+			cluster.SlurmCluster = slurmConfClusterName(cluster.SlurmConf)
+		}
+		r.clusters = append(r.clusters, cluster)
+	}
+
+	if len(r.clusters) == 0 {
+		r.clusters = []*Cluster{localCluster()}
+	}
+
+	// 未指定默认集群时以第一个为默认
+	hasDefault := false
+	for _, cluster := range r.clusters {
+		if cluster.Default {
+			if hasDefault {
+				cluster.Default = false
+			}
+			hasDefault = true
+		}
+	}
+	if !hasDefault {
+		r.clusters[0].Default = true
+	}
+	return r
+}
+
+// loadClusters 读取集群配置文件
+func loadClusters(path string) ([]*Cluster, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var clusters []*Cluster
+	if err := json.Unmarshal(data, &clusters); err != nil {
+		return nil, fmt.Errorf("解析 %s 失败: %v", path, err)
+	}
+	for _, cluster := range clusters {
+		if cluster.SlurmConf == "" && cluster.RestURL == "" {
+			// 忽略该集群会让请求悄悄落到本机默认配置上，因此整个配置视为无效
+			return nil, fmt.Errorf("集群 %q 既未配置 slurm_conf 也未配置 rest_url", cluster.Name)
+		}
+	}
+	return clusters, nil
+}
+
+// Err 返回加载集群配置时的错误，配置文件不存在时为 nil
+func (r *ClusterRegistry) Err() error {
+	return r.err
+}
+
+// localCluster 本机默认集群，名称取自 slurm.conf 中的 ClusterName
+func localCluster() *Cluster {
+	cluster := &Cluster{Name: "default", SlurmConf: SlurmConfPath(), Default: true}
+	if name := slurmConfClusterName(cluster.SlurmConf); name != "" {
+		cluster.Name = name
+		cluster.SlurmCluster = name
+	}
+	return cluster
+}
+
+// slurmConfClusterName 读取 slurm.conf 中的 ClusterName，路径为空或读取失败时返回空字符串
+func slurmConfClusterName(path string) string {
+	if path == "" {
+		return ""
+	}
+	cfg, err := ParseSlurmConf(path)
+	if err != nil {
+		return ""
+	}
+	name := ""
+	for _, param := range cfg.Parameters {
+		if strings.EqualFold(param.Key, "ClusterName") && param.Value != "" {
+			name = param.Value
+		}
+	}
+	return name
+}
+
+// List 返回所有集群
+func (r *ClusterRegistry) List() []*Cluster {
+	return r.clusters
+}
+
+// Get 按名称查找集群，名称为空时返回默认集群
+func (r *ClusterRegistry) Get(name string) (*Cluster, error) {
+	for _, cluster := range r.clusters {
+		if (name == "" && cluster.Default) || cluster.Name == name {
+			return cluster, nil
+		}
+	}
+	return nil, fmt.Errorf("未知的集群: %s", name)
+}
+
+// Resolve 解析请求中的集群参数，all 表示所有集群
+func (r *ClusterRegistry) Resolve(name string) ([]*Cluster, error) {
+	if name == AllClusters {
+		return r.clusters, nil
+	}
+	cluster, err := r.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return []*Cluster{cluster}, nil
+}
+
+// Names 返回集群名称列表
+func (r *ClusterRegistry) Names() []string {
+	names := make([]string, 0, len(r.clusters))
+	for _, cluster := range r.clusters {
+		names = append(names, cluster.Name)
+	}
+	return names
+}
+
+// SlurmNames 返回各集群在 Slurm 中的 ClusterName，用于 sacct --clusters
+func (r *ClusterRegistry) SlurmNames() []string {
+	names := make([]string, 0, len(r.clusters))
+	for _, cluster := range r.clusters {
+		names = append(names, cluster.SlurmName())
+	}
+	return names
+}
+
+// ClusterName 返回集群名称，nil 表示本机默认配置，返回空字符串
+func (c *Cluster) ClusterName() string {
+	if c == nil {
+		return ""
+	}
+	return c.Name
+}
+
+// SlurmName 返回集群在 Slurm 中的 ClusterName，未知时返回面板中的名称
+func (c *Cluster) SlurmName() string {
+	if c == nil {
+		return ""
+	}
+	if c.SlurmCluster != "" {
+		return c.SlurmCluster
+	}
+	return c.Name
+}
+
+// restOnly 判断集群是否只配置了 slurmrestd 端点
+func (c *Cluster) restOnly() bool {
+	return c != nil && c.SlurmConf == "" && c.RestURL != ""
+}
+
+// errRESTOnly 只配置 slurmrestd 的集群不支持依赖命令行工具或 slurm.conf 的操作
+func (c *Cluster) errRESTOnly(what string) error {
+	return fmt.Errorf("集群 %s 只配置了 slurmrestd 端点，不支持 %s，如需使用请为其配置 slurm_conf", c.Name, what)
+}
+
+// isLocal 判断是否为使用本机 slurm.conf 的集群（slurmctld 可能运行在本机）
+func (c *Cluster) isLocal() bool {
+	return !c.restOnly() && c.confPath() == SlurmConfPath()
+}
+
+// confPath 返回集群的 slurm.conf 路径
+func (c *Cluster) confPath() string {
+	if c == nil || c.SlurmConf == "" {
+		return SlurmConfPath()
+	}
+	return c.SlurmConf
+}
+
+// command 创建在该集群上执行的命令
+func (c *Cluster) command(name string, args ...string) *exec.Cmd {
+	cmd := exec.Command(name, args...)
+	if c != nil && c.SlurmConf != "" {
+		cmd.Env = append(os.Environ(), "SLURM_CONF="+c.SlurmConf)
+	}
+	return cmd
+}
+
+// runSlurmCommand 在指定集群上执行 Slurm 命令，失败时把 stderr 内容带入错误信息
+// 只配置 slurmrestd 的集群没有可用的 slurm.conf，直接返回错误而不是落到本机默认配置上
+func runSlurmCommand(cluster *Cluster, name string, args ...string) (string, error) {
+	if cluster.restOnly() {
+		return "", cluster.errRESTOnly(name + " 命令")
+	}
+	cmd := cluster.command(name, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			return "", fmt.Errorf("执行 %s 失败: %v", name, err)
+		}
+		return "", fmt.Errorf("执行 %s 失败: %s", name, msg)
+	}
+	return string(output), nil
+}
@@ -0,0 +1,345 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"panel-tool/internal/models"
+	"panel-tool/internal/utils"
+)
+
+// defaultCollectInterval 集群状态默认刷新间隔，可通过 PANEL_COLLECT_INTERVAL（秒）覆盖
+const defaultCollectInterval = 10 * time.Second
+
+// subscriberBuffer 每个订阅者的事件缓冲区大小，缓冲区满时丢弃事件而不阻塞采集
+const subscriberBuffer = 256
+
+// 集群变更事件类型
+const (
+	EventJobSubmitted    = "job.submitted"
+	EventJobStarted      = "job.started"
+	EventJobFinished     = "job.finished"
+	EventJobStateChanged = "job.state_changed"
+	EventNodeAdded       = "node.added"
+	EventNodeRemoved     = "node.removed"
+	EventNodeChanged     = "node.state_changed"
+)
+
+// ClusterSnapshot 某一时刻的集群状态
+type ClusterSnapshot struct {
+	Time       time.Time               `json:"time"`
+	Jobs       []models.JobModel       `json:"jobs"`
+	Nodes      []models.NodeModel      `json:"nodes"`
+	Partitions []models.PartitionModel `json:"partitions"`
+}
+
+// ClusterEvent 集群状态变更事件
+type ClusterEvent struct {
+	Type     string            `json:"type"`
+	Cluster  string            `json:"cluster,omitempty"`
+	Time     time.Time         `json:"time"`
+	JobID    string            `json:"job_id,omitempty"`
+	Node     string            `json:"node,omitempty"`
+	OldState string            `json:"old_state,omitempty"`
+	NewState string            `json:"new_state,omitempty"`
+	Job      *models.JobModel  `json:"job,omitempty"`
+	NodeInfo *models.NodeModel `json:"node_info,omitempty"`
+}
+
+// ClusterCollector 后台定时采集集群状态，缓存最新快照并向订阅者推送变更事件
+type ClusterCollector struct {
+	cluster  *Cluster
+	logger   *utils.Logger
+	interval time.Duration
+
+	// agents 为空时不合并节点代理上报
+	agents *AgentRegistry
+
+	// collectMutex 保证同一时刻只有一次采集，避免重复推送事件
+	collectMutex sync.Mutex
+
+	snapshotMutex sync.RWMutex
+	snapshot      *ClusterSnapshot
+
+	subscribersMutex sync.Mutex
+	subscribers      map[chan ClusterEvent]struct{}
+
+	startOnce sync.Once
+	stop      chan struct{}
+}
+
+// NewClusterCollector 创建新的集群状态采集器
+func NewClusterCollector(cluster *Cluster) *ClusterCollector {
+	interval := defaultCollectInterval
+	if value := os.Getenv("PANEL_COLLECT_INTERVAL"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+			interval = time.Duration(seconds) * time.Second
+		}
+	}
+
+	return &ClusterCollector{
+		cluster:     cluster,
+		logger:      utils.NewLogger(),
+		interval:    interval,
+		subscribers: make(map[chan ClusterEvent]struct{}),
+		stop:        make(chan struct{}),
+	}
+}
+
+// UseAgents 设置节点代理注册表，采集节点信息后合并代理上报的实测指标，需在 Start 之前调用
+func (c *ClusterCollector) UseAgents(agents *AgentRegistry) {
+	c.agents = agents
+}
+
+// Start 启动后台采集，重复调用无效
+func (c *ClusterCollector) Start() {
+	c.startOnce.Do(func() {
+		c.logger.Info(fmt.Sprintf("集群 %s 状态采集器启动，间隔 %s", c.cluster.ClusterName(), c.interval))
+		go c.run()
+	})
+}
+
+// Stop 停止后台采集
+func (c *ClusterCollector) Stop() {
+	close(c.stop)
+}
+
+// run 采集循环
+func (c *ClusterCollector) run() {
+	c.Collect()
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.Collect()
+		}
+	}
+}
+
+// Collect 立即采集一次集群状态，与上一次快照比较后推送变更事件
+// 某项查询暂时失败时沿用上一次的数据，避免误报大量作业结束或节点移除
+func (c *ClusterCollector) Collect() {
+	c.collectMutex.Lock()
+	defer c.collectMutex.Unlock()
+
+	c.snapshotMutex.Lock()
+	previous := c.snapshot
+	c.snapshotMutex.Unlock()
+
+	current := &ClusterSnapshot{Time: time.Now()}
+
+	jobs, err := GetQueueJobs(c.cluster)
+	switch {
+	case err == nil:
+		current.Jobs = jobs
+	case previous != nil:
+		c.logger.Error(fmt.Sprintf("采集集群 %s 作业信息失败: %v", c.cluster.ClusterName(), err))
+		current.Jobs = previous.Jobs
+	default:
+		current.Jobs = []models.JobModel{}
+	}
+
+	nodes, err := FetchComputeNodes(c.cluster)
+	switch {
+	case err == nil:
+		current.Nodes = nodes
+	case previous != nil:
+		c.logger.Error(fmt.Sprintf("采集集群 %s 节点信息失败: %v", c.cluster.ClusterName(), err))
+		current.Nodes = previous.Nodes
+	default:
+		current.Nodes = nodes
+	}
+	if c.agents != nil {
+		current.Nodes = c.agents.Merge(c.cluster.ClusterName(), current.Nodes)
+	}
+
+	partitions, err := FetchPartitions(c.cluster, current.Jobs, current.Nodes)
+	switch {
+	case err == nil:
+		current.Partitions = partitions
+	case previous != nil:
+		c.logger.Error(fmt.Sprintf("采集集群 %s 分区信息失败: %v", c.cluster.ClusterName(), err))
+		current.Partitions = previous.Partitions
+	default:
+		current.Partitions = []models.PartitionModel{}
+	}
+
+	c.snapshotMutex.Lock()
+	c.snapshot = current
+	c.snapshotMutex.Unlock()
+
+	// 首次采集只建立基线，不产生事件
+	if previous == nil {
+		return
+	}
+	for _, event := range DiffSnapshots(previous, current) {
+		event.Cluster = c.cluster.ClusterName()
+		c.publish(event)
+	}
+}
+
+// Snapshot 返回最新快照，尚未采集时同步采集一次
+func (c *ClusterCollector) Snapshot() *ClusterSnapshot {
+	c.snapshotMutex.RLock()
+	snapshot := c.snapshot
+	c.snapshotMutex.RUnlock()
+
+	if snapshot == nil {
+		c.Collect()
+		c.snapshotMutex.RLock()
+		snapshot = c.snapshot
+		c.snapshotMutex.RUnlock()
+	}
+	return snapshot
+}
+
+// Jobs 返回缓存中的作业列表
+func (c *ClusterCollector) Jobs() []models.JobModel {
+	return c.Snapshot().Jobs
+}
+
+// Nodes 返回缓存中的计算节点列表
+func (c *ClusterCollector) Nodes() []models.NodeModel {
+	return c.Snapshot().Nodes
+}
+
+// Partitions 返回缓存中的分区列表
+func (c *ClusterCollector) Partitions() []models.PartitionModel {
+	return c.Snapshot().Partitions
+}
+
+// Subscribe 订阅集群变更事件，返回事件通道和取消订阅函数
+func (c *ClusterCollector) Subscribe() (<-chan ClusterEvent, func()) {
+	ch := make(chan ClusterEvent, subscriberBuffer)
+
+	c.subscribersMutex.Lock()
+	c.subscribers[ch] = struct{}{}
+	c.subscribersMutex.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			c.subscribersMutex.Lock()
+			delete(c.subscribers, ch)
+			c.subscribersMutex.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// publish 向所有订阅者推送事件，慢订阅者的事件会被丢弃
+func (c *ClusterCollector) publish(event ClusterEvent) {
+	c.subscribersMutex.Lock()
+	defer c.subscribersMutex.Unlock()
+
+	for ch := range c.subscribers {
+		select {
+		case ch <- event:
+		default:
+			c.logger.Error(fmt.Sprintf("订阅者事件缓冲区已满，丢弃事件 %s", event.Type))
+		}
+	}
+}
+
+// DiffSnapshots 比较两次快照，生成作业与节点的变更事件
+func DiffSnapshots(previous, current *ClusterSnapshot) []ClusterEvent {
+	var events []ClusterEvent
+	now := current.Time
+
+	oldJobs := make(map[string]models.JobModel, len(previous.Jobs))
+	for _, job := range previous.Jobs {
+		oldJobs[job.JobID] = job
+	}
+	seenJobs := make(map[string]bool, len(current.Jobs))
+	for i := range current.Jobs {
+		job := &current.Jobs[i]
+		seenJobs[job.JobID] = true
+
+		old, existed := oldJobs[job.JobID]
+		if !existed {
+			events = append(events, ClusterEvent{Type: EventJobSubmitted, Time: now, JobID: job.JobID, NewState: job.Status, Job: job})
+			if job.Status != "pending" {
+				events = append(events, jobTransitionEvent(now, job, "pending"))
+			}
+			continue
+		}
+		if old.Status != job.Status {
+			events = append(events, jobTransitionEvent(now, job, old.Status))
+		}
+	}
+	for id, old := range oldJobs {
+		if seenJobs[id] || isJobFinished(stateKey(old.Status)) {
+			continue
+		}
+		// 作业未经终止状态直接离开队列（超过 MinJobAge 前未被观察到）
+		job := old
+		events = append(events, ClusterEvent{Type: EventJobFinished, Time: now, JobID: id, OldState: old.Status, Job: &job})
+	}
+
+	oldNodes := make(map[string]models.NodeModel, len(previous.Nodes))
+	for _, node := range previous.Nodes {
+		oldNodes[node.Hostname] = node
+	}
+	seenNodes := make(map[string]bool, len(current.Nodes))
+	for i := range current.Nodes {
+		node := &current.Nodes[i]
+		seenNodes[node.Hostname] = true
+
+		old, existed := oldNodes[node.Hostname]
+		if !existed {
+			events = append(events, ClusterEvent{Type: EventNodeAdded, Time: now, Node: node.Hostname, NewState: nodeStateString(*node), NodeInfo: node})
+			continue
+		}
+		if oldState, newState := nodeStateString(old), nodeStateString(*node); oldState != newState {
+			events = append(events, ClusterEvent{Type: EventNodeChanged, Time: now, Node: node.Hostname, OldState: oldState, NewState: newState, NodeInfo: node})
+		}
+	}
+	for name, old := range oldNodes {
+		if !seenNodes[name] {
+			events = append(events, ClusterEvent{Type: EventNodeRemoved, Time: now, Node: name, OldState: nodeStateString(old)})
+		}
+	}
+	return events
+}
+
+// jobTransitionEvent 根据作业的新状态生成开始、结束或状态变更事件
+func jobTransitionEvent(now time.Time, job *models.JobModel, oldState string) ClusterEvent {
+	event := ClusterEvent{Time: now, JobID: job.JobID, OldState: oldState, NewState: job.Status, Job: job}
+	switch {
+	case job.Status == "running" && oldState == "pending":
+		event.Type = EventJobStarted
+	case isJobFinished(stateKey(job.Status)):
+		event.Type = EventJobFinished
+	default:
+		event.Type = EventJobStateChanged
+	}
+	return event
+}
+
+// stateKey 将小写的作业状态还原为 Slurm 状态名，用于判断是否已结束
+func stateKey(status string) string {
+	switch status {
+	case "pending":
+		return "PENDING"
+	case "running":
+		return "RUNNING"
+	}
+	return strings.ToUpper(status)
+}
+
+// nodeStateString 节点状态与标志组合成的字符串，如 mixed+DRAIN
+func nodeStateString(node models.NodeModel) string {
+	state := node.State
+	for _, flag := range node.StateFlags {
+		state += "+" + flag
+	}
+	return state
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"panel-tool/internal/models"
+)
+
+// 判定作业资源浪费的阈值
+const (
+	wastefulCPUEfficiency    = 25.0             // CPU 效率低于该百分比视为浪费
+	wastefulMemoryEfficiency = 25.0             // 内存效率低于该百分比视为浪费
+	wastefulMinElapsed       = 10 * time.Minute // 运行时间过短的作业不参与判定
+)
+
+// JobEfficiency 单个已结束作业的资源使用效率，计算方式与 seff 一致
+type JobEfficiency struct {
+	JobID            string   `json:"job_id"`
+	JobName          string   `json:"job_name"`
+	User             string   `json:"user"`
+	Account          string   `json:"account"`
+	Partition        string   `json:"partition"`
+	State            string   `json:"state"`
+	Nodes            int64    `json:"nodes"`
+	Cores            int64    `json:"cores"`
+	Elapsed          int64    `json:"elapsed"`           // 秒
+	CPUUsed          float64  `json:"cpu_used"`          // 秒，TotalCPU
+	CoreWalltime     float64  `json:"core_walltime"`     // 秒，Elapsed × Cores
+	CPUEfficiency    float64  `json:"cpu_efficiency"`    // 百分比
+	MemoryUsed       int64    `json:"memory_used"`       // 字节，各作业步 MaxRSS 的最大值
+	MemoryRequested  int64    `json:"memory_requested"`  // 字节，每节点
+	MemoryEfficiency float64  `json:"memory_efficiency"` // 百分比
+	Wasteful         bool     `json:"wasteful"`
+	WasteReasons     []string `json:"waste_reasons,omitempty"`
+}
+
+// UserEfficiencySummary 用户维度的效率汇总
+type UserEfficiencySummary struct {
+	User             string  `json:"user"`
+	JobCount         int     `json:"job_count"`
+	WastefulJobs     int     `json:"wasteful_jobs"`
+	CoreHours        float64 `json:"core_hours"`
+	UsedCPUHours     float64 `json:"used_cpu_hours"`
+	WastedCoreHours  float64 `json:"wasted_core_hours"`
+	CPUEfficiency    float64 `json:"cpu_efficiency"`    // 按核时加权
+	MemoryEfficiency float64 `json:"memory_efficiency"` // 作业平均
+}
+
+// GetJobEfficiency 计算单个作业的资源使用效率
+func GetJobEfficiency(cluster *Cluster, jobID string) (*JobEfficiency, error) {
+	records, err := GetJobAccounting(cluster, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := ComputeJobEfficiencies(records)
+	if len(jobs) == 0 {
+		return nil, fmt.Errorf("作业 %s 不存在或尚未结束", jobID)
+	}
+	return &jobs[0], nil
+}
+
+// GetEfficiencyReport 计算时间范围内已结束作业的效率，返回作业明细与按用户汇总
+func GetEfficiencyReport(q *JobHistoryQuery) ([]JobEfficiency, []UserEfficiencySummary, error) {
+	q.IncludeSteps = true
+	q.SortBy = "job_id"
+	q.Order = "asc"
+	records, err := QueryJobHistory(q)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	jobs := ComputeJobEfficiencies(records)
+	return jobs, SummarizeEfficiencyByUser(jobs), nil
+}
+
+// ComputeJobEfficiencies 由含作业步的 sacct 记录计算各已结束作业的效率
+func ComputeJobEfficiencies(records []models.JobAccountingRecord) []JobEfficiency {
+	allocations := make(map[string]*models.JobAccountingRecord)
+	maxRSS := make(map[string]int64)
+	var order []string
+
+	for i := range records {
+		record := &records[i]
+		base, _, isStep := strings.Cut(record.JobID, ".")
+		if !isStep {
+			if _, exists := allocations[base]; !exists {
+				order = append(order, base)
+			}
+			allocations[base] = record
+			continue
+		}
+		if record.MaxRSS > maxRSS[base] {
+			maxRSS[base] = record.MaxRSS
+		}
+	}
+
+	var result []JobEfficiency
+	for _, id := range order {
+		job := allocations[id]
+		if !isJobFinished(job.State) || job.Start.IsZero() {
+			continue
+		}
+		result = append(result, computeEfficiency(job, maxRSS[id]))
+	}
+	return result
+}
+
+// computeEfficiency 计算单个作业分配记录的效率
+func computeEfficiency(job *models.JobAccountingRecord, memoryUsed int64) JobEfficiency {
+	tres := parseTRES(job.AllocTRES)
+	nodes := parseSlurmInt(tres["node"])
+	if nodes < 1 {
+		nodes = 1
+	}
+
+	// AllocTRES 中的 mem 为整个作业的内存，换算为每节点与 MaxRSS 比较
+	memRequested := parseSlurmSize(tres["mem"], 'M') / nodes
+	if memRequested == 0 {
+		memRequested = job.ReqMem
+	}
+
+	eff := JobEfficiency{
+		JobID:           job.JobID,
+		JobName:         job.JobName,
+		User:            job.User,
+		Account:         job.Account,
+		Partition:       job.Partition,
+		State:           job.State,
+		Nodes:           nodes,
+		Cores:           job.AllocCPUs,
+		Elapsed:         job.Elapsed,
+		CPUUsed:         job.TotalCPU,
+		CoreWalltime:    float64(job.Elapsed * job.AllocCPUs),
+		MemoryUsed:      memoryUsed,
+		MemoryRequested: memRequested,
+	}
+	if eff.CoreWalltime > 0 {
+		eff.CPUEfficiency = roundTo(eff.CPUUsed/eff.CoreWalltime*100, 2)
+	}
+	if memRequested > 0 {
+		eff.MemoryEfficiency = roundTo(float64(memoryUsed)/float64(memRequested)*100, 2)
+	}
+
+	if time.Duration(job.Elapsed)*time.Second >= wastefulMinElapsed {
+		if eff.CoreWalltime > 0 && eff.CPUEfficiency < wastefulCPUEfficiency {
+			eff.WasteReasons = append(eff.WasteReasons,
+				fmt.Sprintf("CPU 效率 %.1f%%，申请 %d 核", eff.CPUEfficiency, eff.Cores))
+		}
+		if memRequested > 0 && eff.MemoryEfficiency < wastefulMemoryEfficiency {
+			eff.WasteReasons = append(eff.WasteReasons,
+				fmt.Sprintf("内存效率 %.1f%%", eff.MemoryEfficiency))
+		}
+		eff.Wasteful = len(eff.WasteReasons) > 0
+	}
+	return eff
+}
+
+// SummarizeEfficiencyByUser 按用户汇总作业效率，浪费核时最多的用户排在最前
+func SummarizeEfficiencyByUser(jobs []JobEfficiency) []UserEfficiencySummary {
+	summaries := make(map[string]*UserEfficiencySummary)
+	memoryTotals := make(map[string]float64)
+	memoryCounts := make(map[string]int)
+
+	for _, job := range jobs {
+		summary, ok := summaries[job.User]
+		if !ok {
+			summary = &UserEfficiencySummary{User: job.User}
+			summaries[job.User] = summary
+		}
+		summary.JobCount++
+		if job.Wasteful {
+			summary.WastefulJobs++
+		}
+		summary.CoreHours += job.CoreWalltime / 3600
+		summary.UsedCPUHours += job.CPUUsed / 3600
+		if job.MemoryRequested > 0 {
+			memoryTotals[job.User] += job.MemoryEfficiency
+			memoryCounts[job.User]++
+		}
+	}
+
+	result := make([]UserEfficiencySummary, 0, len(summaries))
+	for user, summary := range summaries {
+		if summary.CoreHours > 0 {
+			summary.CPUEfficiency = roundTo(summary.UsedCPUHours/summary.CoreHours*100, 2)
+		}
+		if memoryCounts[user] > 0 {
+			summary.MemoryEfficiency = roundTo(memoryTotals[user]/float64(memoryCounts[user]), 2)
+		}
+		summary.WastedCoreHours = roundTo(summary.CoreHours-summary.UsedCPUHours, 2)
+		summary.CoreHours = roundTo(summary.CoreHours, 2)
+		summary.UsedCPUHours = roundTo(summary.UsedCPUHours, 2)
+		result = append(result, *summary)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].WastedCoreHours > result[j].WastedCoreHours
+	})
+	return result
+}
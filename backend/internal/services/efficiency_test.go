@@ -0,0 +1,64 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestComputeJobEfficiencies(t *testing.T) {
+	jobs := ComputeJobEfficiencies(ParseSacctOutput(readFixture(t, "sacct_efficiency.txt")))
+
+	// 运行中的 5003 与未启动就取消的 5004 不计算
+	tests := []struct {
+		jobID        string
+		nodes, cores int64
+		cpu, memory  float64
+		used, memReq int64
+		reasons      []string
+	}{
+		// 内存使用取各作业步 MaxRSS 的最大值
+		{"5001", 1, 8, 90, 50, 16 << 30, 32 << 30, nil},
+		// AllocTRES 的 mem 为整个作业的内存，按节点数换算
+		{"5002", 2, 64, 20.31, 4.69, 6 << 30, 128 << 30, []string{"CPU 效率 20.3%，申请 64 核", "内存效率 4.7%"}},
+		// 运行不足 10 分钟的作业不判定浪费；AllocTRES 没有 mem 时使用 ReqMem
+		{"5005", 1, 4, 0.83, 25, 1 << 30, 4 << 30, nil},
+		// 没有作业步记录时内存使用为 0
+		{"5006_3", 1, 1, 95, 0, 0, 2000 << 20, []string{"内存效率 0.0%"}},
+	}
+	if len(jobs) != len(tests) {
+		t.Fatalf("got %d jobs, want %d: %+v", len(jobs), len(tests), jobs)
+	}
+	for i, tt := range tests {
+		t.Run(tt.jobID, func(t *testing.T) {
+			job := jobs[i]
+			if job.JobID != tt.jobID || job.Nodes != tt.nodes || job.Cores != tt.cores {
+				t.Errorf("job = %s %d nodes %d cores, want %s %d %d", job.JobID, job.Nodes, job.Cores, tt.jobID, tt.nodes, tt.cores)
+			}
+			if job.CPUEfficiency != tt.cpu || job.MemoryEfficiency != tt.memory || job.MemoryUsed != tt.used || job.MemoryRequested != tt.memReq {
+				t.Errorf("efficiency = cpu %v%% mem %v%% (%d/%d), want %v%% %v%% (%d/%d)",
+					job.CPUEfficiency, job.MemoryEfficiency, job.MemoryUsed, job.MemoryRequested, tt.cpu, tt.memory, tt.used, tt.memReq)
+			}
+			if job.Wasteful != (tt.reasons != nil) || !reflect.DeepEqual(job.WasteReasons, tt.reasons) {
+				t.Errorf("wasteful = %v %q, want %q", job.Wasteful, job.WasteReasons, tt.reasons)
+			}
+		})
+	}
+	if job := jobs[0]; job.JobName != "bert-finetune" || job.User != "alice" || job.CoreWalltime != 28800 || job.CPUUsed != 25920 {
+		t.Errorf("5001 = %+v", job)
+	}
+}
+
+func TestSummarizeEfficiencyByUser(t *testing.T) {
+	summaries := SummarizeEfficiencyByUser(ComputeJobEfficiencies(ParseSacctOutput(readFixture(t, "sacct_efficiency.txt"))))
+
+	// 浪费核时最多的用户排在最前
+	want := []UserEfficiencySummary{
+		{User: "bob", JobCount: 1, WastefulJobs: 1, CoreHours: 128, UsedCPUHours: 26, WastedCoreHours: 102, CPUEfficiency: 20.31, MemoryEfficiency: 4.69},
+		{User: "alice", JobCount: 1, CoreHours: 8, UsedCPUHours: 7.2, WastedCoreHours: 0.8, CPUEfficiency: 90, MemoryEfficiency: 50},
+		{User: "erin", JobCount: 1, CoreHours: 0.33, WastedCoreHours: 0.33, CPUEfficiency: 0.83, MemoryEfficiency: 25},
+		{User: "frank", JobCount: 1, WastefulJobs: 1, CoreHours: 0.33, UsedCPUHours: 0.32, WastedCoreHours: 0.02, CPUEfficiency: 95},
+	}
+	if !reflect.DeepEqual(summaries, want) {
+		t.Errorf("SummarizeEfficiencyByUser() =\n%+v\nwant\n%+v", summaries, want)
+	}
+}
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"panel-tool/internal/models"
+)
+
+// gresCountPattern 匹配 GRES 数量，可带 K/M/G 等后缀（按 1024 进位）
+var gresCountPattern = regexp.MustCompile(`^\d+[KMGTP]?$`)
+
+// ParseGres 解析节点 Gres/GresUsed 或作业 TresPerNode 等字段
+// 支持 gpu:a100:4(S:0-1)、gpu:a100:2(IDX:0-1)、shard:8、gres/gpu:4、gres:gpu:4 等形式
+func ParseGres(value string) []models.GresResource {
+	resources := []models.GresResource{}
+	value = cleanSlurmValue(strings.TrimSpace(value))
+	if value == "" {
+		return resources
+	}
+
+	for _, item := range splitGresItems(value) {
+		// 去掉括号中的插槽或设备索引，如 (S:0-1)、(IDX:0,2)
+		if idx := strings.Index(item, "("); idx >= 0 {
+			item = item[:idx]
+		}
+		item = strings.TrimPrefix(strings.TrimPrefix(item, "gres/"), "gres:")
+		item = strings.ReplaceAll(item, "=", ":")
+
+		parts := strings.Split(item, ":")
+		if parts[0] == "" {
+			continue
+		}
+		resource := models.GresResource{Name: parts[0], Count: 1}
+		rest := parts[1:]
+		if n := len(rest); n > 0 && gresCountPattern.MatchString(rest[n-1]) {
+			resource.Count = parseSlurmSize(rest[n-1], 0)
+			rest = rest[:n-1]
+		}
+		// no_consume 等标志不是型号
+		var types []string
+		for _, part := range rest {
+			if part != "" && part != "no_consume" {
+				types = append(types, part)
+			}
+		}
+		resource.Type = strings.Join(types, ":")
+		resources = append(resources, resource)
+	}
+	return resources
+}
+
+// splitGresItems 按逗号拆分 GRES 列表，括号内的逗号（如 IDX:0,2）不拆分
+func splitGresItems(value string) []string {
+	var items []string
+	depth, start := 0, 0
+	for i, r := range value {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			if depth > 0 {
+				depth--
+			}
+		case ',':
+			if depth == 0 {
+				items = append(items, strings.TrimSpace(value[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	items = append(items, strings.TrimSpace(value[start:]))
+	return items
+}
+
+// GresFromTRES 从 TRES 字符串（如 cpu=8,gres/gpu=4,gres/gpu:a100=4）中提取 GRES
+// 同一资源同时列出总数和按型号的数量时，优先使用按型号的数量
+func GresFromTRES(tres string) []models.GresResource {
+	typed := make(map[string][]models.GresResource)
+	untyped := make(map[string]int64)
+	for key, value := range parseTRES(tres) {
+		name, ok := strings.CutPrefix(key, "gres/")
+		if !ok {
+			continue
+		}
+		count := parseSlurmSize(value, 0)
+		if base, gresType, hasType := strings.Cut(name, ":"); hasType {
+			typed[base] = append(typed[base], models.GresResource{Name: base, Type: gresType, Count: count})
+		} else {
+			untyped[name] = count
+		}
+	}
+
+	resources := []models.GresResource{}
+	for name, count := range untyped {
+		if _, ok := typed[name]; !ok {
+			resources = append(resources, models.GresResource{Name: name, Count: count})
+		}
+	}
+	for _, list := range typed {
+		resources = append(resources, list...)
+	}
+	sortGres(resources)
+	return resources
+}
+
+// MergeGresUsage 将已使用数量合并到总量中，型号不一致时按名称匹配第一个仍有余量的资源
+func MergeGresUsage(total, used []models.GresResource) []models.GresResource {
+	merged := make([]models.GresResource, len(total))
+	copy(merged, total)
+
+	for _, u := range used {
+		if u.Count == 0 {
+			continue
+		}
+		matched := -1
+		for i := range merged {
+			if merged[i].Name == u.Name && merged[i].Type == u.Type {
+				matched = i
+				break
+			}
+		}
+		if matched < 0 {
+			for i := range merged {
+				if merged[i].Name == u.Name && merged[i].Used < merged[i].Count {
+					matched = i
+					break
+				}
+			}
+		}
+		if matched >= 0 {
+			merged[matched].Used += u.Count
+		}
+	}
+	return merged
+}
+
+// gresTotals 汇总指定名称资源的总量和已使用量
+func gresTotals(resources []models.GresResource, name string) (int64, int64) {
+	var count, used int64
+	for _, resource := range resources {
+		if resource.Name == name {
+			count += resource.Count
+			used += resource.Used
+		}
+	}
+	return count, used
+}
+
+// sortGres 按名称和型号排序，保证输出稳定
+func sortGres(resources []models.GresResource) {
+	sort.Slice(resources, func(i, j int) bool {
+		if resources[i].Name != resources[j].Name {
+			return resources[i].Name < resources[j].Name
+		}
+		return resources[i].Type < resources[j].Type
+	})
+}
+
+// applyNodeGres 根据 Gres、GresUsed（scontrol show node -d）或 AllocTRES 填充节点的 GRES 使用情况
+func applyNodeGres(node *models.NodeModel, record map[string]string) {
+	total := ParseGres(record["Gres"])
+	if len(total) == 0 {
+		return
+	}
+
+	var used []models.GresResource
+	if value, ok := record["GresUsed"]; ok {
+		used = ParseGres(value)
+	} else {
+		used = GresFromTRES(record["AllocTRES"])
+	}
+	node.GresList = MergeGresUsage(total, used)
+	node.GPUTotal, node.GPUAlloc = gresTotals(node.GresList, "gpu")
+}
+
+// applyPartitionGPUs 按节点所属分区汇总 GPU 总量和已分配量
+func applyPartitionGPUs(partitions []models.PartitionModel, nodes []models.NodeModel) {
+	index := partitionIndex(partitions)
+	for _, node := range nodes {
+		if node.GPUTotal == 0 {
+			continue
+		}
+		for _, name := range node.Partitions {
+			if i, ok := index[name]; ok {
+				partitions[i].GPUTotal += node.GPUTotal
+				partitions[i].GPUAlloc += node.GPUAlloc
+			}
+		}
+	}
+}
+
+// GetLicenses 获取许可证使用情况
+func GetLicenses(cluster *Cluster) ([]models.LicenseModel, error) {
+	output, err := runSlurmCommand(cluster, "scontrol", "show", "licenses")
+	if err != nil {
+		return nil, err
+	}
+	return ParseLicenseOutput(output), nil
+}
+
+// ParseLicenseOutput 解析 scontrol show licenses 的输出
+// 各许可证之间不一定有空行，以 LicenseName= 作为记录起点
+func ParseLicenseOutput(output string) []models.LicenseModel {
+	licenses := []models.LicenseModel{}
+	output = strings.ReplaceAll(output, "LicenseName=", "\n\nLicenseName=")
+	for _, record := range ParseScontrolRecords(output) {
+		if record["LicenseName"] == "" {
+			continue
+		}
+		licenses = append(licenses, models.LicenseModel{
+			Name:     record["LicenseName"],
+			Total:    parseSlurmInt(record["Total"]),
+			Used:     parseSlurmInt(record["Used"]),
+			Free:     parseSlurmInt(record["Free"]),
+			Reserved: parseSlurmInt(record["Reserved"]),
+			Remote:   strings.EqualFold(record["Remote"], "yes"),
+		})
+	}
+	return licenses
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"panel-tool/internal/models"
+)
+
+// readFixture 读取 testdata 中采集的命令输出
+func readFixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("read fixture %s: %v", name, err)
+	}
+	return string(data)
+}
+
+// nodeFixture 按节点名索引 scontrol show node -d 的采集输出
+func nodeFixture(t *testing.T) map[string]map[string]string {
+	t.Helper()
+	nodes := make(map[string]map[string]string)
+	for _, record := range ParseScontrolRecords(readFixture(t, "scontrol_show_node_d.txt")) {
+		nodes[record["NodeName"]] = record
+	}
+	return nodes
+}
+
+// squeueFixture 按作业 ID 索引 squeue（squeueFormat）采集输出中的 %b 列
+func squeueFixture(t *testing.T) map[string]string {
+	t.Helper()
+	tres := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(readFixture(t, "squeue_tres.txt")), "\n") {
+		parts := strings.Split(line, "|")
+		tres[parts[0]] = parts[11]
+	}
+	return tres
+}
+
+func gres(name, gresType string, count, used int64) models.GresResource {
+	return models.GresResource{Name: name, Type: gresType, Count: count, Used: used}
+}
+
+func TestParseGres(t *testing.T) {
+	nodes := nodeFixture(t)
+	jobs := squeueFixture(t)
+	tests := []struct {
+		name  string
+		value string
+		want  []models.GresResource
+	}{
+		{"typed gpu and shard with socket", nodes["gpu001"]["Gres"], []models.GresResource{gres("gpu", "a100", 4, 0), gres("shard", "a100", 16, 0)}},
+		{"used with IDX and shard detail", nodes["gpu001"]["GresUsed"], []models.GresResource{gres("gpu", "a100", 2, 0), gres("shard", "a100", 3, 0)}},
+		{"gpu and mps", nodes["gpu002"]["Gres"], []models.GresResource{gres("gpu", "v100", 2, 0), gres("mps", "v100", 200, 0)}},
+		{"mps used with IDX:N/A", nodes["gpu002"]["GresUsed"], []models.GresResource{gres("gpu", "v100", 1, 0), gres("mps", "v100", 50, 0)}},
+		{"two gpu types", nodes["gpu003"]["Gres"], []models.GresResource{gres("gpu", "h100", 4, 0), gres("gpu", "a100", 2, 0)}},
+		{"no gres", nodes["cn001"]["Gres"], []models.GresResource{}},
+		{"squeue typed gpu", jobs["101"], []models.GresResource{gres("gpu", "a100", 2, 0)}},
+		{"squeue shard", jobs["102"], []models.GresResource{gres("shard", "", 3, 0)}},
+		{"squeue gpu and mps", jobs["103"], []models.GresResource{gres("gpu", "v100", 1, 0), gres("mps", "", 50, 0)}},
+		{"squeue untyped gpu", jobs["104"], []models.GresResource{gres("gpu", "", 4, 0)}},
+		{"squeue N/A", jobs["105"], []models.GresResource{}},
+		{"gres: prefix", "gres:gpu:4", []models.GresResource{gres("gpu", "", 4, 0)}},
+		{"IDX list with comma", "gpu:a100:2(IDX:0,2),shard:8", []models.GresResource{gres("gpu", "a100", 2, 0), gres("shard", "", 8, 0)}},
+		{"no_consume flag", "gpu:no_consume:2", []models.GresResource{gres("gpu", "", 2, 0)}},
+		{"name only", "gpu", []models.GresResource{gres("gpu", "", 1, 0)}},
+		{"empty", "", []models.GresResource{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseGres(tt.value); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseGres(%q) = %+v, want %+v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSqueueOutputGPUs(t *testing.T) {
+	want := map[string]int64{"101": 2, "102": 0, "103": 1, "104": 8, "105": 0}
+	for _, job := range ParseSqueueOutput(readFixture(t, "squeue_tres.txt")) {
+		if job.GPUs != want[job.JobID] {
+			t.Errorf("job %s GPUs = %d, want %d", job.JobID, job.GPUs, want[job.JobID])
+		}
+	}
+}
+
+func TestGresFromTRES(t *testing.T) {
+	nodes := nodeFixture(t)
+	tests := []struct {
+		name string
+		tres string
+		want []models.GresResource
+	}{
+		{"typed gpu preferred over total", nodes["gpu001"]["AllocTRES"], []models.GresResource{gres("gpu", "a100", 2, 0), gres("shard", "", 3, 0)}},
+		{"configured tres", nodes["gpu001"]["CfgTRES"], []models.GresResource{gres("gpu", "a100", 4, 0), gres("shard", "", 16, 0)}},
+		{"gpu and mps", nodes["gpu002"]["AllocTRES"], []models.GresResource{gres("gpu", "v100", 1, 0), gres("mps", "", 50, 0)}},
+		{"two gpu types", nodes["gpu003"]["AllocTRES"], []models.GresResource{gres("gpu", "a100", 1, 0), gres("gpu", "h100", 4, 0)}},
+		{"untyped only", "cpu=8,gres/gpu=2", []models.GresResource{gres("gpu", "", 2, 0)}},
+		{"no gres", "cpu=8,mem=64G,node=1,billing=8", []models.GresResource{}},
+		{"empty", "", []models.GresResource{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GresFromTRES(tt.tres); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GresFromTRES(%q) = %+v, want %+v", tt.tres, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeGresUsage(t *testing.T) {
+	nodes := nodeFixture(t)
+	tests := []struct {
+		name  string
+		total []models.GresResource
+		used  []models.GresResource
+		want  []models.GresResource
+	}{
+		{"GresUsed with shards", ParseGres(nodes["gpu001"]["Gres"]), ParseGres(nodes["gpu001"]["GresUsed"]),
+			[]models.GresResource{gres("gpu", "a100", 4, 2), gres("shard", "a100", 16, 3)}},
+		{"AllocTRES without shard type", ParseGres(nodes["gpu001"]["Gres"]), GresFromTRES(nodes["gpu001"]["AllocTRES"]),
+			[]models.GresResource{gres("gpu", "a100", 4, 2), gres("shard", "a100", 16, 3)}},
+		{"mps", ParseGres(nodes["gpu002"]["Gres"]), ParseGres(nodes["gpu002"]["GresUsed"]),
+			[]models.GresResource{gres("gpu", "v100", 2, 1), gres("mps", "v100", 200, 50)}},
+		{"two gpu types", ParseGres(nodes["gpu003"]["Gres"]), ParseGres(nodes["gpu003"]["GresUsed"]),
+			[]models.GresResource{gres("gpu", "h100", 4, 4), gres("gpu", "a100", 2, 1)}},
+		{"untyped usage fills first type with capacity",
+			[]models.GresResource{gres("gpu", "a100", 2, 0), gres("gpu", "h100", 2, 0)},
+			[]models.GresResource{gres("gpu", "a100", 2, 0), gres("gpu", "", 1, 0)},
+			[]models.GresResource{gres("gpu", "a100", 2, 2), gres("gpu", "h100", 2, 1)}},
+		{"unknown resource ignored", ParseGres("gpu:2"), ParseGres("fpga:1"), []models.GresResource{gres("gpu", "", 2, 0)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MergeGresUsage(tt.total, tt.used); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MergeGresUsage() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLicenseOutput(t *testing.T) {
+	want := []models.LicenseModel{
+		{Name: "matlab", Total: 50, Used: 12, Free: 36, Reserved: 2},
+		{Name: "ansys@flexlm", Total: 20, Used: 20, Free: 0, Remote: true},
+		{Name: "comsol", Total: 4, Free: 4},
+	}
+	if got := ParseLicenseOutput(readFixture(t, "scontrol_show_licenses.txt")); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseLicenseOutput() = %+v, want %+v", got, want)
+	}
+	if got := ParseLicenseOutput(""); len(got) != 0 {
+		t.Errorf("ParseLicenseOutput(\"\") = %+v, want empty", got)
+	}
+}
@@ -0,0 +1,433 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"panel-tool/internal/models"
+)
+
+// dmiPlaceholders 厂商未填写时 DMI 中常见的占位值，视为空
+var dmiPlaceholders = map[string]bool{
+	"to be filled by o.e.m.": true,
+	"default string":         true,
+	"not specified":          true,
+	"system product name":    true,
+	"system manufacturer":    true,
+	"none":                   true,
+	"0123456789":             true,
+}
+
+// CollectInventory 读取本机硬件清单：DMI、CPU、内存条、物理磁盘和物理网卡
+// sysRoot、procRoot 通常为 /sys 和 /proc
+func CollectInventory(sysRoot, procRoot string) *models.HardwareInventory {
+	inventory := &models.HardwareInventory{
+		CollectedAt: time.Now(),
+		BootTime:    readBootTime(filepath.Join(procRoot, "stat")),
+		DMI:         readDMI(filepath.Join(sysRoot, "devices", "virtual", "dmi", "id")),
+		CPUModel:    readCPUModel(filepath.Join(procRoot, "cpuinfo")),
+		Topology:    ReadCPUTopology(sysRoot, procRoot),
+		Memory:      readMemoryModules(sysRoot),
+		Disks:       readBlockDevices(filepath.Join(sysRoot, "block")),
+		NICs:        readNetworkInterfaces(filepath.Join(sysRoot, "class", "net")),
+	}
+	if memory, err := readMemoryStats(filepath.Join(procRoot, "meminfo")); err == nil {
+		inventory.MemoryTotal = memory.Total
+	}
+	return inventory
+}
+
+// readBootTime 读取 /proc/stat 中的 btime
+func readBootTime(path string) time.Time {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return time.Time{}
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if value, ok := strings.CutPrefix(line, "btime "); ok {
+			if seconds, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64); err == nil {
+				return time.Unix(seconds, 0)
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// readDMIValue 读取 DMI 字段，占位值视为空
+func readDMIValue(dir, name string) string {
+	value := readSysValue(filepath.Join(dir, name))
+	if dmiPlaceholders[strings.ToLower(value)] {
+		return ""
+	}
+	return value
+}
+
+// readDMI 读取主板与 BIOS 信息
+func readDMI(dir string) models.DMIInfo {
+	return models.DMIInfo{
+		Vendor:      readDMIValue(dir, "sys_vendor"),
+		Product:     readDMIValue(dir, "product_name"),
+		Serial:      readDMIValue(dir, "product_serial"),
+		BoardVendor: readDMIValue(dir, "board_vendor"),
+		BoardName:   readDMIValue(dir, "board_name"),
+		BIOSVendor:  readDMIValue(dir, "bios_vendor"),
+		BIOSVersion: readDMIValue(dir, "bios_version"),
+		BIOSDate:    readDMIValue(dir, "bios_date"),
+	}
+}
+
+// readMemoryModules 获取内存条列表：优先使用 dmidecode（需要 root，含序列号与型号），否则读取 EDAC
+func readMemoryModules(sysRoot string) []models.MemoryModule {
+	if _, err := exec.LookPath("dmidecode"); err == nil {
+		if output, err := exec.Command("dmidecode", "-t", "17").Output(); err == nil {
+			if modules := parseDmidecodeMemory(string(output)); len(modules) > 0 {
+				return modules
+			}
+		}
+	}
+	return readEDACModules(filepath.Join(sysRoot, "devices", "system", "edac", "mc"))
+}
+
+// parseDmidecodeMemory 解析 dmidecode -t 17 的输出，跳过空插槽
+func parseDmidecodeMemory(output string) []models.MemoryModule {
+	modules := []models.MemoryModule{}
+	var current map[string]string
+	flush := func() {
+		if current == nil {
+			return
+		}
+		size := parseDmidecodeSize(current["Size"])
+		if size > 0 {
+			locator := current["Locator"]
+			if locator == "" {
+				locator = current["Bank Locator"]
+			}
+			module := models.MemoryModule{
+				Locator:      locator,
+				Size:         size,
+				Type:         cleanDMIField(current["Type"]),
+				Speed:        cleanDMIField(current["Speed"]),
+				Manufacturer: cleanDMIField(current["Manufacturer"]),
+				Serial:       cleanDMIField(current["Serial Number"]),
+				PartNumber:   cleanDMIField(current["Part Number"]),
+			}
+			modules = append(modules, module)
+		}
+		current = nil
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "Memory Device" {
+			flush()
+			current = make(map[string]string)
+			continue
+		}
+		if current == nil || !strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "\t\t") {
+			continue
+		}
+		if key, value, ok := strings.Cut(trimmed, ":"); ok {
+			current[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	flush()
+	return modules
+}
+
+// cleanDMIField 将 dmidecode 的 Unknown、Not Specified 等占位值转换为空字符串
+func cleanDMIField(value string) string {
+	switch strings.ToLower(value) {
+	case "unknown", "not specified", "not provided", "no module installed":
+		return ""
+	}
+	if dmiPlaceholders[strings.ToLower(value)] {
+		return ""
+	}
+	return value
+}
+
+// parseDmidecodeSize 解析 dmidecode 的容量，如 "32 GB"、"16384 MB"，空插槽返回 0
+func parseDmidecodeSize(value string) int64 {
+	fields := strings.Fields(value)
+	if len(fields) != 2 {
+		return 0
+	}
+	number, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return 0
+	}
+	switch strings.ToUpper(fields[1]) {
+	case "KB":
+		return number << 10
+	case "MB":
+		return number << 20
+	case "GB":
+		return number << 30
+	case "TB":
+		return number << 40
+	}
+	return 0
+}
+
+// readEDACModules 读取 EDAC 驱动提供的内存条信息（mc*/dimm*），容量单位为 MB
+func readEDACModules(dir string) []models.MemoryModule {
+	modules := []models.MemoryModule{}
+	dimms, _ := filepath.Glob(filepath.Join(dir, "mc[0-9]*", "dimm[0-9]*"))
+	for _, dimm := range dimms {
+		sizeMB, err := strconv.ParseInt(readSysValue(filepath.Join(dimm, "size")), 10, 64)
+		if err != nil || sizeMB == 0 {
+			continue
+		}
+		locator := readSysValue(filepath.Join(dimm, "dimm_label"))
+		if locator == "" {
+			locator = filepath.Base(filepath.Dir(dimm)) + "/" + filepath.Base(dimm)
+		}
+		modules = append(modules, models.MemoryModule{
+			Locator: locator,
+			Size:    sizeMB << 20,
+			Type:    readSysValue(filepath.Join(dimm, "dimm_mem_type")),
+		})
+	}
+	sort.Slice(modules, func(i, j int) bool { return modules[i].Locator < modules[j].Locator })
+	return modules
+}
+
+// readBlockDevices 读取物理磁盘，跳过 loop、ram 以及 dm、md 等没有底层设备的虚拟块设备
+func readBlockDevices(dir string) []models.BlockDevice {
+	disks := []models.BlockDevice{}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return disks
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, "loop") || strings.HasPrefix(name, "ram") || strings.HasPrefix(name, "zram") {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(filepath.Join(path, "device")); err != nil {
+			continue
+		}
+		sectors, _ := strconv.ParseInt(readSysValue(filepath.Join(path, "size")), 10, 64)
+		disk := models.BlockDevice{
+			Name:       name,
+			Size:       sectors * diskSectorSize,
+			Model:      readSysValue(filepath.Join(path, "device", "model")),
+			Vendor:     readSysValue(filepath.Join(path, "device", "vendor")),
+			Serial:     readDiskSerial(path),
+			Rotational: readSysValue(filepath.Join(path, "queue", "rotational")) == "1",
+			Removable:  readSysValue(filepath.Join(path, "removable")) == "1",
+		}
+		disks = append(disks, disk)
+	}
+	return disks
+}
+
+// readDiskSerial 读取磁盘序列号：NVMe 与部分驱动提供 device/serial，SCSI/SATA 磁盘读取 VPD 0x80 页
+func readDiskSerial(path string) string {
+	if serial := readSysValue(filepath.Join(path, "device", "serial")); serial != "" {
+		return serial
+	}
+	data, err := os.ReadFile(filepath.Join(path, "device", "vpd_pg80"))
+	if err != nil || len(data) <= 4 {
+		return ""
+	}
+	// 前 4 字节为页头
+	return strings.TrimSpace(strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e {
+			return -1
+		}
+		return r
+	}, string(data[4:])))
+}
+
+// readNetworkInterfaces 读取物理网卡，跳过 lo、网桥、veth 等没有底层设备的虚拟接口
+func readNetworkInterfaces(dir string) []models.NetworkInterface {
+	nics := []models.NetworkInterface{}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nics
+	}
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if _, err := os.Stat(filepath.Join(path, "device")); err != nil {
+			continue
+		}
+		nic := models.NetworkInterface{
+			Name:      entry.Name(),
+			MAC:       readSysValue(filepath.Join(path, "address")),
+			OperState: readSysValue(filepath.Join(path, "operstate")),
+		}
+		// 链路未连接时 speed 读取失败或为 -1
+		if speed, err := strconv.Atoi(readSysValue(filepath.Join(path, "speed"))); err == nil && speed > 0 {
+			nic.Speed = speed
+		}
+		nic.MTU, _ = strconv.Atoi(readSysValue(filepath.Join(path, "mtu")))
+		if driver, err := os.Readlink(filepath.Join(path, "device", "driver")); err == nil {
+			nic.Driver = filepath.Base(driver)
+		}
+		nics = append(nics, nic)
+	}
+	return nics
+}
+
+// DiffInventory 比较两次硬件清单，返回新增、移除和变化的部件
+func DiffInventory(previous, current *models.HardwareInventory) []models.InventoryChange {
+	changes := []models.InventoryChange{}
+	reboot := !previous.BootTime.IsZero() && !current.BootTime.IsZero() && !previous.BootTime.Equal(current.BootTime)
+	add := func(component, key, kind, old, new string) {
+		changes = append(changes, models.InventoryChange{
+			Time:      current.CollectedAt,
+			Hostname:  current.Hostname,
+			Cluster:   current.Cluster,
+			Component: component,
+			Key:       key,
+			Kind:      kind,
+			Old:       old,
+			New:       new,
+			Reboot:    reboot,
+		})
+	}
+
+	dmiFields := []struct{ name, old, new string }{
+		{"vendor", previous.DMI.Vendor, current.DMI.Vendor},
+		{"product", previous.DMI.Product, current.DMI.Product},
+		{"serial", previous.DMI.Serial, current.DMI.Serial},
+		{"board", previous.DMI.BoardVendor + " " + previous.DMI.BoardName, current.DMI.BoardVendor + " " + current.DMI.BoardName},
+		{"bios_version", previous.DMI.BIOSVersion, current.DMI.BIOSVersion},
+	}
+	for _, field := range dmiFields {
+		if field.old != field.new {
+			add("dmi", field.name, "changed", strings.TrimSpace(field.old), strings.TrimSpace(field.new))
+		}
+	}
+
+	if previous.CPUModel != current.CPUModel {
+		add("cpu", "model", "changed", previous.CPUModel, current.CPUModel)
+	}
+	if oldTopology, newTopology := describeTopology(previous.Topology), describeTopology(current.Topology); oldTopology != newTopology {
+		add("cpu", "topology", "changed", oldTopology, newTopology)
+	}
+
+	diffComponents(add, "memory", memoryModuleMap(previous.Memory), memoryModuleMap(current.Memory))
+	// 读取不到内存条信息时（非 root 且无 EDAC），以总内存变化超过 1% 作为依据
+	if len(previous.Memory) == 0 && len(current.Memory) == 0 && previous.MemoryTotal > 0 {
+		if delta := current.MemoryTotal - previous.MemoryTotal; delta*100 > previous.MemoryTotal || -delta*100 > previous.MemoryTotal {
+			add("memory", "total", "changed", formatBytes(previous.MemoryTotal), formatBytes(current.MemoryTotal))
+		}
+	}
+	diffComponents(add, "disk", blockDeviceMap(previous.Disks), blockDeviceMap(current.Disks))
+	diffComponents(add, "nic", networkInterfaceMap(previous.NICs), networkInterfaceMap(current.NICs))
+	// 速率只在前后两次链路都连接时比较，拔线或链路抖动不报告为变化
+	oldSpeeds, newSpeeds := nicSpeedMap(previous.NICs), nicSpeedMap(current.NICs)
+	for _, nic := range current.NICs {
+		if old, ok := oldSpeeds[nic.Name]; ok && newSpeeds[nic.Name] != "" && old != newSpeeds[nic.Name] {
+			add("nic_speed", nic.Name, "changed", old, newSpeeds[nic.Name])
+		}
+	}
+	return changes
+}
+
+// diffComponents 按键比较两组部件的描述
+func diffComponents(add func(component, key, kind, old, new string), component string, previous, current map[string]string) {
+	keys := make([]string, 0, len(previous)+len(current))
+	for key := range previous {
+		keys = append(keys, key)
+	}
+	for key := range current {
+		if _, ok := previous[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		old, hadOld := previous[key]
+		new, hasNew := current[key]
+		switch {
+		case !hasNew:
+			add(component, key, "removed", old, "")
+		case !hadOld:
+			add(component, key, "added", "", new)
+		case old != new:
+			add(component, key, "changed", old, new)
+		}
+	}
+}
+
+// memoryModuleMap 以插槽为键描述内存条
+func memoryModuleMap(modules []models.MemoryModule) map[string]string {
+	result := make(map[string]string)
+	for _, module := range modules {
+		result[module.Locator] = strings.Join(nonEmpty(formatBytes(module.Size), module.Type, module.Manufacturer, module.PartNumber, module.Serial), " ")
+	}
+	return result
+}
+
+// blockDeviceMap 以序列号（没有时为设备名）为键描述磁盘，避免重启后设备名顺序变化被误报
+// 描述中不包含设备名，sda 与 sdb 互换不算变化
+func blockDeviceMap(disks []models.BlockDevice) map[string]string {
+	result := make(map[string]string)
+	for _, disk := range disks {
+		key := disk.Name
+		if disk.Serial != "" {
+			key = disk.Serial
+		}
+		result[key] = strings.Join(nonEmpty(formatBytes(disk.Size), disk.Vendor, disk.Model), " ")
+	}
+	return result
+}
+
+// networkInterfaceMap 以网卡名为键描述 MAC 与驱动，速率随链路协商变化，由 nicSpeedMap 单独比较
+func networkInterfaceMap(nics []models.NetworkInterface) map[string]string {
+	result := make(map[string]string)
+	for _, nic := range nics {
+		result[nic.Name] = strings.Join(nonEmpty(nic.MAC, nic.Driver), " ")
+	}
+	return result
+}
+
+// nicSpeedMap 以网卡名为键描述链路速率，链路未连接的网卡不包含在内
+func nicSpeedMap(nics []models.NetworkInterface) map[string]string {
+	result := make(map[string]string)
+	for _, nic := range nics {
+		if nic.Speed > 0 {
+			result[nic.Name] = fmt.Sprintf("%dMb/s", nic.Speed)
+		}
+	}
+	return result
+}
+
+// describeTopology 描述 CPU 拓扑，如 "2S 40C 80T"
+func describeTopology(topology models.CPUTopology) string {
+	return fmt.Sprintf("%dS %dC %dT", topology.Sockets, topology.Cores, topology.Threads)
+}
+
+// nonEmpty 过滤空字符串
+func nonEmpty(values ...string) []string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// formatBytes 以二进制单位格式化容量，如 "32 GiB"
+func formatBytes(size int64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB"}
+	value := float64(size)
+	unit := 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+	return strings.TrimSuffix(fmt.Sprintf("%.1f", value), ".0") + " " + units[unit]
+}
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"panel-tool/internal/models"
+	"panel-tool/internal/utils"
+)
+
+// 硬件清单默认参数，存储路径可通过 PANEL_INVENTORY_FILE 覆盖
+const (
+	defaultInventoryFile = "./data/inventory.json"
+	// inventorySnapshotLimit 每个节点保留的快照数量，只有硬件变化或重启后才追加快照
+	inventorySnapshotLimit = 20
+	// inventoryChangeLimit 保留的变更记录数量
+	inventoryChangeLimit = 2000
+	// inventoryLocalInterval 管理节点硬件清单的采集间隔
+	inventoryLocalInterval = time.Hour
+)
+
+// inventoryState 持久化到文件的快照与变更记录
+type inventoryState struct {
+	Snapshots map[string][]models.HardwareInventory `json:"snapshots"`
+	Changes   []models.InventoryChange              `json:"changes"`
+}
+
+// InventoryService 保存各节点的硬件清单快照，并在新快照与上一次不同时记录变更
+type InventoryService struct {
+	logger *utils.Logger
+	path   string
+
+	mutex     sync.Mutex
+	snapshots map[string][]models.HardwareInventory
+	changes   []models.InventoryChange
+
+	startOnce sync.Once
+}
+
+// NewInventoryService 创建新的硬件清单服务实例，加载已保存的快照
+func NewInventoryService() *InventoryService {
+	path := os.Getenv("PANEL_INVENTORY_FILE")
+	if path == "" {
+		path = defaultInventoryFile
+	}
+	s := &InventoryService{
+		logger:    utils.NewLogger(),
+		path:      path,
+		snapshots: make(map[string][]models.HardwareInventory),
+		changes:   []models.InventoryChange{},
+	}
+	if err := s.load(); err != nil && !os.IsNotExist(err) {
+		s.logger.Error(fmt.Sprintf("读取硬件清单失败: %v", err))
+	}
+	return s
+}
+
+// load 从文件读取快照与变更记录
+func (s *InventoryService) load() error {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+	var state inventoryState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("解析 %s 失败: %v", s.path, err)
+	}
+	if state.Snapshots != nil {
+		s.snapshots = state.Snapshots
+	}
+	if state.Changes != nil {
+		s.changes = state.Changes
+	}
+	return nil
+}
+
+// save 将快照与变更记录写入文件，调用方需持有 mutex
+func (s *InventoryService) save() error {
+	data, err := json.MarshalIndent(inventoryState{Snapshots: s.snapshots, Changes: s.changes}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("创建数据目录失败: %v", err)
+	}
+	if err := writeFileAtomic(s.path, data); err != nil {
+		return fmt.Errorf("保存硬件清单失败: %v", err)
+	}
+	return nil
+}
+
+// inventoryKey 快照索引键
+func inventoryKey(cluster, hostname string) string {
+	return cluster + "/" + hostname
+}
+
+// Record 保存节点的硬件清单并返回与上一次快照相比的变化
+// 硬件有变化或节点重启过时追加新快照，否则只更新最新快照的采集时间
+func (s *InventoryService) Record(inventory *models.HardwareInventory) ([]models.InventoryChange, error) {
+	if inventory.Hostname == "" {
+		return nil, fmt.Errorf("硬件清单缺少节点名")
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := inventoryKey(inventory.Cluster, inventory.Hostname)
+	history := s.snapshots[key]
+	var changes []models.InventoryChange
+	if len(history) == 0 {
+		history = append(history, *inventory)
+	} else {
+		latest := &history[len(history)-1]
+		changes = DiffInventory(latest, inventory)
+		if len(changes) > 0 || !latest.BootTime.Equal(inventory.BootTime) {
+			history = append(history, *inventory)
+		} else {
+			latest.CollectedAt = inventory.CollectedAt
+		}
+	}
+	if len(history) > inventorySnapshotLimit {
+		history = history[len(history)-inventorySnapshotLimit:]
+	}
+	s.snapshots[key] = history
+
+	for _, change := range changes {
+		s.logger.Info(fmt.Sprintf("节点 %s 硬件变化: %s %s %s（%s -> %s）", change.Hostname, change.Component, change.Key, change.Kind, change.Old, change.New))
+	}
+	s.changes = append(s.changes, changes...)
+	if len(s.changes) > inventoryChangeLimit {
+		s.changes = s.changes[len(s.changes)-inventoryChangeLimit:]
+	}
+	return changes, s.save()
+}
+
+// StartLocal 定时采集管理节点自身的硬件清单，重复调用无效
+func (s *InventoryService) StartLocal() {
+	s.startOnce.Do(func() {
+		go func() {
+			for {
+				inventory := CollectInventory("/sys", "/proc")
+				inventory.Hostname = getHostname()
+				if _, err := s.Record(inventory); err != nil {
+					s.logger.Error(err.Error())
+				}
+				time.Sleep(inventoryLocalInterval)
+			}
+		}()
+	})
+}
+
+// Latest 返回各节点最新的硬件清单，cluster 不为空时只返回该集群（及未标注集群）的节点
+func (s *InventoryService) Latest(cluster string) []models.HardwareInventory {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	result := []models.HardwareInventory{}
+	for _, history := range s.snapshots {
+		latest := history[len(history)-1]
+		if cluster != "" && latest.Cluster != "" && latest.Cluster != cluster {
+			continue
+		}
+		result = append(result, latest)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Cluster != result[j].Cluster {
+			return result[i].Cluster < result[j].Cluster
+		}
+		return result[i].Hostname < result[j].Hostname
+	})
+	return result
+}
+
+// History 返回节点的历史快照，由新到旧
+func (s *InventoryService) History(hostname string) []models.HardwareInventory {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	result := []models.HardwareInventory{}
+	for _, history := range s.snapshots {
+		if len(history) == 0 || history[0].Hostname != hostname {
+			continue
+		}
+		for i := len(history) - 1; i >= 0; i-- {
+			result = append(result, history[i])
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].CollectedAt.After(result[j].CollectedAt) })
+	return result
+}
+
+// Changes 返回最近的硬件变更记录，由新到旧；hostname 为空时返回所有节点
+func (s *InventoryService) Changes(hostname string, limit int) []models.InventoryChange {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	result := []models.InventoryChange{}
+	for i := len(s.changes) - 1; i >= 0 && len(result) < limit; i-- {
+		if hostname == "" || s.changes[i].Hostname == hostname {
+			result = append(result, s.changes[i])
+		}
+	}
+	return result
+}
+
+// WriteInventoryCSV 将硬件清单导出为 CSV，每个节点一行，内存条、磁盘和网卡合并为一列
+func WriteInventoryCSV(w io.Writer, inventories []models.HardwareInventory) error {
+	writer := csv.NewWriter(w)
+	header := []string{
+		"cluster", "hostname", "vendor", "product", "serial", "bios_version", "bios_date",
+		"cpu_model", "sockets", "cores", "threads", "memory_total", "memory_modules", "disks", "nics", "collected_at",
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, inventory := range inventories {
+		var modules, disks, nics []string
+		for _, module := range inventory.Memory {
+			modules = append(modules, fmt.Sprintf("%s:%s", module.Locator, strings.Join(nonEmpty(formatBytes(module.Size), module.Type, module.Serial), " ")))
+		}
+		for _, disk := range inventory.Disks {
+			kind := "SSD"
+			if disk.Rotational {
+				kind = "HDD"
+			}
+			disks = append(disks, fmt.Sprintf("%s:%s", disk.Name, strings.Join(nonEmpty(formatBytes(disk.Size), kind, disk.Model, disk.Serial), " ")))
+		}
+		for _, nic := range inventory.NICs {
+			nics = append(nics, fmt.Sprintf("%s:%s %dMb/s", nic.Name, nic.MAC, nic.Speed))
+		}
+		line := []string{
+			inventory.Cluster, inventory.Hostname,
+			inventory.DMI.Vendor, inventory.DMI.Product, inventory.DMI.Serial,
+			inventory.DMI.BIOSVersion, inventory.DMI.BIOSDate,
+			inventory.CPUModel,
+			strconv.Itoa(inventory.Topology.Sockets), strconv.Itoa(inventory.Topology.Cores), strconv.Itoa(inventory.Topology.Threads),
+			formatBytes(inventory.MemoryTotal),
+			strings.Join(modules, "; "), strings.Join(disks, "; "), strings.Join(nics, "; "),
+			inventory.CollectedAt.Format(time.RFC3339),
+		}
+		if err := writer.Write(line); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"panel-tool/internal/models"
+)
+
+func TestCollectInventory(t *testing.T) {
+	// 找不到 dmidecode 时读取 EDAC 中的内存条
+	t.Setenv("PATH", "")
+	root := filepath.Join("testdata", "host")
+	inventory := CollectInventory(filepath.Join(root, "sys"), filepath.Join(root, "proc"))
+
+	if inventory.BootTime.Unix() != 1709539200 || inventory.MemoryTotal != 16384000<<10 {
+		t.Errorf("boot time/memory = %v, %d", inventory.BootTime, inventory.MemoryTotal)
+	}
+	// board_name 为占位值 "Default string"
+	wantDMI := models.DMIInfo{Vendor: "Dell Inc.", Product: "PowerEdge R750", Serial: "7XK2PL3", BoardVendor: "Dell Inc.", BIOSVendor: "Dell Inc.", BIOSVersion: "1.8.2", BIOSDate: "09/14/2022"}
+	if inventory.DMI != wantDMI {
+		t.Errorf("DMI = %+v, want %+v", inventory.DMI, wantDMI)
+	}
+	if inventory.CPUModel != "Intel(R) Xeon(R) Gold 6338 CPU @ 2.00GHz" || describeTopology(inventory.Topology) != "2S 4C 8T" {
+		t.Errorf("cpu = %q %+v", inventory.CPUModel, inventory.Topology)
+	}
+
+	wantMemory := []models.MemoryModule{
+		{Locator: "CPU0_DIMM_A1", Size: 32 << 30, Type: "Registered-DDR4"},
+		{Locator: "mc1/dimm0", Size: 32 << 30, Type: "Registered-DDR4"},
+	}
+	if !reflect.DeepEqual(inventory.Memory, wantMemory) {
+		t.Errorf("memory = %+v, want %+v", inventory.Memory, wantMemory)
+	}
+
+	// dm-0 没有底层设备，SATA 磁盘的序列号来自 VPD 0x80 页
+	wantDisks := []models.BlockDevice{
+		{Name: "nvme0n1", Size: 1000215216 * 512, Model: "SAMSUNG MZQL2960HCJR-00A07", Serial: "S64FNE0R801234"},
+		{Name: "sda", Size: 1953525168 * 512, Model: "ST2000NM0055-1V4", Vendor: "ATA", Serial: "ZBS1K2X7", Rotational: true},
+	}
+	if !reflect.DeepEqual(inventory.Disks, wantDisks) {
+		t.Errorf("disks = %+v, want %+v", inventory.Disks, wantDisks)
+	}
+
+	// 链路未连接的 eth1 速率为 0，lo 没有底层设备
+	wantNICs := []models.NetworkInterface{
+		{Name: "eth0", MAC: "b8:59:9f:12:34:56", Speed: 25000, MTU: 9000, OperState: "up", Driver: "mlx5_core"},
+		{Name: "eth1", MAC: "3c:ec:ef:00:11:22", MTU: 1500, OperState: "down", Driver: "ixgbe"},
+	}
+	if !reflect.DeepEqual(inventory.NICs, wantNICs) {
+		t.Errorf("nics = %+v, want %+v", inventory.NICs, wantNICs)
+	}
+
+	if empty := CollectInventory(filepath.Join("testdata", "missing"), filepath.Join("testdata", "missing")); len(empty.Disks) != 0 || len(empty.NICs) != 0 || !empty.BootTime.IsZero() {
+		t.Errorf("CollectInventory() without /sys = %+v", empty)
+	}
+}
+
+func TestParseDmidecodeMemory(t *testing.T) {
+	// 空插槽不计入，Locator 为空时使用 Bank Locator
+	want := []models.MemoryModule{
+		{Locator: "A1", Size: 32 << 30, Type: "DDR4", Speed: "3200 MT/s", Manufacturer: "00AD063200AD", Serial: "82A1B2C3", PartNumber: "HMA84GR7CJR4N-XN"},
+		{Locator: "BANK 1", Size: 16 << 30, Type: "DDR4", Serial: "00000000"},
+		{Locator: "ROM", Size: 512 << 10, Type: "Flash"},
+	}
+	if got := parseDmidecodeMemory(readFixture(t, "dmidecode_t17.txt")); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseDmidecodeMemory() = %+v, want %+v", got, want)
+	}
+	if got := parseDmidecodeMemory("# dmidecode 3.3\n# No SMBIOS nor DMI entry point found, sorry.\n"); len(got) != 0 {
+		t.Errorf("parseDmidecodeMemory() without devices = %+v", got)
+	}
+}
+
+// baseInventory 两块磁盘、两块网卡和两根内存条的节点清单
+func baseInventory() *models.HardwareInventory {
+	return &models.HardwareInventory{
+		Hostname:    "cn001",
+		Cluster:     "alpha",
+		CollectedAt: time.Unix(1709600000, 0),
+		BootTime:    time.Unix(1709539200, 0),
+		DMI:         models.DMIInfo{Vendor: "Dell Inc.", Product: "PowerEdge R750", Serial: "7XK2PL3", BIOSVersion: "1.8.2"},
+		CPUModel:    "Intel(R) Xeon(R) Gold 6338 CPU @ 2.00GHz",
+		Topology:    models.CPUTopology{Sockets: 2, Cores: 64, Threads: 128},
+		MemoryTotal: 256 << 30,
+		Memory: []models.MemoryModule{
+			{Locator: "A1", Size: 32 << 30, Type: "DDR4", Serial: "82A1B2C3"},
+			{Locator: "A2", Size: 32 << 30, Type: "DDR4", Serial: "82A1B2C4"},
+		},
+		Disks: []models.BlockDevice{
+			{Name: "sda", Size: 2 << 40, Model: "ST2000NM0055", Serial: "ZBS1K2X7"},
+			{Name: "sdb", Size: 2 << 40, Model: "ST2000NM0055", Serial: "ZBS1K2X8"},
+		},
+		NICs: []models.NetworkInterface{
+			{Name: "eth0", MAC: "b8:59:9f:12:34:56", Speed: 25000, Driver: "mlx5_core"},
+			{Name: "eth1", MAC: "3c:ec:ef:00:11:22", Speed: 10000, Driver: "ixgbe"},
+		},
+	}
+}
+
+func TestDiffInventory(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(inventory *models.HardwareInventory)
+		want   []models.InventoryChange
+	}{
+		{"unchanged", func(*models.HardwareInventory) {}, nil},
+		// 重启后磁盘名互换，序列号不变
+		{"disk renamed", func(inventory *models.HardwareInventory) {
+			inventory.Disks[0].Name, inventory.Disks[1].Name = "sdb", "sda"
+		}, nil},
+		{"disk replaced", func(inventory *models.HardwareInventory) {
+			inventory.Disks[1].Serial = "ZBS1K2X9"
+		}, []models.InventoryChange{
+			{Component: "disk", Key: "ZBS1K2X8", Kind: "removed", Old: "2 TiB ST2000NM0055"},
+			{Component: "disk", Key: "ZBS1K2X9", Kind: "added", New: "2 TiB ST2000NM0055"},
+		}},
+		{"memory removed after reboot", func(inventory *models.HardwareInventory) {
+			inventory.BootTime = inventory.BootTime.Add(time.Hour)
+			inventory.Memory = inventory.Memory[:1]
+		}, []models.InventoryChange{
+			{Component: "memory", Key: "A2", Kind: "removed", Old: "32 GiB DDR4 82A1B2C4", Reboot: true},
+		}},
+		{"bios and topology", func(inventory *models.HardwareInventory) {
+			inventory.DMI.BIOSVersion = "1.10.2"
+			inventory.Topology.Threads = 64
+		}, []models.InventoryChange{
+			{Component: "dmi", Key: "bios_version", Kind: "changed", Old: "1.8.2", New: "1.10.2"},
+			{Component: "cpu", Key: "topology", Kind: "changed", Old: "2S 64C 128T", New: "2S 64C 64T"},
+		}},
+		{"nic replaced", func(inventory *models.HardwareInventory) {
+			inventory.NICs[1].MAC = "3c:ec:ef:00:11:33"
+		}, []models.InventoryChange{
+			{Component: "nic", Key: "eth1", Kind: "changed", Old: "3c:ec:ef:00:11:22 ixgbe", New: "3c:ec:ef:00:11:33 ixgbe"},
+		}},
+		// 链路断开时速率为 0，不报告为变化
+		{"link down", func(inventory *models.HardwareInventory) {
+			inventory.NICs[0].Speed = 0
+		}, nil},
+		{"link renegotiated", func(inventory *models.HardwareInventory) {
+			inventory.NICs[0].Speed = 10000
+		}, []models.InventoryChange{
+			{Component: "nic_speed", Key: "eth0", Kind: "changed", Old: "25000Mb/s", New: "10000Mb/s"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous, current := baseInventory(), baseInventory()
+			current.CollectedAt = current.CollectedAt.Add(time.Hour)
+			tt.modify(current)
+
+			var got []models.InventoryChange
+			for _, change := range DiffInventory(previous, current) {
+				if change.Time != current.CollectedAt || change.Hostname != "cn001" || change.Cluster != "alpha" {
+					t.Errorf("change = %+v, want time, hostname and cluster of the current inventory", change)
+				}
+				change.Time, change.Hostname, change.Cluster = time.Time{}, "", ""
+				got = append(got, change)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffInventory() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffInventoryMemoryTotal(t *testing.T) {
+	previous, current := baseInventory(), baseInventory()
+	previous.Memory, current.Memory = nil, nil
+
+	// 没有内存条信息时，总内存变化不超过 1% 视为正常波动
+	current.MemoryTotal = previous.MemoryTotal - previous.MemoryTotal/200
+	if changes := DiffInventory(previous, current); len(changes) != 0 {
+		t.Errorf("DiffInventory() = %+v, want no changes", changes)
+	}
+	current.MemoryTotal = 192 << 30
+	changes := DiffInventory(previous, current)
+	if len(changes) != 1 || changes[0].Key != "total" || changes[0].Old != "256 GiB" || changes[0].New != "192 GiB" {
+		t.Errorf("DiffInventory() = %+v, want memory total change", changes)
+	}
+}
@@ -0,0 +1,372 @@
+package services
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"panel-tool/internal/models"
+)
+
+// dependencyQueueFormat 依赖图使用的 squeue 输出格式：作业 ID、数组作业 ID、数组任务、名称、用户、状态、分区、原因、依赖
+const dependencyQueueFormat = "%i|%F|%K|%j|%u|%T|%P|%r|%E"
+
+// dependencyItemPattern 匹配单条依赖，如 afterok:123_*(unfulfilled)、singleton(unfulfilled)
+var dependencyItemPattern = regexp.MustCompile(`^([A-Za-z_]+)(?::([^(]*))?(?:\(([^)]*)\))?$`)
+
+// dependencyQueueRow squeue 中的一行
+type dependencyQueueRow struct {
+	JobID      string
+	ArrayJobID string
+	ArrayTasks string // 数组任务表达式，非数组作业为空
+	Name       string
+	User       string
+	State      string
+	Partition  string
+	Reason     string
+	Dependency string
+}
+
+// GetJobDependencyGraph 构建队列中作业的依赖图
+// user 不为空时只查询该用户的作业；jobID 不为空时只返回与该作业相连的部分
+func GetJobDependencyGraph(cluster *Cluster, user, jobID string) (*models.JobDependencyGraph, error) {
+	args := []string{"--all", "--noheader", "--format=" + dependencyQueueFormat}
+	if user != "" {
+		args = append(args, "--user="+user)
+	}
+	output, err := runSlurmCommand(cluster, "squeue", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := parseDependencyQueue(output)
+	finished := lookupFinishedJobs(cluster, missingDependencyIDs(rows))
+	graph := BuildJobDependencyGraph(rows, finished)
+	if jobID != "" {
+		graph = filterGraphComponent(graph, dependencyBaseID(jobID))
+	}
+	return graph, nil
+}
+
+// parseDependencyQueue 解析 squeue --format=dependencyQueueFormat 的输出
+func parseDependencyQueue(output string) []dependencyQueueRow {
+	var rows []dependencyQueueRow
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		parts := strings.SplitN(line, "|", 9)
+		if len(parts) != 9 {
+			continue
+		}
+		row := dependencyQueueRow{
+			JobID:      parts[0],
+			ArrayJobID: parts[1],
+			Name:       parts[3],
+			User:       parts[4],
+			State:      normalizeJobState(parts[5]),
+			Partition:  parts[6],
+			Reason:     parts[7],
+			Dependency: parts[8],
+		}
+		if parts[2] != "" && parts[2] != "N/A" {
+			row.ArrayTasks = parts[2]
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// missingDependencyIDs 返回被依赖但已不在队列中的作业
+func missingDependencyIDs(rows []dependencyQueueRow) []string {
+	inQueue := make(map[string]bool)
+	for _, row := range rows {
+		inQueue[rowGraphID(row)] = true
+	}
+
+	seen := make(map[string]bool)
+	var missing []string
+	for _, row := range rows {
+		for _, dep := range ParseJobDependencies(row.Dependency) {
+			id := dependencyBaseID(dep.JobID)
+			if id == "" || inQueue[id] || seen[id] {
+				continue
+			}
+			seen[id] = true
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
+
+// lookupFinishedJobs 通过 sacct 查询已离开队列的作业，查询失败时返回空结果
+// 数组作业以任一失败任务的状态为准
+func lookupFinishedJobs(cluster *Cluster, ids []string) map[string]models.JobAccountingRecord {
+	finished := make(map[string]models.JobAccountingRecord)
+	if len(ids) == 0 {
+		return finished
+	}
+
+	args := append([]string{"--allusers", "--allocations", "--jobs=" + strings.Join(ids, ",")}, sacctOutputArgs()...)
+	output, err := runSlurmCommand(cluster, "sacct", args...)
+	if err != nil {
+		return finished
+	}
+	for _, record := range ParseSacctOutput(output) {
+		id := dependencyBaseID(record.JobID)
+		if existing, ok := finished[id]; ok && existing.State != "COMPLETED" {
+			continue
+		}
+		finished[id] = record
+	}
+	return finished
+}
+
+// BuildJobDependencyGraph 由队列数据构建依赖图，数组任务合并到父作业下并统计各状态任务数
+func BuildJobDependencyGraph(rows []dependencyQueueRow, finished map[string]models.JobAccountingRecord) *models.JobDependencyGraph {
+	nodes := make(map[string]*models.JobGraphNode)
+	var order []string
+
+	for _, row := range rows {
+		id := rowGraphID(row)
+		node, ok := nodes[id]
+		if !ok {
+			node = &models.JobGraphNode{
+				JobID:        id,
+				Name:         row.Name,
+				User:         row.User,
+				Partition:    row.Partition,
+				State:        row.State,
+				InQueue:      true,
+				Dependencies: []models.JobDependency{},
+			}
+			if row.ArrayTasks != "" {
+				node.IsArray = true
+				node.ArrayStates = make(map[string]int)
+			}
+			nodes[id] = node
+			order = append(order, id)
+		}
+
+		if node.IsArray {
+			count := countArrayTasks(row.ArrayTasks)
+			node.ArrayTasks += count
+			node.ArrayStates[row.State] += count
+		}
+		if row.State == "pending" && row.Reason != "" && row.Reason != "None" &&
+			(node.Reason == "" || strings.HasPrefix(row.Reason, "DependencyNeverSatisfied")) {
+			node.Reason = row.Reason
+		}
+		for _, dep := range ParseJobDependencies(row.Dependency) {
+			if !containsDependency(node.Dependencies, dep) {
+				node.Dependencies = append(node.Dependencies, dep)
+			}
+		}
+	}
+
+	// 数组作业的整体状态：有运行中的任务即为 running，否则有排队任务即为 pending
+	for _, node := range nodes {
+		if !node.IsArray {
+			continue
+		}
+		switch {
+		case node.ArrayStates["running"] > 0:
+			node.State = "running"
+		case node.ArrayStates["pending"] > 0:
+			node.State = "pending"
+		}
+	}
+
+	graph := &models.JobDependencyGraph{
+		Nodes:         []models.JobGraphNode{},
+		Edges:         []models.JobGraphEdge{},
+		BlockedChains: []models.BlockedChain{},
+	}
+	dependents := make(map[string][]string)
+	for _, id := range order {
+		for _, dep := range nodes[id].Dependencies {
+			from := dependencyBaseID(dep.JobID)
+			if from == "" {
+				continue
+			}
+			if _, ok := nodes[from]; !ok {
+				// 被依赖的作业已离开队列，使用 sacct 中的状态
+				placeholder := &models.JobGraphNode{JobID: from, State: "unknown", Dependencies: []models.JobDependency{}}
+				if record, ok := finished[from]; ok {
+					placeholder.Name = record.JobName
+					placeholder.User = record.User
+					placeholder.Partition = record.Partition
+					if fields := strings.Fields(record.State); len(fields) > 0 {
+						placeholder.State = normalizeJobState(fields[0])
+					}
+				}
+				nodes[from] = placeholder
+				order = append(order, from)
+			}
+			graph.Edges = append(graph.Edges, models.JobGraphEdge{From: from, To: id, Type: dep.Type, Status: dep.Status})
+			dependents[from] = append(dependents[from], id)
+		}
+	}
+
+	// 从 DependencyNeverSatisfied 的作业出发，沿依赖关系找出所有受影响的下游作业
+	for _, id := range order {
+		node := nodes[id]
+		if !strings.HasPrefix(node.Reason, "DependencyNeverSatisfied") {
+			continue
+		}
+		chain := models.BlockedChain{JobID: id, Causes: []models.JobDependency{}, Affected: []string{}}
+		for _, dep := range node.Dependencies {
+			if dep.Status != "" && dep.Status != "unfulfilled" {
+				chain.Causes = append(chain.Causes, dep)
+			}
+		}
+		if len(chain.Causes) == 0 {
+			chain.Causes = append(chain.Causes, node.Dependencies...)
+		}
+
+		node.Blocked = true
+		visited := map[string]bool{id: true}
+		queue := append([]string{}, dependents[id]...)
+		for len(queue) > 0 {
+			next := queue[0]
+			queue = queue[1:]
+			if visited[next] {
+				continue
+			}
+			visited[next] = true
+			nodes[next].Blocked = true
+			chain.Affected = append(chain.Affected, next)
+			queue = append(queue, dependents[next]...)
+		}
+		sort.Slice(chain.Affected, func(i, j int) bool { return compareJobIDs(chain.Affected[i], chain.Affected[j]) < 0 })
+		graph.BlockedChains = append(graph.BlockedChains, chain)
+	}
+
+	sort.Slice(order, func(i, j int) bool { return compareJobIDs(order[i], order[j]) < 0 })
+	for _, id := range order {
+		graph.Nodes = append(graph.Nodes, *nodes[id])
+	}
+	return graph
+}
+
+// ParseJobDependencies 解析 squeue %E / scontrol Dependency 字段
+// 多条依赖以逗号（全部满足）或问号（任一满足）分隔，一条依赖可包含多个作业，如 afterok:1:2
+func ParseJobDependencies(expr string) []models.JobDependency {
+	deps := []models.JobDependency{}
+	if expr == "" || expr == "(null)" {
+		return deps
+	}
+
+	for _, item := range strings.FieldsFunc(expr, func(r rune) bool { return r == ',' || r == '?' }) {
+		match := dependencyItemPattern.FindStringSubmatch(strings.TrimSpace(item))
+		if match == nil {
+			continue
+		}
+		depType, ids, status := strings.ToLower(match[1]), match[2], match[3]
+		if ids == "" {
+			deps = append(deps, models.JobDependency{Type: depType, Status: status})
+			continue
+		}
+		for _, id := range strings.Split(ids, ":") {
+			// after:123+10 中的 +10 为延迟分钟数
+			id, _, _ = strings.Cut(id, "+")
+			if id != "" {
+				deps = append(deps, models.JobDependency{Type: depType, JobID: id, Status: status})
+			}
+		}
+	}
+	return deps
+}
+
+// countArrayTasks 统计数组任务表达式中的任务数，如 "1-10%2" 为 10，"1,3,5-7" 为 5
+func countArrayTasks(expr string) int {
+	expr, _, _ = strings.Cut(strings.Trim(expr, "[]"), "%")
+	count := 0
+	for _, part := range strings.Split(expr, ",") {
+		if part == "" {
+			continue
+		}
+		rangePart, stepPart, hasStep := strings.Cut(part, ":")
+		lo, hi, isRange := strings.Cut(rangePart, "-")
+		if !isRange {
+			count++
+			continue
+		}
+		start, end := parseSlurmInt(lo), parseSlurmInt(hi)
+		step := int64(1)
+		if hasStep {
+			if s := parseSlurmInt(stepPart); s > 0 {
+				step = s
+			}
+		}
+		if end >= start {
+			count += int((end-start)/step) + 1
+		}
+	}
+	return count
+}
+
+// rowGraphID 依赖图中的节点 ID，数组任务归入父作业
+func rowGraphID(row dependencyQueueRow) string {
+	if row.ArrayTasks != "" && row.ArrayJobID != "" {
+		return row.ArrayJobID
+	}
+	return row.JobID
+}
+
+// dependencyBaseID 去掉数组任务后缀，如 123_* 或 123_4 返回 123
+func dependencyBaseID(id string) string {
+	base, _, _ := strings.Cut(id, "_")
+	return base
+}
+
+// containsDependency 判断依赖是否已存在
+func containsDependency(deps []models.JobDependency, dep models.JobDependency) bool {
+	for _, existing := range deps {
+		if existing.Type == dep.Type && existing.JobID == dep.JobID {
+			return true
+		}
+	}
+	return false
+}
+
+// filterGraphComponent 只保留与指定作业相连（忽略方向）的节点、边和阻塞链
+func filterGraphComponent(graph *models.JobDependencyGraph, jobID string) *models.JobDependencyGraph {
+	neighbors := make(map[string][]string)
+	for _, edge := range graph.Edges {
+		neighbors[edge.From] = append(neighbors[edge.From], edge.To)
+		neighbors[edge.To] = append(neighbors[edge.To], edge.From)
+	}
+
+	component := map[string]bool{jobID: true}
+	queue := []string{jobID}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		for _, neighbor := range neighbors[next] {
+			if !component[neighbor] {
+				component[neighbor] = true
+				queue = append(queue, neighbor)
+			}
+		}
+	}
+
+	filtered := &models.JobDependencyGraph{
+		Nodes:         []models.JobGraphNode{},
+		Edges:         []models.JobGraphEdge{},
+		BlockedChains: []models.BlockedChain{},
+	}
+	for _, node := range graph.Nodes {
+		if component[node.JobID] {
+			filtered.Nodes = append(filtered.Nodes, node)
+		}
+	}
+	for _, edge := range graph.Edges {
+		if component[edge.From] {
+			filtered.Edges = append(filtered.Edges, edge)
+		}
+	}
+	for _, chain := range graph.BlockedChains {
+		if component[chain.JobID] {
+			filtered.BlockedChains = append(filtered.BlockedChains, chain)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"panel-tool/internal/models"
+)
+
+// fixtureDependencyGraph 由 squeue 输出构建依赖图，被依赖的 290 已离开队列，999 在 sacct 中也查不到
+func fixtureDependencyGraph(t *testing.T) *models.JobDependencyGraph {
+	t.Helper()
+	rows := parseDependencyQueue(readFixture(t, "squeue_dependency.txt"))
+	finished := map[string]models.JobAccountingRecord{
+		"290": {JobID: "290", JobName: "qc", User: "alice", Partition: "cpu", State: "CANCELLED by 1001"},
+	}
+	return BuildJobDependencyGraph(rows, finished)
+}
+
+func TestParseDependencyQueue(t *testing.T) {
+	rows := parseDependencyQueue(readFixture(t, "squeue_dependency.txt"))
+	// 字段不足的 350 被跳过
+	if len(rows) != 9 {
+		t.Fatalf("rows = %d, want 9", len(rows))
+	}
+	want := dependencyQueueRow{
+		JobID: "301_[3-10%2]", ArrayJobID: "301", ArrayTasks: "3-10%2", Name: "align", User: "alice",
+		State: "pending", Partition: "cpu", Reason: "JobArrayTaskLimit", Dependency: "(null)",
+	}
+	if rows[2] != want {
+		t.Errorf("rows[2] = %+v, want %+v", rows[2], want)
+	}
+	// 非数组作业的 %K 为 N/A
+	if rows[3].ArrayTasks != "" || rowGraphID(rows[3]) != "310" {
+		t.Errorf("rows[3] = %+v", rows[3])
+	}
+	if got := missingDependencyIDs(rows); !reflect.DeepEqual(got, []string{"290", "999"}) {
+		t.Errorf("missingDependencyIDs() = %v, want [290 999]", got)
+	}
+}
+
+func TestBuildJobDependencyGraph(t *testing.T) {
+	graph := fixtureDependencyGraph(t)
+
+	nodes := make(map[string]models.JobGraphNode)
+	var ids []string
+	for _, node := range graph.Nodes {
+		nodes[node.JobID] = node
+		ids = append(ids, node.JobID)
+	}
+	if want := []string{"290", "301", "310", "311", "312", "320", "330", "340", "999"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("nodes = %v, want %v", ids, want)
+	}
+
+	// 数组任务合并到父作业，有运行中的任务时整体为 running
+	if array := nodes["301"]; !array.IsArray || array.ArrayTasks != 10 || array.State != "running" ||
+		!reflect.DeepEqual(array.ArrayStates, map[string]int{"running": 2, "pending": 8}) || array.Reason != "JobArrayTaskLimit" {
+		t.Errorf("node 301 = %+v", array)
+	}
+	if array := nodes["340"]; array.ArrayTasks != 6 || array.State != "pending" || array.Blocked {
+		t.Errorf("node 340 = %+v", array)
+	}
+
+	// 已离开队列的作业使用 sacct 中的信息
+	want := models.JobGraphNode{JobID: "290", Name: "qc", User: "alice", Partition: "cpu", State: "cancelled", Dependencies: []models.JobDependency{}}
+	if !reflect.DeepEqual(nodes["290"], want) {
+		t.Errorf("node 290 = %+v, want %+v", nodes["290"], want)
+	}
+	if missing := nodes["999"]; missing.State != "unknown" || missing.InQueue {
+		t.Errorf("node 999 = %+v", missing)
+	}
+	if singleton := nodes["320"]; len(singleton.Dependencies) != 1 || singleton.Dependencies[0].Type != "singleton" || singleton.Blocked {
+		t.Errorf("node 320 = %+v", singleton)
+	}
+
+	wantEdges := []models.JobGraphEdge{
+		{From: "290", To: "310", Type: "afterok", Status: "failed"},
+		{From: "310", To: "311", Type: "afterok", Status: "unfulfilled"},
+		{From: "311", To: "312", Type: "afterany", Status: "unfulfilled"},
+		{From: "301", To: "312", Type: "afterok", Status: "unfulfilled"},
+		{From: "999", To: "330", Type: "after", Status: "unfulfilled"},
+	}
+	if !reflect.DeepEqual(graph.Edges, wantEdges) {
+		t.Errorf("edges = %+v, want %+v", graph.Edges, wantEdges)
+	}
+
+	wantChains := []models.BlockedChain{{
+		JobID:    "310",
+		Causes:   []models.JobDependency{{Type: "afterok", JobID: "290", Status: "failed"}},
+		Affected: []string{"311", "312"},
+	}}
+	if !reflect.DeepEqual(graph.BlockedChains, wantChains) {
+		t.Errorf("blocked chains = %+v, want %+v", graph.BlockedChains, wantChains)
+	}
+	for _, id := range []string{"310", "311", "312"} {
+		if !nodes[id].Blocked {
+			t.Errorf("node %s not blocked", id)
+		}
+	}
+	if nodes["301"].Blocked || nodes["290"].Blocked {
+		t.Error("upstream jobs marked blocked")
+	}
+}
+
+func TestFilterGraphComponent(t *testing.T) {
+	graph := fixtureDependencyGraph(t)
+
+	tests := []struct {
+		jobID  string
+		nodes  []string
+		edges  int
+		chains int
+	}{
+		// 沿边双向查找，上游的 301、290 与下游的 312 都在同一连通分量中
+		{"311", []string{"290", "301", "310", "311", "312"}, 4, 1},
+		{"330", []string{"330", "999"}, 1, 0},
+		{"340", []string{"340"}, 0, 0},
+		{"404", nil, 0, 0},
+	}
+	for _, tt := range tests {
+		filtered := filterGraphComponent(graph, tt.jobID)
+		var ids []string
+		for _, node := range filtered.Nodes {
+			ids = append(ids, node.JobID)
+		}
+		if !reflect.DeepEqual(ids, tt.nodes) || len(filtered.Edges) != tt.edges || len(filtered.BlockedChains) != tt.chains {
+			t.Errorf("filterGraphComponent(%s) = %v, %d edges, %d chains, want %v, %d, %d",
+				tt.jobID, ids, len(filtered.Edges), len(filtered.BlockedChains), tt.nodes, tt.edges, tt.chains)
+		}
+	}
+}
+
+func TestParseJobDependencies(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []models.JobDependency
+	}{
+		{"(null)", []models.JobDependency{}},
+		{"", []models.JobDependency{}},
+		{"afterok:123_*(unfulfilled)", []models.JobDependency{{Type: "afterok", JobID: "123_*", Status: "unfulfilled"}}},
+		// 一条依赖包含多个作业，after 的 +10 为延迟分钟数
+		{"afterok:1:2,after:3+10", []models.JobDependency{
+			{Type: "afterok", JobID: "1"}, {Type: "afterok", JobID: "2"}, {Type: "after", JobID: "3"},
+		}},
+		{"afternotok:7(unfulfilled)?afterany:8(unfulfilled)", []models.JobDependency{
+			{Type: "afternotok", JobID: "7", Status: "unfulfilled"}, {Type: "afterany", JobID: "8", Status: "unfulfilled"},
+		}},
+		{"singleton", []models.JobDependency{{Type: "singleton"}}},
+		{"AfterOK:9,bad item", []models.JobDependency{{Type: "afterok", JobID: "9"}}},
+	}
+	for _, tt := range tests {
+		if got := ParseJobDependencies(tt.expr); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseJobDependencies(%q) = %+v, want %+v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestCountArrayTasks(t *testing.T) {
+	tests := []struct {
+		expr string
+		want int
+	}{
+		{"7", 1},
+		{"1-10%2", 10},
+		{"[1,3,5-7]", 5},
+		{"0-20:5", 5},
+		{"5-1", 0},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if got := countArrayTasks(tt.expr); got != tt.want {
+			t.Errorf("countArrayTasks(%q) = %d, want %d", tt.expr, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"panel-tool/internal/models"
+	"panel-tool/internal/utils"
+)
+
+// 指标采集默认参数，可通过 PANEL_METRICS_FILE 和 PANEL_METRICS_INTERVAL（秒）覆盖
+const (
+	defaultMetricsFile     = "./data/metrics.gob"
+	defaultMetricsInterval = 15 * time.Second
+	metricsSaveInterval    = 5 * time.Minute
+)
+
+// 指标名称
+const (
+	MetricCPUUsage       = "cpu_usage"    // 百分比；计算节点未部署代理时为已分配 CPU 比例
+	MetricMemoryUsage    = "memory_usage" // 百分比
+	MetricLoad1          = "load1"
+	MetricJobsRunning    = "jobs_running"
+	MetricJobsPending    = "jobs_pending"
+	MetricTemperatureMax = "temperature_max" // 节点所有温度传感器中的最高值（°C）
+)
+
+// NodeMetricTarget 计算节点指标的对象名
+// 同一主机名可能出现在多个集群中，或与管理节点重名，因此带上集群名
+func NodeMetricTarget(cluster, host string) string {
+	return cluster + "/" + host
+}
+
+// SplitMetricTarget 将指标对象拆分为集群名和主机名
+// 计算节点为 "<集群>/<主机名>"，作业数指标的对象为集群名，其余为管理节点主机名
+func SplitMetricTarget(metric, target string) (string, string) {
+	if i := strings.LastIndex(target, "/"); i >= 0 {
+		return target[:i], target[i+1:]
+	}
+	switch metric {
+	case MetricJobsRunning, MetricJobsPending:
+		return target, ""
+	}
+	return "", target
+}
+
+// MetricSource 指标来源，每次采集时调用
+type MetricSource func() []models.MetricSample
+
+// MetricsService 定时从各来源采集指标写入时间序列存储，并定期落盘
+type MetricsService struct {
+	logger   *utils.Logger
+	store    *MetricsStore
+	interval time.Duration
+
+	sourcesMutex sync.Mutex
+	sources      []MetricSource
+
+	startOnce sync.Once
+	stop      chan struct{}
+}
+
+// NewMetricsService 创建新的指标服务实例，加载已保存的历史数据
+func NewMetricsService() *MetricsService {
+	path := os.Getenv("PANEL_METRICS_FILE")
+	if path == "" {
+		path = defaultMetricsFile
+	}
+	interval := defaultMetricsInterval
+	if value := os.Getenv("PANEL_METRICS_INTERVAL"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+			interval = time.Duration(seconds) * time.Second
+		}
+	}
+
+	s := &MetricsService{
+		logger:   utils.NewLogger(),
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+	store, err := NewMetricsStore(path)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("加载历史指标失败: %v", err))
+	}
+	s.store = store
+	return s
+}
+
+// AddSource 注册指标来源
+func (s *MetricsService) AddSource(source MetricSource) {
+	s.sourcesMutex.Lock()
+	defer s.sourcesMutex.Unlock()
+	s.sources = append(s.sources, source)
+}
+
+// Start 启动后台采集，重复调用无效
+func (s *MetricsService) Start() {
+	s.startOnce.Do(func() {
+		go s.run()
+	})
+}
+
+// Stop 停止后台采集并保存数据
+func (s *MetricsService) Stop() {
+	close(s.stop)
+}
+
+// run 采集循环
+func (s *MetricsService) run() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	saveTicker := time.NewTicker(metricsSaveInterval)
+	defer saveTicker.Stop()
+
+	for {
+		s.Collect()
+		select {
+		case <-s.stop:
+			s.save()
+			return
+		case <-saveTicker.C:
+			s.save()
+		case <-ticker.C:
+		}
+	}
+}
+
+// save 保存数据，失败只记录日志
+func (s *MetricsService) save() {
+	if err := s.store.Save(); err != nil {
+		s.logger.Error(fmt.Sprintf("保存历史指标失败: %v", err))
+	}
+}
+
+// Collect 立即从所有来源采集一次
+func (s *MetricsService) Collect() {
+	s.sourcesMutex.Lock()
+	sources := append([]MetricSource{}, s.sources...)
+	s.sourcesMutex.Unlock()
+
+	now := time.Now()
+	var samples []models.MetricSample
+	for _, source := range sources {
+		samples = append(samples, source()...)
+	}
+	s.store.Record(now, samples)
+}
+
+// Query 查询时间范围内的序列
+func (s *MetricsService) Query(metric, target string, start, end time.Time, resolution string) ([]models.MetricSeries, error) {
+	return s.store.Query(metric, target, start, end, resolution)
+}
+
+// Catalog 返回已记录的指标及其对象列表
+func (s *MetricsService) Catalog() map[string][]models.MetricTarget {
+	return s.store.Catalog()
+}
+
+// Latest 返回最近两个采集间隔内有更新的各序列的最新值
+func (s *MetricsService) Latest() []models.MetricSample {
+	return s.store.Latest(2 * s.interval)
+}
+
+// ManagementMetrics 管理节点的 CPU、内存、负载和最高温度指标
+func ManagementMetrics() []models.MetricSample {
+	telemetry, err := GetManagementTelemetry()
+	if err != nil {
+		return nil
+	}
+	host := getHostname()
+	samples := []models.MetricSample{
+		{Metric: MetricCPUUsage, Target: host, Value: telemetry.CPU.Total},
+		{Metric: MetricMemoryUsage, Target: host, Value: telemetry.Memory.UsedPercent},
+		{Metric: MetricLoad1, Target: host, Value: telemetry.Load.Load1},
+	}
+	if temperature, ok := maxTemperature(GetManagementSensors()); ok {
+		samples = append(samples, models.MetricSample{Metric: MetricTemperatureMax, Target: host, Value: temperature})
+	}
+	return samples
+}
+
+// ClusterMetrics 返回读取采集器缓存的指标来源：各计算节点的 CPU、内存、负载、最高温度以及集群的作业数
+// 节点指标的对象为 NodeMetricTarget(集群, 主机名)
+func ClusterMetrics(cluster *Cluster, collector *ClusterCollector) MetricSource {
+	return func() []models.MetricSample {
+		snapshot := collector.Snapshot()
+		var samples []models.MetricSample
+		for _, node := range snapshot.Nodes {
+			target := NodeMetricTarget(cluster.Name, node.Hostname)
+			samples = append(samples,
+				models.MetricSample{Metric: MetricCPUUsage, Target: target, Value: node.CPUUsage},
+				models.MetricSample{Metric: MetricMemoryUsage, Target: target, Value: node.MemoryUsage},
+				models.MetricSample{Metric: MetricLoad1, Target: target, Value: node.CPULoad},
+			)
+			if node.Agent != nil && !node.Agent.Stale {
+				if temperature, ok := maxTemperature(node.Agent.Sensors); ok {
+					samples = append(samples, models.MetricSample{Metric: MetricTemperatureMax, Target: target, Value: temperature})
+				}
+			}
+		}
+
+		running, pending := 0, 0
+		for _, job := range snapshot.Jobs {
+			switch job.Status {
+			case "running":
+				running++
+			case "pending":
+				pending++
+			}
+		}
+		return append(samples,
+			models.MetricSample{Metric: MetricJobsRunning, Target: cluster.Name, Value: float64(running)},
+			models.MetricSample{Metric: MetricJobsPending, Target: cluster.Name, Value: float64(pending)},
+		)
+	}
+}
@@ -0,0 +1,284 @@
+package services
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"panel-tool/internal/models"
+)
+
+// metricResolution 时间序列的一种分辨率：每个点覆盖 Step，环形缓冲区保留 Size 个点
+type metricResolution struct {
+	Name string
+	Step time.Duration
+	Size int
+}
+
+// metricResolutions 各分辨率按从细到粗排列：1 分钟保留 24 小时，5 分钟保留 7 天，1 小时保留 90 天
+var metricResolutions = []metricResolution{
+	{Name: "1m", Step: time.Minute, Size: 24 * 60},
+	{Name: "5m", Step: 5 * time.Minute, Size: 7 * 24 * 12},
+	{Name: "1h", Step: time.Hour, Size: 90 * 24},
+}
+
+// metricRing 固定长度的环形缓冲区，槽位号为 Unix 时间 / Step，空槽位为 NaN
+// 同一槽位内的多次采样取平均值，Sum 与 Count 为当前槽位的累计
+type metricRing struct {
+	Step   int64 // 秒
+	Head   int64 // 最新槽位号，0 表示尚无数据
+	Values []float64
+	Sum    float64
+	Count  int
+}
+
+// newMetricRing 创建空的环形缓冲区
+func newMetricRing(resolution metricResolution) *metricRing {
+	ring := &metricRing{Step: int64(resolution.Step / time.Second), Values: make([]float64, resolution.Size)}
+	ring.clear()
+	return ring
+}
+
+// clear 将所有槽位置为空
+func (r *metricRing) clear() {
+	for i := range r.Values {
+		r.Values[i] = math.NaN()
+	}
+}
+
+// add 写入一次采样，早于当前槽位的采样被忽略
+func (r *metricRing) add(t time.Time, value float64) {
+	slot := t.Unix() / r.Step
+	size := int64(len(r.Values))
+	if slot < r.Head {
+		return
+	}
+	if slot > r.Head {
+		// 跳过的槽位没有数据
+		if r.Head == 0 || slot-r.Head >= size {
+			r.clear()
+		} else {
+			for s := r.Head + 1; s < slot; s++ {
+				r.Values[s%size] = math.NaN()
+			}
+		}
+		r.Head = slot
+		r.Sum, r.Count = 0, 0
+	}
+	r.Sum += value
+	r.Count++
+	r.Values[slot%size] = r.Sum / float64(r.Count)
+}
+
+// points 返回 [start, end] 范围内的非空点
+func (r *metricRing) points(start, end time.Time) []models.MetricPoint {
+	points := []models.MetricPoint{}
+	if r.Head == 0 {
+		return points
+	}
+	size := int64(len(r.Values))
+	from := start.Unix() / r.Step
+	if oldest := r.Head - size + 1; from < oldest {
+		from = oldest
+	}
+	to := end.Unix() / r.Step
+	if to > r.Head {
+		to = r.Head
+	}
+	for s := from; s <= to; s++ {
+		if value := r.Values[s%size]; !math.IsNaN(value) {
+			points = append(points, models.MetricPoint{Time: time.Unix(s*r.Step, 0), Value: value})
+		}
+	}
+	return points
+}
+
+// metricSeriesData 一个指标在某个对象上的各分辨率数据
+type metricSeriesData struct {
+	Metric  string
+	Target  string
+	Updated time.Time
+	Rings   []*metricRing
+}
+
+// MetricsStore 内嵌的时间序列存储，每条序列按 metricResolutions 降采样，并以 gob 格式持久化
+type MetricsStore struct {
+	path string
+
+	mutex  sync.RWMutex
+	series map[string]*metricSeriesData
+}
+
+// NewMetricsStore 创建时间序列存储并加载已保存的数据，文件不存在时从空开始
+func NewMetricsStore(path string) (*MetricsStore, error) {
+	s := &MetricsStore{path: path, series: make(map[string]*metricSeriesData)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+
+	var series []*metricSeriesData
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&series); err != nil {
+		return s, fmt.Errorf("解析 %s 失败: %v", path, err)
+	}
+	for _, item := range series {
+		// 分辨率配置变化后旧数据无法对应，直接丢弃
+		if len(item.Rings) != len(metricResolutions) {
+			continue
+		}
+		s.series[metricSeriesKey(item.Metric, item.Target)] = item
+	}
+	return s, nil
+}
+
+// metricSeriesKey 序列的索引键
+func metricSeriesKey(metric, target string) string {
+	return metric + "\x00" + target
+}
+
+// Record 写入同一时刻的一批采样
+func (s *MetricsStore) Record(t time.Time, samples []models.MetricSample) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, sample := range samples {
+		if math.IsNaN(sample.Value) || math.IsInf(sample.Value, 0) {
+			continue
+		}
+		key := metricSeriesKey(sample.Metric, sample.Target)
+		series, ok := s.series[key]
+		if !ok {
+			series = &metricSeriesData{Metric: sample.Metric, Target: sample.Target}
+			for _, resolution := range metricResolutions {
+				series.Rings = append(series.Rings, newMetricRing(resolution))
+			}
+			s.series[key] = series
+		}
+		for _, ring := range series.Rings {
+			ring.add(t, sample.Value)
+		}
+		series.Updated = t
+	}
+}
+
+// Query 查询时间范围内的序列，target 为空时返回该指标的所有对象
+// resolution 为空时选择能覆盖起始时间的最细分辨率
+func (s *MetricsStore) Query(metric, target string, start, end time.Time, resolution string) ([]models.MetricSeries, error) {
+	if !start.Before(end) {
+		return nil, fmt.Errorf("开始时间必须早于结束时间")
+	}
+	index := -1
+	for i, r := range metricResolutions {
+		// 留出一个时间桶的余量，使“最近 24 小时”“最近 7 天”恰好落在对应分辨率内
+		if resolution == r.Name || (resolution == "" && time.Since(start) <= r.Step*time.Duration(r.Size+1)) {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		if resolution != "" {
+			return nil, fmt.Errorf("不支持的分辨率: %s", resolution)
+		}
+		index = len(metricResolutions) - 1
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	result := []models.MetricSeries{}
+	for _, series := range s.series {
+		if series.Metric != metric || (target != "" && series.Target != target) {
+			continue
+		}
+		cluster, host := SplitMetricTarget(series.Metric, series.Target)
+		result = append(result, models.MetricSeries{
+			Metric:     series.Metric,
+			Target:     series.Target,
+			Cluster:    cluster,
+			Host:       host,
+			Resolution: metricResolutions[index].Name,
+			Points:     series.Rings[index].points(start, end),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Target < result[j].Target })
+	return result, nil
+}
+
+// Catalog 返回已记录的指标及其对象列表，对象按 Target 排序
+func (s *MetricsStore) Catalog() map[string][]models.MetricTarget {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	catalog := make(map[string][]models.MetricTarget)
+	for _, series := range s.series {
+		cluster, host := SplitMetricTarget(series.Metric, series.Target)
+		catalog[series.Metric] = append(catalog[series.Metric], models.MetricTarget{Target: series.Target, Cluster: cluster, Host: host})
+	}
+	for _, targets := range catalog {
+		sort.Slice(targets, func(i, j int) bool { return targets[i].Target < targets[j].Target })
+	}
+	return catalog
+}
+
+// Latest 返回 maxAge 内有更新的各序列的最新值（最细分辨率当前槽位的平均值）
+func (s *MetricsStore) Latest(maxAge time.Duration) []models.MetricSample {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	since := time.Now().Add(-maxAge)
+	samples := []models.MetricSample{}
+	for _, series := range s.series {
+		ring := series.Rings[0]
+		if series.Updated.Before(since) || ring.Head == 0 {
+			continue
+		}
+		value := ring.Values[ring.Head%int64(len(ring.Values))]
+		if math.IsNaN(value) {
+			continue
+		}
+		samples = append(samples, models.MetricSample{Metric: series.Metric, Target: series.Target, Value: value})
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		if samples[i].Metric != samples[j].Metric {
+			return samples[i].Metric < samples[j].Metric
+		}
+		return samples[i].Target < samples[j].Target
+	})
+	return samples
+}
+
+// Save 将所有序列写入文件，超过最长保留期未更新的序列（如已下线的节点）会被删除
+func (s *MetricsStore) Save() error {
+	last := metricResolutions[len(metricResolutions)-1]
+	expire := time.Now().Add(-last.Step * time.Duration(last.Size))
+
+	s.mutex.Lock()
+	series := make([]*metricSeriesData, 0, len(s.series))
+	for key, item := range s.series {
+		if item.Updated.Before(expire) {
+			delete(s.series, key)
+			continue
+		}
+		series = append(series, item)
+	}
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(series)
+	s.mutex.Unlock()
+	if err != nil {
+		return fmt.Errorf("序列化时间序列失败: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("创建数据目录失败: %v", err)
+	}
+	return writeFileAtomic(s.path, buf.Bytes())
+}
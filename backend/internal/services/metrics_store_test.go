@@ -0,0 +1,142 @@
+package services
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"panel-tool/internal/models"
+)
+
+func TestSplitMetricTarget(t *testing.T) {
+	tests := []struct {
+		metric, target string
+		cluster, host  string
+	}{
+		{MetricCPUUsage, "alpha/cn001", "alpha", "cn001"},
+		{MetricTemperatureMax, "beta/cn001", "beta", "cn001"},
+		{MetricCPUUsage, "mgmt01", "", "mgmt01"},
+		{MetricJobsRunning, "alpha", "alpha", ""},
+		{MetricJobsPending, "beta", "beta", ""},
+	}
+	for _, tt := range tests {
+		cluster, host := SplitMetricTarget(tt.metric, tt.target)
+		if cluster != tt.cluster || host != tt.host {
+			t.Errorf("SplitMetricTarget(%s, %s) = %q, %q, want %q, %q", tt.metric, tt.target, cluster, host, tt.cluster, tt.host)
+		}
+	}
+}
+
+func TestMetricsStoreClusterTargets(t *testing.T) {
+	store, err := NewMetricsStore(t.TempDir() + "/metrics.gob")
+	if err != nil {
+		t.Fatalf("NewMetricsStore() error: %v", err)
+	}
+	now := time.Now()
+	// 两个集群中的同名节点、与节点重名的管理节点和集群作业数不能落到同一序列上
+	store.Record(now, []models.MetricSample{
+		{Metric: MetricCPUUsage, Target: NodeMetricTarget("alpha", "cn001"), Value: 10},
+		{Metric: MetricCPUUsage, Target: NodeMetricTarget("beta", "cn001"), Value: 90},
+		{Metric: MetricCPUUsage, Target: "cn001", Value: 50},
+		{Metric: MetricJobsRunning, Target: "alpha", Value: 3},
+	})
+
+	want := []models.MetricTarget{
+		{Target: "alpha/cn001", Cluster: "alpha", Host: "cn001"},
+		{Target: "beta/cn001", Cluster: "beta", Host: "cn001"},
+		{Target: "cn001", Host: "cn001"},
+	}
+	catalog := store.Catalog()
+	if !reflect.DeepEqual(catalog[MetricCPUUsage], want) {
+		t.Errorf("Catalog()[cpu_usage] = %+v, want %+v", catalog[MetricCPUUsage], want)
+	}
+	if got := catalog[MetricJobsRunning]; !reflect.DeepEqual(got, []models.MetricTarget{{Target: "alpha", Cluster: "alpha"}}) {
+		t.Errorf("Catalog()[jobs_running] = %+v", got)
+	}
+
+	series, err := store.Query(MetricCPUUsage, "beta/cn001", now.Add(-time.Minute), now.Add(time.Minute), "1m")
+	if err != nil {
+		t.Fatalf("Query() error: %v", err)
+	}
+	if len(series) != 1 || series[0].Cluster != "beta" || series[0].Host != "cn001" || len(series[0].Points) != 1 || series[0].Points[0].Value != 90 {
+		t.Errorf("Query(beta/cn001) = %+v", series)
+	}
+}
+
+func TestMetricRing(t *testing.T) {
+	// 4 个 1 分钟槽位，base 位于槽位 1000
+	ring := newMetricRing(metricResolution{Name: "test", Step: time.Minute, Size: 4})
+	base := time.Unix(1000*60, 0)
+	if points := ring.points(base.Add(-time.Hour), base.Add(time.Hour)); points == nil || len(points) != 0 {
+		t.Errorf("points() on empty ring = %#v", points)
+	}
+
+	point := func(slot int64, value float64) models.MetricPoint {
+		return models.MetricPoint{Time: time.Unix(slot*60, 0), Value: value}
+	}
+	steps := []struct {
+		name   string
+		offset time.Duration
+		value  float64
+		want   []models.MetricPoint
+	}{
+		{"first sample", 0, 10, []models.MetricPoint{point(1000, 10)}},
+		// 同一槽位内取平均值
+		{"same slot", 30 * time.Second, 20, []models.MetricPoint{point(1000, 15)}},
+		{"older slot ignored", -time.Minute, 99, []models.MetricPoint{point(1000, 15)}},
+		{"skipped slot", 2 * time.Minute, 30, []models.MetricPoint{point(1000, 15), point(1002, 30)}},
+		// 槽位 1004 覆盖槽位 1000 的位置，1001 与 1003 为空
+		{"wraparound", 4 * time.Minute, 40, []models.MetricPoint{point(1002, 30), point(1004, 40)}},
+		// 间隔超过缓冲区长度时清空所有旧数据
+		{"gap longer than ring", 10 * time.Minute, 50, []models.MetricPoint{point(1010, 50)}},
+	}
+	for _, step := range steps {
+		ring.add(base.Add(step.offset), step.value)
+		if got := ring.points(base.Add(-time.Hour), base.Add(time.Hour)); !reflect.DeepEqual(got, step.want) {
+			t.Errorf("%s: points() = %+v, want %+v", step.name, got, step.want)
+		}
+	}
+
+	ring.add(base.Add(11*time.Minute), 60)
+	if got := ring.points(base.Add(11*time.Minute), base.Add(time.Hour)); !reflect.DeepEqual(got, []models.MetricPoint{point(1011, 60)}) {
+		t.Errorf("points() from slot 1011 = %+v", got)
+	}
+	if got := ring.points(base, base.Add(5*time.Minute)); len(got) != 0 {
+		t.Errorf("points() before retained range = %+v", got)
+	}
+}
+
+func TestMetricsStoreSaveLoad(t *testing.T) {
+	path := t.TempDir() + "/data/metrics.gob"
+	store, err := NewMetricsStore(path)
+	if err != nil {
+		t.Fatalf("NewMetricsStore() error: %v", err)
+	}
+	now := time.Now()
+	store.Record(now, []models.MetricSample{{Metric: MetricCPUUsage, Target: "mgmt01", Value: 42}})
+	// 超过最长保留期未更新的序列在保存时删除
+	store.Record(now.AddDate(0, 0, -100), []models.MetricSample{{Metric: MetricCPUUsage, Target: "retired", Value: 1}})
+	if err := store.Save(); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	loaded, err := NewMetricsStore(path)
+	if err != nil {
+		t.Fatalf("NewMetricsStore(saved) error: %v", err)
+	}
+	want := []models.MetricSample{{Metric: MetricCPUUsage, Target: "mgmt01", Value: 42}}
+	if got := loaded.Latest(time.Minute); !reflect.DeepEqual(got, want) {
+		t.Errorf("Latest() after load = %+v, want %+v", got, want)
+	}
+	if targets := loaded.Catalog()[MetricCPUUsage]; len(targets) != 1 {
+		t.Errorf("Catalog() after load = %+v", targets)
+	}
+
+	if err := os.WriteFile(path, []byte("not gob"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewMetricsStore(path); err == nil {
+		t.Error("NewMetricsStore() = nil error for corrupt file")
+	}
+}
@@ -2,11 +2,15 @@ package services
 
 import (
 	"bufio"
+	"container/list"
+	"context"
 	"fmt"
+	"net"
 	"os"
 	"os/exec"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"panel-tool/internal/models"
@@ -23,6 +27,8 @@ func GetManagementNode() *models.ManagementNode {
 	kernelVersion := getKernelVersion()
 	localTime := time.Now().Format("2006-01-02 15:04:05")
 	uptime := getUptime()
+	// 实时指标采集失败时只返回静态信息
+	telemetry, _ := GetManagementTelemetry()
 
 	return &models.ManagementNode{
 		Hostname:      hostname,
@@ -33,9 +39,18 @@ func GetManagementNode() *models.ManagementNode {
 		KernelVersion: kernelVersion,
 		LocalTime:     localTime,
 		Uptime:        uptime,
+		Telemetry:     telemetry,
 	}
 }
 
+// managementTelemetry 管理节点的指标采样器，保存上一次的计数用于计算速率
+var managementTelemetry = NewTelemetrySampler()
+
+// GetManagementTelemetry 获取管理节点的实时指标
+func GetManagementTelemetry() (*models.HostTelemetry, error) {
+	return managementTelemetry.Sample()
+}
+
 // getHostname 获取主机名
 func getHostname() string {
 	hostname, err := os.Hostname()
@@ -66,42 +81,46 @@ func getArchitecture() string {
 	return strings.TrimSpace(string(output))
 }
 
-// getCPUInfo 获取CPU信息
+// getCPUInfo 获取CPU信息，核心数与线程数来自 CPU 拓扑，如 "Intel Xeon Gold 6248 x 2S 40C 80T @ 2.50GHz"
 func getCPUInfo() string {
-	file, err := os.Open("/proc/cpuinfo")
+	cpuModel := readCPUModel("/proc/cpuinfo")
+	if cpuModel != "" {
+		// 简化CPU信息显示
+		cpuParts := strings.Split(cpuModel, " @ ")
+		model := cpuParts[0]
+		clockSpeed := ""
+		if len(cpuParts) > 1 {
+			clockSpeed = " @ " + cpuParts[1]
+		}
+		topology := ReadCPUTopology("/sys", "/proc")
+		if topology.Sockets > 1 {
+			return fmt.Sprintf("%s x %dS %dC %dT%s", model, topology.Sockets, topology.Cores, topology.Threads, clockSpeed)
+		}
+		return fmt.Sprintf("%s x %dC %dT%s", model, topology.Cores, topology.Threads, clockSpeed)
+	}
+
+	return "Unknown CPU"
+}
+
+// readCPUModel 读取 /proc/cpuinfo 中第一个 model name，读取失败时返回空字符串
+func readCPUModel(path string) string {
+	file, err := os.Open(path)
 	if err != nil {
-		return "Unknown CPU"
+		return ""
 	}
 	defer file.Close()
 
 	scanner := bufio.NewScanner(file)
-	var cpuModel string
-	var cpuCount int
-
 	for scanner.Scan() {
 		line := scanner.Text()
 		if strings.HasPrefix(line, "model name") {
 			parts := strings.Split(line, ":")
-			if len(parts) > 1 && cpuModel == "" {
-				cpuModel = strings.TrimSpace(parts[1])
+			if len(parts) > 1 {
+				return strings.TrimSpace(parts[1])
 			}
-		} else if strings.HasPrefix(line, "processor") {
-			cpuCount++
-		}
-	}
-
-	if cpuModel != "" {
-		// 简化CPU信息显示
-		cpuParts := strings.Split(cpuModel, " @ ")
-		model := cpuParts[0]
-		clockSpeed := ""
-		if len(cpuParts) > 1 {
-			clockSpeed = " @ " + cpuParts[1]
 		}
-		return fmt.Sprintf("%s x %dC %dT%s", model, cpuCount, cpuCount, clockSpeed)
 	}
-
-	return "Unknown CPU"
+	return ""
 }
 
 // getOSVersion 获取操作系统版本，特别支持Rocky Linux和OpenEuler
@@ -181,90 +200,251 @@ func getUptime() string {
 
 // GetComputeNodes 获取计算节点真实信息
 func GetComputeNodes() []models.NodeModel {
-	// 检查slurm是否安装
-	if _, err := os.Stat("/usr/sbin/slurmctld"); os.IsNotExist(err) {
-		// Slurm未安装
-		return []models.NodeModel{}
-	}
-	
-	// 检查slurmctld服务是否运行
-	cmd := exec.Command("systemctl", "is-active", "slurmctld")
-	output, err := cmd.Output()
-	if err != nil {
-		// Slurmctld未运行
-		return []models.NodeModel{}
+	nodes, _ := FetchComputeNodes(nil)
+	return nodes
+}
+
+// FetchComputeNodes 获取计算节点信息，Slurm 未安装或未运行时返回空列表，
+// 仅在 scontrol 查询失败时返回错误，便于调用方区分“没有节点”和“暂时查询不到”
+// 远程集群的 slurmctld 不在本机运行，跳过本机服务检查；只配置 slurmrestd 的集群从 /nodes 接口读取
+func FetchComputeNodes(cluster *Cluster) ([]models.NodeModel, error) {
+	if cluster.restOnly() {
+		return fetchRESTNodes(cluster)
 	}
-	
-	status := strings.TrimSpace(string(output))
-	if status != "active" {
-		// Slurmctld未运行
-		return []models.NodeModel{}
+	if cluster.isLocal() && !localSlurmctldRunning() {
+		return []models.NodeModel{}, nil
 	}
-	
+
 	// 检查是否有配置文件
-	if _, err := os.Stat("/etc/slurm/slurm.conf"); os.IsNotExist(err) {
+	if _, err := os.Stat(cluster.confPath()); os.IsNotExist(err) {
 		// Slurmctld已运行但没有客户端配置
-		return []models.NodeModel{}
+		return []models.NodeModel{}, nil
 	}
 	
-	// 尝试使用sinfo命令获取节点信息
-	cmd = exec.Command("sinfo", "-h", "-o", "%n|%C|%m")
-	output, err = cmd.Output()
+	// 使用scontrol show node -d获取节点的完整信息（-d 输出 GresUsed）
+	output, err := cluster.command("scontrol", "show", "node", "-d").Output()
 	if err != nil {
 		// Slurmctld已运行但没有客户端在线
-		return []models.NodeModel{}
+		return []models.NodeModel{}, fmt.Errorf("执行 scontrol show node 失败: %v", err)
 	}
 	
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	if len(lines) == 0 || (len(lines) == 1 && lines[0] == "") {
+	nodes := ParseNodeRecords(string(output))
+	if len(nodes) == 0 {
 		// Slurmctld已运行但没有客户端在线
-		return []models.NodeModel{}
+		return []models.NodeModel{}, nil
+	}
+	for i := range nodes {
+		nodes[i].Cluster = cluster.ClusterName()
 	}
 	
+	return nodes, nil
+}
+
+// localSlurmctldRunning 检查本机 Slurm 是否安装且 slurmctld 正在运行
+func localSlurmctldRunning() bool {
+	// 检查slurm是否安装
+	if _, err := os.Stat("/usr/sbin/slurmctld"); os.IsNotExist(err) {
+		return false
+	}
+
+	// 检查slurmctld服务是否运行
+	output, err := exec.Command("systemctl", "is-active", "slurmctld").Output()
+	if err != nil {
+		return false
+	}
+	return strings.TrimSpace(string(output)) == "active"
+}
+
+// ParseNodeRecords 解析 scontrol show node 的输出为节点列表
+func ParseNodeRecords(output string) []models.NodeModel {
 	var nodes []models.NodeModel
-	for _, line := range lines {
-		if line == "" {
+	for _, record := range ParseScontrolRecords(output) {
+		if record["NodeName"] == "" {
 			continue
 		}
-		
-		parts := strings.Split(line, "|")
-		if len(parts) < 3 {
-			continue
+		nodes = append(nodes, nodeFromScontrol(record))
+	}
+	return nodes
+}
+
+// nodeFromScontrol 由 scontrol show node 记录构造节点信息
+func nodeFromScontrol(record map[string]string) models.NodeModel {
+	node := models.NodeModel{
+		Hostname:     record["NodeName"],
+		Architecture: record["Arch"],
+		NodeAddr:     record["NodeAddr"],
+		CPUTotal:     int(parseSlurmInt(record["CPUTot"])),
+		CPUAlloc:     int(parseSlurmInt(record["CPUAlloc"])),
+		RealMemory:   parseSlurmInt(record["RealMemory"]),
+		AllocMemory:  parseSlurmInt(record["AllocMem"]),
+		FreeMemory:   parseSlurmInt(record["FreeMem"]),
+		Gres:         cleanSlurmValue(record["Gres"]),
+		Reason:       cleanSlurmValue(record["Reason"]),
+		BootTime:     parseSlurmTime(record["BootTime"]),
+	}
+	node.State, node.StateFlags = splitNodeState(record["State"])
+	node.IP = resolveNodeAddr(node.NodeAddr)
+
+	if partitions := cleanSlurmValue(record["Partitions"]); partitions != "" {
+		node.Partitions = strings.Split(partitions, ",")
+	} else {
+		node.Partitions = []string{}
+	}
+	if features := cleanSlurmValue(record["ActiveFeatures"]); features != "" {
+		node.Features = strings.Split(features, ",")
+	}
+	if load, err := strconv.ParseFloat(record["CPULoad"], 64); err == nil {
+		node.CPULoad = load
+	}
+	applyNodeGres(&node, record)
+
+	// 计算CPU使用率 (已分配/总计)
+	if node.CPUTotal > 0 {
+		node.CPUUsage = float64(node.CPUAlloc) / float64(node.CPUTotal) * 100
+	}
+
+	// 内存使用量取节点实际占用（RealMemory - FreeMem），FreeMem 不可用时退回到已分配内存
+	if node.RealMemory > 0 {
+		if _, ok := record["FreeMem"]; ok && record["FreeMem"] != "N/A" {
+			node.UsedMemory = node.RealMemory - node.FreeMemory
+		} else {
+			node.UsedMemory = node.AllocMemory
 		}
-		
-		hostname := parts[0]
-		cpuInfo := strings.Split(parts[1], "/")
-		// totalMemory := parts[2]  // 不再使用这个变量
-		
-		// 计算CPU使用率 (已分配/总计)
-		if len(cpuInfo) >= 4 {
-			allocated, _ := strconv.ParseFloat(cpuInfo[2], 64)
-			total, _ := strconv.ParseFloat(cpuInfo[3], 64)
-			
-			cpuUsage := 0.0
-			if total > 0 {
-				cpuUsage = (allocated / total) * 100
-			}
-			
-			// 内存信息处理
-			// totalMem, _ := strconv.ParseFloat(totalMemory, 64)  // 移除未使用的变量
-			// 这里简化处理，实际内存使用率需要通过其他方式获取
-			memoryUsage := 30.0 // 默认值
-			
-			nodes = append(nodes, models.NodeModel{
-				Hostname:    hostname,
-				IP:          hostname, // 简化处理，实际应该获取真实IP
-				CPUUsage:    cpuUsage,
-				MemoryUsage: memoryUsage,
-			})
+		if node.UsedMemory < 0 {
+			node.UsedMemory = 0
 		}
+		node.MemoryUsage = float64(node.UsedMemory) / float64(node.RealMemory) * 100
 	}
-	
-	// 如果没有获取到节点信息
-	if len(nodes) == 0 {
-		// Slurmctld已运行但没有客户端在线
-		return []models.NodeModel{}
+
+	// 节点代理在线时由 AgentRegistry.Merge 替换为实测值
+	node.MetricsSource = MetricsSourceSlurm
+
+	return node
+}
+
+// splitNodeState 拆分 Slurm 节点状态，如 "MIXED+DRAIN" 拆为 "mixed" 和 ["DRAIN"]
+func splitNodeState(state string) (string, []string) {
+	parts := strings.Split(state, "+")
+	base := strings.ToLower(strings.TrimRight(parts[0], "*~#!%$@^-"))
+	flags := []string{}
+	for _, flag := range parts[1:] {
+		if flag != "" {
+			flags = append(flags, flag)
+		}
 	}
-	
-	return nodes
-}
\ No newline at end of file
+	return base, flags
+}
+
+// cleanSlurmValue 将 (null)、N/A 等空值转换为空字符串
+func cleanSlurmValue(value string) string {
+	switch value {
+	case "(null)", "N/A", "none", "None":
+		return ""
+	}
+	return value
+}
+
+// 节点地址解析结果的缓存时间，解析失败的结果缓存较短时间以便尽快重试
+// 单次解析最多等待 nodeAddrLookupTimeout，避免 DNS 不可用时拖慢每轮采集
+const (
+	nodeAddrCacheTTL      = 5 * time.Minute
+	nodeAddrFailureTTL    = time.Minute
+	nodeAddrCacheMaxSize  = 65536
+	nodeAddrLookupTimeout = 2 * time.Second
+)
+
+// nodeAddrEntry 一次地址解析的结果
+type nodeAddrEntry struct {
+	addr    string
+	ip      string
+	expires time.Time
+}
+
+// nodeAddrCache 节点地址解析缓存，条目数超过 max 时淘汰最早写入的条目
+type nodeAddrCache struct {
+	mutex   sync.Mutex
+	max     int
+	entries map[string]*list.Element
+	// order 按写入先后排列，元素为 *nodeAddrEntry，最早写入的在前
+	order *list.List
+}
+
+// newNodeAddrCache 创建最多保存 max 条结果的解析缓存
+func newNodeAddrCache(max int) *nodeAddrCache {
+	return &nodeAddrCache{max: max, entries: make(map[string]*list.Element), order: list.New()}
+}
+
+// get 返回未过期的解析结果
+func (c *nodeAddrCache) get(addr string, now time.Time) (string, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	element, ok := c.entries[addr]
+	if !ok {
+		return "", false
+	}
+	entry := element.Value.(*nodeAddrEntry)
+	if !now.Before(entry.expires) {
+		return "", false
+	}
+	return entry.ip, true
+}
+
+// put 写入解析结果，已有的条目视为重新写入
+func (c *nodeAddrCache) put(addr, ip string, expires time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if element, ok := c.entries[addr]; ok {
+		entry := element.Value.(*nodeAddrEntry)
+		entry.ip, entry.expires = ip, expires
+		c.order.MoveToBack(element)
+		return
+	}
+	c.entries[addr] = c.order.PushBack(&nodeAddrEntry{addr: addr, ip: ip, expires: expires})
+	for c.order.Len() > c.max {
+		oldest := c.order.Front()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*nodeAddrEntry).addr)
+	}
+}
+
+var (
+	nodeAddrs = newNodeAddrCache(nodeAddrCacheMaxSize)
+	// lookupHost 解析主机名，测试中可替换
+	lookupHost = net.DefaultResolver.LookupHost
+)
+
+// resolveNodeAddr 将 NodeAddr 解析为 IP 地址，解析失败时返回原值
+// 每次采集都会解析所有节点，结果按 nodeAddrCacheTTL 缓存，避免频繁查询 DNS
+func resolveNodeAddr(addr string) string {
+	if addr == "" || net.ParseIP(addr) != nil {
+		return addr
+	}
+
+	now := time.Now()
+	if ip, ok := nodeAddrs.get(addr, now); ok {
+		return ip
+	}
+
+	ip, ttl := lookupNodeAddr(addr), nodeAddrCacheTTL
+	if ip == addr {
+		ttl = nodeAddrFailureTTL
+	}
+	nodeAddrs.put(addr, ip, now.Add(ttl))
+	return ip
+}
+
+// lookupNodeAddr 通过 DNS 解析主机名，优先返回 IPv4 地址，解析失败或超时时返回原值
+func lookupNodeAddr(addr string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), nodeAddrLookupTimeout)
+	defer cancel()
+	ips, err := lookupHost(ctx, addr)
+	if err != nil || len(ips) == 0 {
+		return addr
+	}
+	for _, ip := range ips {
+		if parsed := net.ParseIP(ip); parsed != nil && parsed.To4() != nil {
+			return ip
+		}
+	}
+	return ips[0]
+}
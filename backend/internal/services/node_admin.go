@@ -0,0 +1,95 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"panel-tool/internal/hostlist"
+)
+
+// nodeNamePattern 合法的节点名，防止参数注入
+var nodeNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.\-]*$`)
+
+// nodeStateActions 节点管理操作与 scontrol state 取值的对应关系
+var nodeStateActions = map[string]string{
+	"drain":   "DRAIN",
+	"resume":  "RESUME",
+	"down":    "DOWN",
+	"undrain": "UNDRAIN",
+}
+
+// NodeState 节点当前状态
+type NodeState struct {
+	Name   string `json:"name"`
+	State  string `json:"state"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// UpdateNodeState 修改节点状态（drain/resume/down/undrain），nodes 可以是主机列表表达式
+// 返回操作后节点的状态
+func UpdateNodeState(cluster *Cluster, nodes, action, reason string) ([]NodeState, error) {
+	state, ok := nodeStateActions[action]
+	if !ok {
+		return nil, fmt.Errorf("不支持的节点操作: %s", action)
+	}
+	expr, err := normalizeHostlist(nodes)
+	if err != nil {
+		return nil, err
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("必须填写操作原因")
+	}
+
+	args := []string{"update", "nodename=" + expr, "state=" + state}
+	// scontrol 只在 DRAIN/DOWN 时接受 reason，其余操作的原因仅写入审计日志
+	if state == "DRAIN" || state == "DOWN" {
+		args = append(args, "reason="+reason)
+	}
+	if _, err := runSlurmCommand(cluster, "scontrol", args...); err != nil {
+		return nil, err
+	}
+
+	return GetNodeStates(cluster, expr)
+}
+
+// normalizeHostlist 展开并校验主机列表表达式，返回压缩后的规范形式
+func normalizeHostlist(nodes string) (string, error) {
+	names, err := hostlist.Expand(nodes)
+	if err != nil {
+		return "", fmt.Errorf("无效的节点列表: %v", err)
+	}
+	if len(names) == 0 {
+		return "", fmt.Errorf("节点列表为空")
+	}
+	for _, name := range names {
+		if !nodeNamePattern.MatchString(name) {
+			return "", fmt.Errorf("无效的节点名: %s", name)
+		}
+	}
+	return hostlist.Compress(names), nil
+}
+
+// GetNodeStates 通过 scontrol show node 查询节点状态
+func GetNodeStates(cluster *Cluster, nodes string) ([]NodeState, error) {
+	expr, err := normalizeHostlist(nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := runSlurmCommand(cluster, "scontrol", "show", "node", expr)
+	if err != nil {
+		return nil, err
+	}
+
+	var states []NodeState
+	for _, record := range ParseScontrolRecords(output) {
+		states = append(states, NodeState{
+			Name:   record["NodeName"],
+			State:  record["State"],
+			Reason: record["Reason"],
+		})
+	}
+	return states, nil
+}
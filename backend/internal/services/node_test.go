@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// stubLookupHost 替换 DNS 解析，返回解析次数的计数表，测试结束后恢复
+func stubLookupHost(t *testing.T, hosts map[string][]string) map[string]int {
+	t.Helper()
+	calls := make(map[string]int)
+	savedLookup, savedCache := lookupHost, nodeAddrs
+	lookupHost = func(ctx context.Context, host string) ([]string, error) {
+		calls[host]++
+		if _, ok := ctx.Deadline(); !ok {
+			t.Errorf("lookup of %s without timeout", host)
+		}
+		if ips, ok := hosts[host]; ok {
+			return ips, nil
+		}
+		return nil, errors.New("no such host")
+	}
+	nodeAddrs = newNodeAddrCache(nodeAddrCacheMaxSize)
+	t.Cleanup(func() { lookupHost, nodeAddrs = savedLookup, savedCache })
+	return calls
+}
+
+func TestParseNodeRecords(t *testing.T) {
+	stubLookupHost(t, map[string][]string{"cn002-ib": {"fe80::2", "10.3.0.2"}})
+	nodes := ParseNodeRecords(readFixture(t, "scontrol_show_node_d.txt") + "\n" + readFixture(t, "scontrol_show_node_drain.txt"))
+
+	var names []string
+	for _, node := range nodes {
+		names = append(names, node.Hostname)
+	}
+	if want := []string{"gpu001", "gpu002", "gpu003", "cn001", "cn002", "cn003"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("nodes = %v, want %v", names, want)
+	}
+
+	tests := []struct {
+		name      string
+		state     string
+		flags     []string
+		ip        string
+		cpu       float64
+		used      int64
+		memory    float64
+		load      float64
+		gpus      [2]int64
+		parts     []string
+		features  []string
+		reason    string
+		bootKnown bool
+	}{
+		{"gpu001", "mixed", []string{}, "10.1.0.1", 37.5, 515000 - 301233, float64(515000-301233) / 515000 * 100, 23.87, [2]int64{4, 2}, []string{"gpu"}, []string{"a100", "ib"}, "", true},
+		{"gpu002", "mixed", []string{}, "10.1.0.2", 25, 191000 - 150122, float64(191000-150122) / 191000 * 100, 7.02, [2]int64{2, 1}, []string{"gpu", "debug"}, []string{"v100"}, "", false},
+		{"cn001", "idle", []string{}, "10.2.0.1", 0, 0, 0, 0.01, [2]int64{0, 0}, []string{"cpu"}, nil, "", false},
+		// FreeMem=N/A 时以已分配内存计算使用量，NodeAddr 为主机名时优先解析为 IPv4
+		{"cn002", "mixed", []string{"DRAIN"}, "10.3.0.2", 25, 64000, 25, 0, [2]int64{0, 0}, []string{}, []string{"arm"},
+			"Low RealMemory (reported:250000 < 100.00% of configured:256000) [slurm@2024-03-05T10:00:00]", false},
+		// 解析失败时保留原值
+		{"cn003", "down", []string{"NOT_RESPONDING"}, "cn003", 0, 0, 0, 0, [2]int64{0, 0}, []string{"cpu"}, nil, "Not responding [slurm@2024-03-05T09:12:00]", false},
+	}
+	byName := make(map[string]int)
+	for i, node := range nodes {
+		byName[node.Hostname] = i
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := nodes[byName[tt.name]]
+			if node.State != tt.state || !reflect.DeepEqual(node.StateFlags, tt.flags) || node.IP != tt.ip {
+				t.Errorf("state/ip = %s %v %s, want %s %v %s", node.State, node.StateFlags, node.IP, tt.state, tt.flags, tt.ip)
+			}
+			if node.CPUUsage != tt.cpu || node.UsedMemory != tt.used || node.MemoryUsage != tt.memory || node.CPULoad != tt.load {
+				t.Errorf("usage = cpu %v mem %d (%v%%) load %v, want %v %d (%v%%) %v", node.CPUUsage, node.UsedMemory, node.MemoryUsage, node.CPULoad, tt.cpu, tt.used, tt.memory, tt.load)
+			}
+			if [2]int64{node.GPUTotal, node.GPUAlloc} != tt.gpus {
+				t.Errorf("gpus = %d/%d, want %v", node.GPUAlloc, node.GPUTotal, tt.gpus)
+			}
+			if !reflect.DeepEqual(node.Partitions, tt.parts) || !reflect.DeepEqual(node.Features, tt.features) || node.Reason != tt.reason {
+				t.Errorf("partitions/features/reason = %v %v %q", node.Partitions, node.Features, node.Reason)
+			}
+			if node.BootTime.IsZero() == tt.bootKnown || node.MetricsSource != MetricsSourceSlurm {
+				t.Errorf("boot time = %v, source = %s", node.BootTime, node.MetricsSource)
+			}
+		})
+	}
+}
+
+func TestResolveNodeAddrCache(t *testing.T) {
+	calls := stubLookupHost(t, map[string][]string{"cn001": {"10.2.0.1"}, "cn002": {"10.2.0.2"}, "cn003": {"10.2.0.3"}})
+	nodeAddrs = newNodeAddrCache(2)
+
+	for _, addr := range []string{"cn001", "cn001", "10.9.9.9", ""} {
+		resolveNodeAddr(addr)
+	}
+	if calls["cn001"] != 1 || len(calls) != 1 {
+		t.Errorf("lookups = %v, want cn001 once and no lookup for IPs", calls)
+	}
+
+	// 超过容量时淘汰最早写入的条目
+	resolveNodeAddr("cn002")
+	resolveNodeAddr("cn003")
+	if len(nodeAddrs.entries) != 2 || nodeAddrs.order.Len() != 2 {
+		t.Fatalf("cache size = %d/%d, want 2", len(nodeAddrs.entries), nodeAddrs.order.Len())
+	}
+	if _, ok := nodeAddrs.get("cn001", time.Now()); ok {
+		t.Error("oldest entry cn001 was not evicted")
+	}
+	if got := resolveNodeAddr("cn003"); got != "10.2.0.3" || calls["cn003"] != 1 {
+		t.Errorf("resolveNodeAddr(cn003) = %s after %d lookups", got, calls["cn003"])
+	}
+
+	// 过期的条目重新解析，并按重新写入处理
+	nodeAddrs.put("cn002", "10.2.0.2", time.Now().Add(-time.Second))
+	if got := resolveNodeAddr("cn002"); got != "10.2.0.2" || calls["cn002"] != 2 {
+		t.Errorf("resolveNodeAddr(cn002) = %s after %d lookups, want a fresh lookup", got, calls["cn002"])
+	}
+	resolveNodeAddr("cn001")
+	if _, ok := nodeAddrs.get("cn002", time.Now()); !ok {
+		t.Error("refreshed entry cn002 was evicted before cn003")
+	}
+}
@@ -0,0 +1,472 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"panel-tool/internal/models"
+	"panel-tool/internal/utils"
+)
+
+// defaultNotificationsFile 订阅与站内信的默认存储路径，可通过 PANEL_NOTIFICATIONS_FILE 覆盖
+const defaultNotificationsFile = "./config/notifications.json"
+
+// defaultLocalMailDir 本地 SMTP 替身保存邮件的默认目录，可通过 PANEL_SMTP_MAIL_DIR 覆盖
+const defaultLocalMailDir = "./logs/mail"
+
+// inboxLimit 每个用户保留的站内信数量，超出时删除最旧的
+const inboxLimit = 200
+
+// notificationQueueSize 每个集群待处理的作业结束事件队列长度
+const notificationQueueSize = 4096
+
+// notificationBatchSize 单次 sacct 查询合并的作业数量上限
+const notificationBatchSize = 100
+
+// jobFailureStates 视为失败的作业结束状态
+var jobFailureStates = map[string]bool{
+	"failed":        true,
+	"out_of_memory": true,
+	"timeout":       true,
+	"node_fail":     true,
+	"boot_fail":     true,
+	"deadline":      true,
+}
+
+// notificationState 持久化到文件的订阅与站内信
+type notificationState struct {
+	Subscriptions []models.NotificationSubscription `json:"subscriptions"`
+	Inbox         []models.Notification             `json:"inbox"`
+}
+
+// NotificationService 根据集群采集器的作业结束事件匹配用户订阅，并通过各渠道发送通知
+type NotificationService struct {
+	logger *utils.Logger
+	path   string
+
+	mutex         sync.Mutex
+	subscriptions []models.NotificationSubscription
+	inbox         []models.Notification
+
+	notifiersMutex sync.RWMutex
+	notifiers      map[string]Notifier
+
+	// localSMTP 为 PANEL_SMTP_ADDR=local 时启动的本地 SMTP 替身
+	localSMTP *LocalSMTPServer
+}
+
+// NewNotificationService 创建新的通知服务实例，注册站内信、Webhook 和邮件渠道
+// PANEL_SMTP_ADDR=local 时在本机启动 SMTP 替身接收邮件并保存到 PANEL_SMTP_MAIL_DIR，便于测试
+func NewNotificationService() *NotificationService {
+	path := os.Getenv("PANEL_NOTIFICATIONS_FILE")
+	if path == "" {
+		path = defaultNotificationsFile
+	}
+
+	s := &NotificationService{
+		logger:        utils.NewLogger(),
+		path:          path,
+		subscriptions: []models.NotificationSubscription{},
+		inbox:         []models.Notification{},
+		notifiers:     make(map[string]Notifier),
+	}
+	if err := s.load(); err != nil && !os.IsNotExist(err) {
+		s.logger.Error(fmt.Sprintf("读取通知配置失败: %v", err))
+	}
+
+	smtpConfig := SMTPConfigFromEnv()
+	if smtpConfig.Addr == "local" {
+		mailDir := os.Getenv("PANEL_SMTP_MAIL_DIR")
+		if mailDir == "" {
+			mailDir = defaultLocalMailDir
+		}
+		server, err := StartLocalSMTPServer("127.0.0.1:0", mailDir)
+		if err != nil {
+			s.logger.Error(fmt.Sprintf("启动本地 SMTP 替身失败: %v", err))
+			smtpConfig.Addr = ""
+		} else {
+			s.localSMTP = server
+			smtpConfig.Addr = server.Addr()
+			s.logger.Info(fmt.Sprintf("本地 SMTP 替身监听 %s，邮件保存到 %s", server.Addr(), mailDir))
+		}
+	}
+
+	s.RegisterNotifier(&InboxNotifier{service: s})
+	s.RegisterNotifier(NewRestrictedWebhookNotifier())
+	s.RegisterNotifier(NewSMTPNotifier(smtpConfig))
+	return s
+}
+
+// load 从文件加载订阅与站内信
+func (s *NotificationService) load() error {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+	var state notificationState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("解析 %s 失败: %v", s.path, err)
+	}
+	if state.Subscriptions != nil {
+		s.subscriptions = state.Subscriptions
+	}
+	if state.Inbox != nil {
+		s.inbox = state.Inbox
+	}
+	return nil
+}
+
+// save 将订阅与站内信写入文件，调用方需持有 mutex
+func (s *NotificationService) save() error {
+	data, err := json.MarshalIndent(notificationState{Subscriptions: s.subscriptions, Inbox: s.inbox}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("创建配置目录失败: %v", err)
+	}
+	if err := writeFileAtomic(s.path, data); err != nil {
+		return fmt.Errorf("保存通知配置失败: %v", err)
+	}
+	return nil
+}
+
+// RegisterNotifier 注册通知渠道，同名渠道会被替换
+func (s *NotificationService) RegisterNotifier(notifier Notifier) {
+	s.notifiersMutex.Lock()
+	defer s.notifiersMutex.Unlock()
+	s.notifiers[notifier.Channel()] = notifier
+}
+
+// notifier 按名称获取通知渠道
+func (s *NotificationService) notifier(channel string) (Notifier, bool) {
+	s.notifiersMutex.RLock()
+	defer s.notifiersMutex.RUnlock()
+	notifier, ok := s.notifiers[channel]
+	return notifier, ok
+}
+
+// Watch 订阅集群采集器的变更事件，作业结束时发送通知
+// 事件先转入队列由单独的协程处理，避免 sacct 查询阻塞订阅通道导致采集器丢弃事件
+func (s *NotificationService) Watch(cluster *Cluster, collector *ClusterCollector) {
+	events, _ := collector.Subscribe()
+	queue := make(chan ClusterEvent, notificationQueueSize)
+	go func() {
+		defer close(queue)
+		for event := range events {
+			if event.Type != EventJobFinished || event.Job == nil {
+				continue
+			}
+			select {
+			case queue <- event:
+			default:
+				s.logger.Error(fmt.Sprintf("通知队列已满，丢弃作业 %s 的结束事件", event.Job.JobID))
+			}
+		}
+	}()
+	go s.processJobEvents(cluster, queue)
+}
+
+// processJobEvents 每次取出队列中已积压的事件（最多 notificationBatchSize 个）一并处理
+func (s *NotificationService) processJobEvents(cluster *Cluster, queue <-chan ClusterEvent) {
+	for event := range queue {
+		batch := []ClusterEvent{event}
+	drain:
+		for len(batch) < notificationBatchSize {
+			select {
+			case next, ok := <-queue:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, next)
+			default:
+				break drain
+			}
+		}
+		s.HandleJobsFinished(cluster, batch)
+	}
+}
+
+// HandleJobsFinished 处理一批作业结束事件：确定最终状态，匹配订阅并发送通知
+// 未经终止状态直接离开队列的作业通过一次 sacct 查询批量获取最终状态
+func (s *NotificationService) HandleJobsFinished(cluster *Cluster, events []ClusterEvent) {
+	var lookup []string
+	for _, event := range events {
+		if event.Job != nil && !isJobFinished(stateKey(event.NewState)) {
+			lookup = append(lookup, event.Job.JobID)
+		}
+	}
+	finalStates := jobFinalStates(cluster, lookup)
+
+	for _, event := range events {
+		if event.Job == nil {
+			continue
+		}
+		job := *event.Job
+		state := event.NewState
+		if !isJobFinished(stateKey(state)) {
+			state = "unknown"
+			if final, ok := finalStates[job.JobID]; ok {
+				state = final
+			}
+		}
+
+		notification := buildJobNotification(cluster.ClusterName(), job, state)
+		for _, sub := range s.matchSubscriptions(cluster.ClusterName(), job, notification.Failed) {
+			go s.deliver(sub, notification)
+		}
+	}
+}
+
+// jobFinalStates 通过一次 sacct 查询多个作业的最终状态，查询失败时返回空结果
+func jobFinalStates(cluster *Cluster, jobIDs []string) map[string]string {
+	states := make(map[string]string)
+	var valid []string
+	for _, jobID := range jobIDs {
+		if ValidJobID(jobID) {
+			valid = append(valid, jobID)
+		}
+	}
+	if len(valid) == 0 {
+		return states
+	}
+
+	output, err := runSlurmCommand(cluster, "sacct", append([]string{"-j", strings.Join(valid, ",")}, sacctOutputArgs()...)...)
+	if err != nil {
+		return states
+	}
+	for _, record := range ParseSacctOutput(output) {
+		if fields := strings.Fields(record.State); len(fields) > 0 {
+			if _, ok := states[record.JobID]; !ok {
+				states[record.JobID] = normalizeJobState(fields[0])
+			}
+		}
+	}
+	return states
+}
+
+// buildJobNotification 构造作业结束通知
+func buildJobNotification(cluster string, job models.JobModel, state string) models.Notification {
+	failed := jobFailureStates[state]
+	title := fmt.Sprintf("作业 %s (%s) 已结束: %s", job.JobID, job.Name, state)
+	if failed {
+		title = fmt.Sprintf("作业 %s (%s) 失败: %s", job.JobID, job.Name, state)
+	}
+
+	var lines []string
+	if cluster != "" {
+		lines = append(lines, "集群: "+cluster)
+	}
+	lines = append(lines,
+		"作业: "+job.JobID,
+		"名称: "+job.Name,
+		"用户: "+job.User,
+		"分区: "+job.Partition,
+		"状态: "+state,
+	)
+	if job.NodeList != "" && job.NodeList != "(null)" {
+		lines = append(lines, "节点: "+job.NodeList)
+	}
+	if state == "out_of_memory" {
+		lines = append(lines, "作业因超出内存限制被终止，请增加 --mem 或 --mem-per-cpu 后重新提交")
+	}
+
+	return models.Notification{
+		Time:    time.Now(),
+		Title:   title,
+		Message: strings.Join(lines, "\n"),
+		Cluster: cluster,
+		JobID:   job.JobID,
+		JobName: job.Name,
+		State:   state,
+		Failed:  failed,
+	}
+}
+
+// matchSubscriptions 返回与作业匹配的订阅
+func (s *NotificationService) matchSubscriptions(cluster string, job models.JobModel, failed bool) []models.NotificationSubscription {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var matched []models.NotificationSubscription
+	for _, sub := range s.subscriptions {
+		if sub.Cluster != "" && sub.Cluster != cluster {
+			continue
+		}
+		if sub.FailuresOnly && !failed {
+			continue
+		}
+		if sub.JobID != "" {
+			// 订阅数组作业时匹配其所有任务
+			if sub.JobID != job.JobID && sub.JobID != dependencyBaseID(job.JobID) {
+				continue
+			}
+		} else if sub.JobUser != job.User {
+			continue
+		}
+		matched = append(matched, sub)
+	}
+	return matched
+}
+
+// deliver 通过订阅的各个渠道发送通知，失败只记录日志
+func (s *NotificationService) deliver(sub models.NotificationSubscription, notification models.Notification) {
+	notification.User = sub.User
+	for _, channel := range sub.Channels {
+		notifier, ok := s.notifier(channel)
+		if !ok {
+			s.logger.Error(fmt.Sprintf("订阅 %s 使用了未注册的通知渠道 %s", sub.ID, channel))
+			continue
+		}
+		if err := notifier.Notify(sub, notification); err != nil {
+			s.logger.Error(fmt.Sprintf("通过 %s 向 %s 发送作业 %s 的通知失败: %v", channel, sub.User, notification.JobID, err))
+		}
+	}
+}
+
+// addToInbox 写入站内信，每个用户只保留最近 inboxLimit 条
+func (s *NotificationService) addToInbox(notification models.Notification) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	notification.ID = newNotificationID()
+	s.inbox = append(s.inbox, notification)
+
+	count := 0
+	for i := len(s.inbox) - 1; i >= 0; i-- {
+		if s.inbox[i].User != notification.User {
+			continue
+		}
+		count++
+		if count > inboxLimit {
+			s.inbox = append(s.inbox[:i], s.inbox[i+1:]...)
+		}
+	}
+	return s.save()
+}
+
+// Subscriptions 返回用户的订阅
+func (s *NotificationService) Subscriptions(user string) []models.NotificationSubscription {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	subs := []models.NotificationSubscription{}
+	for _, sub := range s.subscriptions {
+		if sub.User == user {
+			subs = append(subs, sub)
+		}
+	}
+	return subs
+}
+
+// AddSubscription 校验并保存订阅，非管理员只能订阅自己的作业
+func (s *NotificationService) AddSubscription(sub models.NotificationSubscription, admin bool) (*models.NotificationSubscription, error) {
+	if sub.User == "" {
+		return nil, fmt.Errorf("订阅用户不能为空")
+	}
+	if !admin || sub.JobUser == "" {
+		sub.JobUser = sub.User
+	}
+	if sub.JobID != "" && !ValidJobID(sub.JobID) {
+		return nil, fmt.Errorf("无效的作业 ID: %s", sub.JobID)
+	}
+	if len(sub.Channels) == 0 {
+		sub.Channels = []string{ChannelInbox}
+	}
+	for _, channel := range sub.Channels {
+		if _, ok := s.notifier(channel); !ok {
+			return nil, fmt.Errorf("不支持的通知渠道: %s", channel)
+		}
+		switch channel {
+		case ChannelEmail:
+			if !strings.Contains(sub.Email, "@") {
+				return nil, fmt.Errorf("邮件通知需要有效的邮箱地址")
+			}
+		case ChannelWebhook:
+			u, err := url.Parse(sub.WebhookURL)
+			if err != nil {
+				return nil, fmt.Errorf("Webhook 地址必须是 http 或 https URL")
+			}
+			if !admin && !webhookHostAllowed(u.Hostname()) {
+				return nil, fmt.Errorf("只有管理员可以添加 Webhook 通知，或由管理员将 %s 加入 PANEL_WEBHOOK_ALLOWED_HOSTS", u.Hostname())
+			}
+			if err := ValidateWebhookURL(sub.WebhookURL); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	sub.ID = newNotificationID()
+	sub.CreatedAt = time.Now()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.subscriptions = append(s.subscriptions, sub)
+	if err := s.save(); err != nil {
+		s.subscriptions = s.subscriptions[:len(s.subscriptions)-1]
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// DeleteSubscription 删除用户的订阅
+func (s *NotificationService) DeleteSubscription(user, id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i, sub := range s.subscriptions {
+		if sub.ID == id && sub.User == user {
+			s.subscriptions = append(s.subscriptions[:i], s.subscriptions[i+1:]...)
+			return s.save()
+		}
+	}
+	return fmt.Errorf("订阅 %s 不存在", id)
+}
+
+// Inbox 返回用户的站内信（最新的在前），unreadOnly 为 true 时只返回未读通知
+func (s *NotificationService) Inbox(user string, unreadOnly bool) []models.Notification {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	notifications := []models.Notification{}
+	for i := len(s.inbox) - 1; i >= 0; i-- {
+		n := s.inbox[i]
+		if n.User == user && (!unreadOnly || !n.Read) {
+			notifications = append(notifications, n)
+		}
+	}
+	return notifications
+}
+
+// MarkRead 将用户的通知标记为已读，id 为空时标记全部
+func (s *NotificationService) MarkRead(user, id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	found := false
+	for i := range s.inbox {
+		if s.inbox[i].User == user && (id == "" || s.inbox[i].ID == id) {
+			s.inbox[i].Read = true
+			found = true
+		}
+	}
+	if id != "" && !found {
+		return fmt.Errorf("通知 %s 不存在", id)
+	}
+	return s.save()
+}
+
+// newNotificationID 生成随机 ID
+func newNotificationID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"bytes"
+	"mime"
+	"net/mail"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"panel-tool/internal/models"
+)
+
+// deliverMail 通过本地 SMTP 替身发送通知并返回收到的邮件
+func deliverMail(t *testing.T, sub models.NotificationSubscription, notification models.Notification) LocalMail {
+	t.Helper()
+	server, err := StartLocalSMTPServer("127.0.0.1:0", "")
+	if err != nil {
+		t.Fatalf("start local SMTP: %v", err)
+	}
+	defer server.Close()
+
+	notifier := NewSMTPNotifier(SMTPConfig{Addr: server.Addr(), From: "panel@example.com"})
+	if err := notifier.Notify(sub, notification); err != nil {
+		t.Fatalf("Notify() error: %v", err)
+	}
+	mails := server.Mails()
+	if len(mails) != 1 {
+		t.Fatalf("received %d mails, want 1", len(mails))
+	}
+	return mails[0]
+}
+
+func TestSMTPNotifierLocalServer(t *testing.T) {
+	job := models.JobModel{JobID: "4242", Name: "train", User: "alice", Partition: "gpu", NodeList: "gpu001"}
+	tests := []struct {
+		name    string
+		state   string
+		title   string
+		message string
+	}{
+		{"failed", "failed", "作业 4242 (train) 失败: failed", "状态: failed"},
+		{"out of memory", "out_of_memory", "作业 4242 (train) 失败: out_of_memory", "请增加 --mem 或 --mem-per-cpu"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notification := buildJobNotification("alpha", job, tt.state)
+			if !notification.Failed {
+				t.Fatalf("notification for %s not marked failed", tt.state)
+			}
+			sub := models.NotificationSubscription{ID: "sub1", User: "alice", Email: "alice@example.com"}
+			received := deliverMail(t, sub, notification)
+
+			if received.From != "panel@example.com" {
+				t.Errorf("MAIL FROM = %q, want panel@example.com", received.From)
+			}
+			if !reflect.DeepEqual(received.To, []string{"alice@example.com"}) {
+				t.Errorf("RCPT TO = %v, want [alice@example.com]", received.To)
+			}
+
+			msg, err := mail.ReadMessage(bytes.NewReader(received.Data))
+			if err != nil {
+				t.Fatalf("parse mail: %v", err)
+			}
+			if to := msg.Header.Get("To"); to != "alice@example.com" {
+				t.Errorf("To header = %q", to)
+			}
+			raw := msg.Header.Get("Subject")
+			if !strings.HasPrefix(raw, "=?UTF-8?b?") {
+				t.Errorf("Subject %q is not RFC 2047 encoded", raw)
+			}
+			subject, err := new(mime.WordDecoder).DecodeHeader(raw)
+			if err != nil || subject != tt.title {
+				t.Errorf("Subject = %q (%v), want %q", subject, err, tt.title)
+			}
+			var body bytes.Buffer
+			body.ReadFrom(msg.Body)
+			if !strings.Contains(body.String(), tt.message) || !strings.Contains(body.String(), "集群: alpha") {
+				t.Errorf("body %q missing %q", body.String(), tt.message)
+			}
+		})
+	}
+}
+
+func TestMatchSubscriptions(t *testing.T) {
+	s := &NotificationService{subscriptions: []models.NotificationSubscription{
+		{ID: "job", User: "alice", JobUser: "alice", JobID: "100"},
+		{ID: "array", User: "alice", JobUser: "alice", JobID: "200"},
+		{ID: "mine", User: "alice", JobUser: "alice"},
+		{ID: "failures", User: "bob", JobUser: "bob", FailuresOnly: true},
+		{ID: "beta", User: "bob", JobUser: "bob", Cluster: "beta"},
+	}}
+	tests := []struct {
+		name    string
+		cluster string
+		job     models.JobModel
+		failed  bool
+		want    []string
+	}{
+		{"job id", "alpha", models.JobModel{JobID: "100", User: "alice"}, false, []string{"job", "mine"}},
+		{"other job of user", "alpha", models.JobModel{JobID: "101", User: "alice"}, false, []string{"mine"}},
+		{"array base id", "alpha", models.JobModel{JobID: "200_7", User: "alice"}, false, []string{"array", "mine"}},
+		{"array task id prefix", "alpha", models.JobModel{JobID: "2000", User: "carol"}, true, nil},
+		{"failures only skips success", "alpha", models.JobModel{JobID: "300", User: "bob"}, false, nil},
+		{"failures only on failure", "alpha", models.JobModel{JobID: "300", User: "bob"}, true, []string{"failures"}},
+		{"cluster filter", "beta", models.JobModel{JobID: "301", User: "bob"}, false, []string{"beta"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, sub := range s.matchSubscriptions(tt.cluster, tt.job, tt.failed) {
+				got = append(got, sub.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("matchSubscriptions(%s, %+v, %v) = %v, want %v", tt.cluster, tt.job, tt.failed, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddSubscriptionJobUser(t *testing.T) {
+	s := &NotificationService{
+		path:      t.TempDir() + "/notifications.json",
+		notifiers: map[string]Notifier{ChannelInbox: &InboxNotifier{}},
+	}
+	tests := []struct {
+		name  string
+		sub   models.NotificationSubscription
+		admin bool
+		want  string
+	}{
+		{"user cannot watch others", models.NotificationSubscription{User: "alice", JobUser: "bob"}, false, "alice"},
+		{"admin may watch others", models.NotificationSubscription{User: "admin", JobUser: "bob"}, true, "bob"},
+		{"default to self", models.NotificationSubscription{User: "admin"}, true, "admin"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, err := s.AddSubscription(tt.sub, tt.admin)
+			if err != nil {
+				t.Fatalf("AddSubscription() error: %v", err)
+			}
+			if sub.JobUser != tt.want {
+				t.Errorf("JobUser = %q, want %q", sub.JobUser, tt.want)
+			}
+			if !reflect.DeepEqual(sub.Channels, []string{ChannelInbox}) || sub.CreatedAt.After(time.Now()) {
+				t.Errorf("unexpected defaults: %+v", sub)
+			}
+		})
+	}
+}
+
+func TestValidateWebhookURL(t *testing.T) {
+	for _, rawURL := range []string{
+		"ftp://hooks.example.com/",
+		"http://127.0.0.1:8080/",
+		"http://localhost/",
+		"http://[::1]/",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://10.1.2.3/",
+		"http://192.168.0.10/",
+		"http://0.0.0.0/",
+	} {
+		if err := ValidateWebhookURL(rawURL); err == nil {
+			t.Errorf("ValidateWebhookURL(%q) = nil, want error", rawURL)
+		}
+	}
+
+	t.Setenv("PANEL_WEBHOOK_ALLOWED_HOSTS", "chat.internal, 10.1.2.3")
+	for _, rawURL := range []string{"https://chat.internal/hook", "http://10.1.2.3/"} {
+		if err := ValidateWebhookURL(rawURL); err != nil {
+			t.Errorf("ValidateWebhookURL(%q) = %v, want nil for allowed host", rawURL, err)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"panel-tool/internal/models"
+)
+
+// 通知渠道名称
+const (
+	ChannelInbox   = "inbox"
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+)
+
+// webhookTimeout Webhook 请求超时时间
+const webhookTimeout = 10 * time.Second
+
+// Notifier 通知发送渠道，新的渠道实现该接口后通过 NotificationService.RegisterNotifier 注册
+type Notifier interface {
+	// Channel 返回渠道名称，与订阅中的 Channels 对应
+	Channel() string
+	// Notify 按订阅中的收件地址发送通知
+	Notify(sub models.NotificationSubscription, notification models.Notification) error
+}
+
+// SMTPConfig SMTP 服务器配置
+type SMTPConfig struct {
+	Addr     string // host:port
+	Username string
+	Password string
+	From     string
+}
+
+// SMTPConfigFromEnv 从 PANEL_SMTP_ADDR、PANEL_SMTP_USER、PANEL_SMTP_PASSWORD、PANEL_SMTP_FROM 读取 SMTP 配置
+func SMTPConfigFromEnv() SMTPConfig {
+	config := SMTPConfig{
+		Addr:     os.Getenv("PANEL_SMTP_ADDR"),
+		Username: os.Getenv("PANEL_SMTP_USER"),
+		Password: os.Getenv("PANEL_SMTP_PASSWORD"),
+		From:     os.Getenv("PANEL_SMTP_FROM"),
+	}
+	if config.From == "" {
+		config.From = "panel@" + getHostname()
+	}
+	return config
+}
+
+// SMTPNotifier 通过 SMTP 发送邮件通知
+type SMTPNotifier struct {
+	config SMTPConfig
+}
+
+// NewSMTPNotifier 创建新的邮件通知渠道
+func NewSMTPNotifier(config SMTPConfig) *SMTPNotifier {
+	return &SMTPNotifier{config: config}
+}
+
+// Channel 返回渠道名称
+func (n *SMTPNotifier) Channel() string {
+	return ChannelEmail
+}
+
+// Notify 发送邮件，未配置用户名时不进行认证
+func (n *SMTPNotifier) Notify(sub models.NotificationSubscription, notification models.Notification) error {
+	if n.config.Addr == "" {
+		return fmt.Errorf("未配置 SMTP 服务器")
+	}
+	if sub.Email == "" {
+		return fmt.Errorf("订阅 %s 未设置邮箱地址", sub.ID)
+	}
+
+	var auth smtp.Auth
+	if n.config.Username != "" {
+		host := n.config.Addr
+		if idx := strings.LastIndex(host, ":"); idx >= 0 {
+			host = host[:idx]
+		}
+		auth = smtp.PlainAuth("", n.config.Username, n.config.Password, host)
+	}
+	if err := smtp.SendMail(n.config.Addr, auth, n.config.From, []string{sub.Email}, buildMailMessage(n.config.From, sub.Email, notification)); err != nil {
+		return fmt.Errorf("发送邮件失败: %v", err)
+	}
+	return nil
+}
+
+// buildMailMessage 构造纯文本邮件，标题使用 RFC 2047 编码以支持中文
+func buildMailMessage(from, to string, notification models.Notification) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", to)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", notification.Title))
+	fmt.Fprintf(&buf, "Date: %s\r\n", notification.Time.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	buf.WriteString(strings.ReplaceAll(notification.Message, "\n", "\r\n"))
+	buf.WriteString("\r\n")
+	return buf.Bytes()
+}
+
+// WebhookNotifier 以 JSON POST 请求发送通知到订阅中的 URL
+type WebhookNotifier struct {
+	client *http.Client
+	// restricted 为 nil 时不限制目标地址；否则用于 PANEL_WEBHOOK_ALLOWED_HOSTS 之外的主机，拒绝连接内网地址
+	restricted *http.Client
+}
+
+// NewWebhookNotifier 创建新的 Webhook 通知渠道，不限制目标地址，用于管理员配置的告警 Webhook
+func NewWebhookNotifier() *WebhookNotifier {
+	return &WebhookNotifier{client: &http.Client{Timeout: webhookTimeout}}
+}
+
+// NewRestrictedWebhookNotifier 创建用于用户订阅的 Webhook 通知渠道
+// 除 PANEL_WEBHOOK_ALLOWED_HOSTS 列出的主机外，连接时检查解析后的地址，拒绝回环、链路本地和内网地址，防止借面板访问内部服务
+func NewRestrictedWebhookNotifier() *WebhookNotifier {
+	dialer := &net.Dialer{
+		Timeout: webhookTimeout,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !webhookPublicIP(ip) {
+				return fmt.Errorf("Webhook 不允许连接内网地址 %s", host)
+			}
+			return nil
+		},
+	}
+	// 不使用代理，保证地址检查作用于实际的目标地址
+	transport := &http.Transport{DialContext: dialer.DialContext}
+	return &WebhookNotifier{
+		client:     &http.Client{Timeout: webhookTimeout},
+		restricted: &http.Client{Timeout: webhookTimeout, Transport: transport},
+	}
+}
+
+// webhookHostAllowed 判断主机是否在 PANEL_WEBHOOK_ALLOWED_HOSTS（逗号分隔的主机名）中
+func webhookHostAllowed(host string) bool {
+	for _, allowed := range strings.Split(os.Getenv("PANEL_WEBHOOK_ALLOWED_HOSTS"), ",") {
+		if allowed = strings.TrimSpace(allowed); allowed != "" && strings.EqualFold(allowed, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// webhookPublicIP 判断地址是否可作为用户 Webhook 的目标：排除回环、链路本地、内网、未指定和组播地址
+func webhookPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsPrivate() ||
+		ip.IsUnspecified() || ip.IsMulticast())
+}
+
+// ValidateWebhookURL 检查用户订阅的 Webhook 地址：必须是 http 或 https URL；
+// 不在 PANEL_WEBHOOK_ALLOWED_HOSTS 中的主机解析后不能是回环、链路本地或内网地址
+func ValidateWebhookURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return fmt.Errorf("Webhook 地址必须是 http 或 https URL")
+	}
+	host := u.Hostname()
+	if webhookHostAllowed(host) {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return fmt.Errorf("无法解析 Webhook 主机 %s", host)
+	}
+	for _, addr := range addrs {
+		if !webhookPublicIP(addr.IP) {
+			return fmt.Errorf("Webhook 主机 %s 解析为内网地址 %s", host, addr.IP)
+		}
+	}
+	return nil
+}
+
+// Channel 返回渠道名称
+func (n *WebhookNotifier) Channel() string {
+	return ChannelWebhook
+}
+
+// Notify 发送 Webhook 请求，非 2xx 响应视为失败
+func (n *WebhookNotifier) Notify(sub models.NotificationSubscription, notification models.Notification) error {
+	if sub.WebhookURL == "" {
+		return fmt.Errorf("订阅 %s 未设置 Webhook 地址", sub.ID)
+	}
+	data, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+	client := n.client
+	if n.restricted != nil {
+		// 连接时再次检查地址，防止 DNS 在校验后改为指向内网
+		if u, err := url.Parse(sub.WebhookURL); err != nil || !webhookHostAllowed(u.Hostname()) {
+			client = n.restricted
+		}
+	}
+	resp, err := client.Post(sub.WebhookURL, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("发送 Webhook 失败: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Webhook 返回状态码 %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// InboxNotifier 将通知写入面板站内信
+type InboxNotifier struct {
+	service *NotificationService
+}
+
+// Channel 返回渠道名称
+func (n *InboxNotifier) Channel() string {
+	return ChannelInbox
+}
+
+// Notify 写入订阅用户的收件箱
+func (n *InboxNotifier) Notify(sub models.NotificationSubscription, notification models.Notification) error {
+	return n.service.addToInbox(notification)
+}
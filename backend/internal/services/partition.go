@@ -0,0 +1,187 @@
+package services
+
+import (
+	"strconv"
+	"strings"
+
+	"panel-tool/internal/models"
+)
+
+// qosFields sacctmgr show qos 使用的字段，顺序与 ParseQOSOutput 对应
+var qosFields = []string{
+	"Name", "Priority", "Preempt", "PreemptMode", "Flags", "UsageFactor",
+	"GrpTRES", "GrpJobs", "GrpSubmit", "MaxTRES", "MaxTRESPU", "MaxJobsPU",
+	"MaxSubmitPU", "MaxWall", "MinTRES",
+}
+
+// FetchPartitions 获取所有分区的配置和 CPU 使用情况，作业数和 GPU 由采集器已有的作业与节点列表统计
+// 只配置 slurmrestd 的集群从 /partitions 接口读取配置，CPU 使用情况按节点列表汇总
+func FetchPartitions(cluster *Cluster, jobs []models.JobModel, nodes []models.NodeModel) ([]models.PartitionModel, error) {
+	var partitions []models.PartitionModel
+	if cluster.restOnly() {
+		var err error
+		if partitions, err = fetchRESTPartitions(cluster); err != nil {
+			return nil, err
+		}
+		applyPartitionNodeCPUs(partitions, nodes)
+	} else {
+		output, err := runSlurmCommand(cluster, "scontrol", "show", "partition")
+		if err != nil {
+			return nil, err
+		}
+		partitions = ParsePartitionRecords(output)
+
+		// CPU 使用情况获取失败时保留配置信息
+		if cpuOutput, err := runSlurmCommand(cluster, "sinfo", "--noheader", "--format=%R|%C"); err == nil {
+			applyPartitionCPUs(partitions, cpuOutput)
+		}
+	}
+	for i := range partitions {
+		partitions[i].Cluster = cluster.ClusterName()
+	}
+	applyPartitionJobs(partitions, jobs)
+	applyPartitionGPUs(partitions, nodes)
+	return partitions, nil
+}
+
+// ParsePartitionRecords 解析 scontrol show partition 的输出
+func ParsePartitionRecords(output string) []models.PartitionModel {
+	partitions := []models.PartitionModel{}
+	for _, record := range ParseScontrolRecords(output) {
+		if record["PartitionName"] == "" {
+			continue
+		}
+		partitions = append(partitions, partitionFromScontrol(record))
+	}
+	return partitions
+}
+
+// partitionFromScontrol 由 scontrol show partition 记录构造分区信息
+func partitionFromScontrol(record map[string]string) models.PartitionModel {
+	return models.PartitionModel{
+		Name:          record["PartitionName"],
+		State:         record["State"],
+		Default:       record["Default"] == "YES",
+		Nodes:         cleanSlurmValue(record["Nodes"]),
+		TotalNodes:    parseSlurmInt(record["TotalNodes"]),
+		TotalCPUs:     parseSlurmInt(record["TotalCPUs"]),
+		MaxTime:       record["MaxTime"],
+		DefaultTime:   record["DefaultTime"],
+		DefMemPerCPU:  parseSlurmInt(record["DefMemPerCPU"]),
+		DefMemPerNode: parseSlurmInt(record["DefMemPerNode"]),
+		MaxMemPerCPU:  parseSlurmInt(record["MaxMemPerCPU"]),
+		MaxMemPerNode: parseSlurmInt(record["MaxMemPerNode"]),
+		AllowAccounts: splitSlurmList(record["AllowAccounts"]),
+		DenyAccounts:  splitSlurmList(record["DenyAccounts"]),
+		AllowQOS:      splitSlurmList(record["AllowQos"]),
+		QOS:           cleanSlurmValue(record["QoS"]),
+	}
+}
+
+// applyPartitionCPUs 根据 sinfo %R|%C（已分配/空闲/其他/总计）填充分区 CPU 数据
+// sinfo 可能为同一分区输出多行，需要累加
+func applyPartitionCPUs(partitions []models.PartitionModel, output string) {
+	index := partitionIndex(partitions)
+	reset := make(map[int]bool)
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		name, cpus, ok := strings.Cut(line, "|")
+		if !ok {
+			continue
+		}
+		i, ok := index[strings.TrimSuffix(name, "*")]
+		if !ok {
+			continue
+		}
+		counts := strings.Split(cpus, "/")
+		if len(counts) != 4 {
+			continue
+		}
+		p := &partitions[i]
+		if !reset[i] {
+			p.AllocCPUs, p.IdleCPUs, p.OtherCPUs, p.TotalCPUs = 0, 0, 0, 0
+			reset[i] = true
+		}
+		p.AllocCPUs += parseSlurmInt(counts[0])
+		p.IdleCPUs += parseSlurmInt(counts[1])
+		p.OtherCPUs += parseSlurmInt(counts[2])
+		p.TotalCPUs += parseSlurmInt(counts[3])
+	}
+}
+
+// applyPartitionJobs 统计各分区排队和运行的作业数
+// 提交到多个分区的排队作业（Partition 为逗号分隔的列表）在每个分区都计数
+func applyPartitionJobs(partitions []models.PartitionModel, jobs []models.JobModel) {
+	index := partitionIndex(partitions)
+	for _, job := range jobs {
+		for _, name := range strings.Split(job.Partition, ",") {
+			i, ok := index[name]
+			if !ok {
+				continue
+			}
+			switch job.Status {
+			case "pending":
+				partitions[i].JobsPending++
+			case "running":
+				partitions[i].JobsRunning++
+			}
+		}
+	}
+}
+
+// partitionIndex 建立分区名到下标的索引
+func partitionIndex(partitions []models.PartitionModel) map[string]int {
+	index := make(map[string]int, len(partitions))
+	for i, p := range partitions {
+		index[p.Name] = i
+	}
+	return index
+}
+
+// splitSlurmList 拆分逗号分隔的列表，空值返回空切片
+func splitSlurmList(value string) []string {
+	value = cleanSlurmValue(value)
+	if value == "" {
+		return []string{}
+	}
+	return strings.Split(value, ",")
+}
+
+// GetQOSList 通过 sacctmgr 获取所有 QoS 及其限制
+func GetQOSList(cluster *Cluster) ([]models.QOSModel, error) {
+	output, err := runSlurmCommand(cluster, "sacctmgr", "show", "qos", "--parsable2", "--noheader",
+		"format="+strings.Join(qosFields, ","))
+	if err != nil {
+		return nil, err
+	}
+	return ParseQOSOutput(output), nil
+}
+
+// ParseQOSOutput 解析 sacctmgr show qos --parsable2 的输出，字段顺序为 qosFields
+func ParseQOSOutput(output string) []models.QOSModel {
+	list := []models.QOSModel{}
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		parts := strings.Split(line, "|")
+		if len(parts) < len(qosFields) || parts[0] == "" {
+			continue
+		}
+		usageFactor, _ := strconv.ParseFloat(parts[5], 64)
+		list = append(list, models.QOSModel{
+			Name:             parts[0],
+			Priority:         parseSlurmInt(parts[1]),
+			Preempt:          parts[2],
+			PreemptMode:      parts[3],
+			Flags:            parts[4],
+			UsageFactor:      usageFactor,
+			GrpTRES:          parts[6],
+			GrpJobs:          parts[7],
+			GrpSubmitJobs:    parts[8],
+			MaxTRES:          parts[9],
+			MaxTRESPerUser:   parts[10],
+			MaxJobsPerUser:   parts[11],
+			MaxSubmitPerUser: parts[12],
+			MaxWall:          parts[13],
+			MinTRES:          parts[14],
+		})
+	}
+	return list
+}
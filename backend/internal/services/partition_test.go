@@ -0,0 +1,94 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"panel-tool/internal/models"
+)
+
+func TestParsePartitionRecords(t *testing.T) {
+	partitions := ParsePartitionRecords(readFixture(t, "scontrol_show_partition.txt"))
+
+	// 内存限制为 UNLIMITED 时为 0，(null)、N/A 视为未设置
+	want := []models.PartitionModel{
+		{Name: "cpu", State: "UP", Default: true, Nodes: "cn[001-064]", TotalNodes: 64, TotalCPUs: 4096,
+			MaxTime: "7-00:00:00", DefaultTime: "01:00:00", DefMemPerCPU: 4000,
+			AllowAccounts: []string{"ALL"}, DenyAccounts: []string{}, AllowQOS: []string{"ALL"}},
+		{Name: "gpu", State: "UP", Nodes: "gpu[001-008]", TotalNodes: 8, TotalCPUs: 512,
+			MaxTime: "2-00:00:00", DefaultTime: "NONE", MaxMemPerCPU: 16000,
+			AllowAccounts: []string{"ml", "vision"}, DenyAccounts: []string{}, AllowQOS: []string{"gpu_normal", "gpu_long"}, QOS: "gpu_part"},
+		{Name: "maint", State: "INACTIVE", MaxTime: "UNLIMITED", DefaultTime: "NONE",
+			AllowAccounts: []string{"ALL"}, DenyAccounts: []string{"guest", "students"}, AllowQOS: []string{"ALL"}},
+	}
+	if len(partitions) != len(want) {
+		t.Fatalf("got %d partitions, want %d", len(partitions), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(partitions[i], want[i]) {
+			t.Errorf("partition %s =\n%+v\nwant\n%+v", want[i].Name, partitions[i], want[i])
+		}
+	}
+
+	if got := ParsePartitionRecords(""); len(got) != 0 {
+		t.Errorf("ParsePartitionRecords(\"\") = %+v", got)
+	}
+}
+
+func TestApplyPartitionUsage(t *testing.T) {
+	partitions := ParsePartitionRecords(readFixture(t, "scontrol_show_partition.txt"))
+
+	// sinfo 为同一分区输出多行时累加，默认分区名带 *
+	applyPartitionCPUs(partitions, readFixture(t, "sinfo_partition_cpus.txt"))
+	tests := []struct {
+		name                      string
+		alloc, idle, other, total int64
+	}{
+		{"cpu", 1536, 2432, 128, 4096},
+		{"gpu", 200, 300, 12, 512},
+		// 格式不正确的行跳过
+		{"maint", 0, 0, 0, 0},
+	}
+	for i, tt := range tests {
+		p := partitions[i]
+		if p.Name != tt.name || p.AllocCPUs != tt.alloc || p.IdleCPUs != tt.idle || p.OtherCPUs != tt.other || p.TotalCPUs != tt.total {
+			t.Errorf("partition %s cpus = %d/%d/%d/%d, want %d/%d/%d/%d", p.Name, p.AllocCPUs, p.IdleCPUs, p.OtherCPUs, p.TotalCPUs, tt.alloc, tt.idle, tt.other, tt.total)
+		}
+	}
+
+	// 提交到多个分区的排队作业在每个分区都计数
+	applyPartitionJobs(partitions, ParseSqueueOutput(readFixture(t, "squeue_pending.txt")))
+	applyPartitionGPUs(partitions, []models.NodeModel{
+		{Hostname: "gpu001", GPUTotal: 4, GPUAlloc: 3, Partitions: []string{"gpu", "maint"}},
+		{Hostname: "gpu002", GPUTotal: 4, GPUAlloc: 0, Partitions: []string{"gpu"}},
+		{Hostname: "cn001", Partitions: []string{"cpu"}},
+	})
+	usage := []struct {
+		pending, running int
+		gpus             [2]int64
+	}{
+		{4, 1, [2]int64{0, 0}},
+		{3, 0, [2]int64{8, 3}},
+		{0, 0, [2]int64{4, 3}},
+	}
+	for i, tt := range usage {
+		p := partitions[i]
+		if p.JobsPending != tt.pending || p.JobsRunning != tt.running || [2]int64{p.GPUTotal, p.GPUAlloc} != tt.gpus {
+			t.Errorf("partition %s = %d pending, %d running, %d/%d GPUs", p.Name, p.JobsPending, p.JobsRunning, p.GPUAlloc, p.GPUTotal)
+		}
+	}
+}
+
+func TestParseQOSOutput(t *testing.T) {
+	// 字段数不足的行不解析
+	want := []models.QOSModel{
+		{Name: "normal", PreemptMode: "cluster", UsageFactor: 1},
+		{Name: "gpu_normal", Priority: 100, Preempt: "scavenger", PreemptMode: "cluster", Flags: "DenyOnLimit", UsageFactor: 1.5,
+			GrpTRES: "gres/gpu=64", MaxTRES: "gres/gpu=8", MaxTRESPerUser: "gres/gpu=16", MaxJobsPerUser: "4", MaxSubmitPerUser: "20",
+			MaxWall: "2-00:00:00", MinTRES: "gres/gpu=1"},
+		{Name: "scavenger", PreemptMode: "requeue", Flags: "NoReserve"},
+	}
+	if got := ParseQOSOutput(readFixture(t, "sacctmgr_show_qos.txt")); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseQOSOutput() =\n%+v\nwant\n%+v", got, want)
+	}
+}
@@ -0,0 +1,414 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"panel-tool/internal/models"
+	"panel-tool/internal/utils"
+)
+
+// defaultChargeRatesFile 计费费率配置文件的默认路径，可通过 PANEL_CHARGE_RATES_FILE 覆盖
+const defaultChargeRatesFile = "./config/charge_rates.json"
+
+// defaultRatePartition 未单独配置费率的分区使用的键
+const defaultRatePartition = "*"
+
+// ChargeRate 分区计费费率
+type ChargeRate struct {
+	CPUHour  float64            `json:"cpu_hour"`
+	GRESHour map[string]float64 `json:"gres_hour,omitempty"` // 键为 GRES 类型，如 gpu
+}
+
+// UsageReportQuery 用量报表查询条件
+type UsageReportQuery struct {
+	// Cluster 与 Clusters 含义同 JobHistoryQuery
+	Cluster  *Cluster
+	Clusters []string
+	Start    time.Time
+	End      time.Time
+	GroupBy  string // user、account 或 partition
+	Period   string // day、week、month 或 total
+}
+
+// UsageReportRow 用量报表中的一行
+type UsageReportRow struct {
+	Period    string             `json:"period"`
+	Key       string             `json:"key"`
+	JobCount  int                `json:"job_count"`
+	CPUHours  float64            `json:"cpu_hours"`
+	GRESHours map[string]float64 `json:"gres_hours"`
+	WaitP50   float64            `json:"wait_p50"` // 秒
+	WaitP90   float64            `json:"wait_p90"`
+	WaitP99   float64            `json:"wait_p99"`
+	Charge    float64            `json:"charge"`
+	waitTimes []float64
+}
+
+// UsageReport 用量报表
+type UsageReport struct {
+	Start       time.Time        `json:"start"`
+	End         time.Time        `json:"end"`
+	GroupBy     string           `json:"group_by"`
+	Period      string           `json:"period"`
+	GeneratedAt time.Time        `json:"generated_at"`
+	Rows        []UsageReportRow `json:"rows"`
+}
+
+// ReportService 集群用量报表与计费服务
+type ReportService struct {
+	logger    *utils.Logger
+	ratesFile string
+	rates     map[string]ChargeRate
+	ratesMu   sync.RWMutex
+}
+
+// NewReportService 创建新的报表服务实例
+func NewReportService() *ReportService {
+	ratesFile := os.Getenv("PANEL_CHARGE_RATES_FILE")
+	if ratesFile == "" {
+		ratesFile = defaultChargeRatesFile
+	}
+
+	s := &ReportService{
+		logger:    utils.NewLogger(),
+		ratesFile: ratesFile,
+		rates:     map[string]ChargeRate{},
+	}
+	if err := s.loadRates(); err != nil && !os.IsNotExist(err) {
+		s.logger.Error(fmt.Sprintf("读取计费费率失败: %v", err))
+	}
+	return s
+}
+
+// loadRates 从配置文件加载费率
+func (s *ReportService) loadRates() error {
+	data, err := os.ReadFile(s.ratesFile)
+	if err != nil {
+		return err
+	}
+
+	var rates map[string]ChargeRate
+	if err := json.Unmarshal(data, &rates); err != nil {
+		return err
+	}
+
+	s.ratesMu.Lock()
+	s.rates = rates
+	s.ratesMu.Unlock()
+	return nil
+}
+
+// GetRates 获取当前计费费率
+func (s *ReportService) GetRates() map[string]ChargeRate {
+	s.ratesMu.RLock()
+	defer s.ratesMu.RUnlock()
+
+	rates := make(map[string]ChargeRate, len(s.rates))
+	for partition, rate := range s.rates {
+		rates[partition] = rate
+	}
+	return rates
+}
+
+// SetRates 更新计费费率并写入配置文件
+func (s *ReportService) SetRates(rates map[string]ChargeRate) error {
+	for partition, rate := range rates {
+		if rate.CPUHour < 0 {
+			return fmt.Errorf("分区 %s 的 CPU 费率不能为负数", partition)
+		}
+		for gres, price := range rate.GRESHour {
+			if price < 0 {
+				return fmt.Errorf("分区 %s 的 %s 费率不能为负数", partition, gres)
+			}
+		}
+	}
+
+	data, err := json.MarshalIndent(rates, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.ratesFile), 0755); err != nil {
+		return fmt.Errorf("创建配置目录失败: %v", err)
+	}
+	if err := os.WriteFile(s.ratesFile, data, 0644); err != nil {
+		return fmt.Errorf("保存计费费率失败: %v", err)
+	}
+
+	s.ratesMu.Lock()
+	s.rates = rates
+	s.ratesMu.Unlock()
+	s.logger.Info(fmt.Sprintf("计费费率已更新，共 %d 个分区", len(rates)))
+	return nil
+}
+
+// rateFor 获取分区费率，未配置时使用默认费率
+func (s *ReportService) rateFor(partition string) ChargeRate {
+	s.ratesMu.RLock()
+	defer s.ratesMu.RUnlock()
+
+	if rate, ok := s.rates[partition]; ok {
+		return rate
+	}
+	return s.rates[defaultRatePartition]
+}
+
+// GenerateUsageReport 基于 sacct 作业记录生成用量报表
+func (s *ReportService) GenerateUsageReport(q UsageReportQuery) (*UsageReport, error) {
+	switch q.GroupBy {
+	case "":
+		q.GroupBy = "user"
+	case "user", "account", "partition":
+	default:
+		return nil, fmt.Errorf("不支持的分组方式: %s", q.GroupBy)
+	}
+	switch q.Period {
+	case "":
+		q.Period = "month"
+	case "day", "week", "month", "total":
+	default:
+		return nil, fmt.Errorf("不支持的统计周期: %s", q.Period)
+	}
+
+	history := &JobHistoryQuery{Cluster: q.Cluster, Clusters: q.Clusters, Start: q.Start, End: q.End, SortBy: "submit", Order: "asc"}
+	records, err := QueryJobHistory(history)
+	if err != nil {
+		return nil, err
+	}
+	// 使用补全默认值后的时间范围
+	q.Start, q.End = history.Start, history.End
+
+	return &UsageReport{
+		Start:       q.Start,
+		End:         q.End,
+		GroupBy:     q.GroupBy,
+		Period:      q.Period,
+		GeneratedAt: time.Now(),
+		Rows:        s.aggregateUsage(records, q),
+	}, nil
+}
+
+// aggregateUsage 按周期和分组汇总作业用量
+// 作业的运行时间截取到查询范围内，并按与各周期重叠的时长拆分；作业数和等待时间计入截取后的第一个周期，
+// 开始于查询范围之前的作业不计等待时间（已计入更早的报表）
+func (s *ReportService) aggregateUsage(records []models.JobAccountingRecord, q UsageReportQuery) []UsageReportRow {
+	rows := make(map[string]*UsageReportRow)
+	var order []string
+	rowFor := func(period, key string) *UsageReportRow {
+		id := period + "\x00" + key
+		row, ok := rows[id]
+		if !ok {
+			row = &UsageReportRow{Period: period, Key: key, GRESHours: map[string]float64{}}
+			rows[id] = row
+			order = append(order, id)
+		}
+		return row
+	}
+
+	for _, record := range records {
+		// 从未开始运行的作业不产生用量
+		if record.Start.IsZero() {
+			continue
+		}
+
+		from := record.Start
+		to := from.Add(time.Duration(record.Elapsed) * time.Second)
+		if from.Before(q.Start) {
+			from = q.Start
+		}
+		if to.After(q.End) {
+			to = q.End
+		}
+		// 与查询范围没有重叠；运行时间为 0 的作业仍计入开始时间所在的周期
+		if to.Before(from) || (to.Equal(from) && !from.Equal(record.Start)) {
+			continue
+		}
+
+		key := reportGroupKey(record, q.GroupBy)
+		rate := s.rateFor(record.Partition)
+		gres := jobGRESCounts(record.AllocTRES)
+		for segment := from; ; {
+			segmentEnd := to
+			if next := nextReportPeriod(segment, q.Period); !next.IsZero() && next.Before(to) {
+				segmentEnd = next
+			}
+
+			row := rowFor(reportPeriodLabel(segment, q.Period), key)
+			if segment.Equal(from) {
+				row.JobCount++
+				if !record.Submit.IsZero() && !record.Start.Before(q.Start) {
+					row.waitTimes = append(row.waitTimes, record.Start.Sub(record.Submit).Seconds())
+				}
+			}
+
+			hours := segmentEnd.Sub(segment).Hours()
+			cpuHours := float64(record.AllocCPUs) * hours
+			row.CPUHours += cpuHours
+			row.Charge += cpuHours * rate.CPUHour
+			for name, count := range gres {
+				gresHours := count * hours
+				row.GRESHours[name] += gresHours
+				row.Charge += gresHours * rate.GRESHour[name]
+			}
+
+			if !segmentEnd.Before(to) {
+				break
+			}
+			segment = segmentEnd
+		}
+	}
+
+	result := make([]UsageReportRow, 0, len(order))
+	for _, id := range order {
+		row := rows[id]
+		sort.Float64s(row.waitTimes)
+		row.WaitP50 = percentile(row.waitTimes, 50)
+		row.WaitP90 = percentile(row.waitTimes, 90)
+		row.WaitP99 = percentile(row.waitTimes, 99)
+		row.CPUHours = roundTo(row.CPUHours, 2)
+		row.Charge = roundTo(row.Charge, 2)
+		for name, hours := range row.GRESHours {
+			row.GRESHours[name] = roundTo(hours, 2)
+		}
+		result = append(result, *row)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Period != result[j].Period {
+			return result[i].Period < result[j].Period
+		}
+		return result[i].CPUHours > result[j].CPUHours
+	})
+	return result
+}
+
+// jobGRESCounts 从 AllocTRES 中提取各 GRES 类型的数量，忽略带型号的重复项（如 gres/gpu:a100）
+func jobGRESCounts(allocTRES string) map[string]float64 {
+	counts := make(map[string]float64)
+	for key, value := range parseTRES(allocTRES) {
+		name, ok := strings.CutPrefix(key, "gres/")
+		if !ok || strings.Contains(name, ":") {
+			continue
+		}
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			counts[name] = n
+		}
+	}
+	return counts
+}
+
+// reportPeriodLabel 计算时间所在的统计周期标签
+func reportPeriodLabel(t time.Time, period string) string {
+	switch period {
+	case "day":
+		return t.Format("2006-01-02")
+	case "week":
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case "month":
+		return t.Format("2006-01")
+	}
+	return "total"
+}
+
+// nextReportPeriod 返回 t 所在统计周期的结束时间（即下一个周期的起点），total 没有边界，返回零值
+func nextReportPeriod(t time.Time, period string) time.Time {
+	year, month, day := t.Date()
+	switch period {
+	case "day":
+		return time.Date(year, month, day+1, 0, 0, 0, 0, t.Location())
+	case "week":
+		// ISO 周从周一开始
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(year, month, day-offset+7, 0, 0, 0, 0, t.Location())
+	case "month":
+		return time.Date(year, month+1, 1, 0, 0, 0, 0, t.Location())
+	}
+	return time.Time{}
+}
+
+// reportGroupKey 获取作业在指定分组方式下的键
+func reportGroupKey(record models.JobAccountingRecord, groupBy string) string {
+	switch groupBy {
+	case "account":
+		return record.Account
+	case "partition":
+		return record.Partition
+	}
+	return record.User
+}
+
+// percentile 计算已排序数据的百分位数（最近秩法）
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// roundTo 四舍五入到指定小数位
+func roundTo(value float64, digits int) float64 {
+	scale := math.Pow(10, float64(digits))
+	return math.Round(value*scale) / scale
+}
+
+// WriteUsageReportCSV 将用量报表导出为 CSV，GRES 类型展开为独立列
+func WriteUsageReportCSV(w io.Writer, report *UsageReport) error {
+	gresSet := make(map[string]bool)
+	for _, row := range report.Rows {
+		for name := range row.GRESHours {
+			gresSet[name] = true
+		}
+	}
+	gresNames := make([]string, 0, len(gresSet))
+	for name := range gresSet {
+		gresNames = append(gresNames, name)
+	}
+	sort.Strings(gresNames)
+
+	writer := csv.NewWriter(w)
+	header := []string{"period", report.GroupBy, "job_count", "cpu_hours"}
+	for _, name := range gresNames {
+		header = append(header, name+"_hours")
+	}
+	header = append(header, "wait_p50_seconds", "wait_p90_seconds", "wait_p99_seconds", "charge")
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, row := range report.Rows {
+		line := []string{
+			row.Period, row.Key,
+			strconv.Itoa(row.JobCount),
+			strconv.FormatFloat(row.CPUHours, 'f', 2, 64),
+		}
+		for _, name := range gresNames {
+			line = append(line, strconv.FormatFloat(row.GRESHours[name], 'f', 2, 64))
+		}
+		line = append(line,
+			strconv.FormatFloat(row.WaitP50, 'f', 0, 64),
+			strconv.FormatFloat(row.WaitP90, 'f', 0, 64),
+			strconv.FormatFloat(row.WaitP99, 'f', 0, 64),
+			strconv.FormatFloat(row.Charge, 'f', 2, 64),
+		)
+		if err := writer.Write(line); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,311 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"panel-tool/internal/hostlist"
+	"panel-tool/internal/models"
+)
+
+// reservationFlags 允许通过面板设置的预约标志
+var reservationFlags = map[string]bool{
+	"MAINT":                  true,
+	"IGNORE_JOBS":            true,
+	"OVERLAP":                true,
+	"FLEX":                   true,
+	"DAILY":                  true,
+	"WEEKLY":                 true,
+	"WEEKDAY":                true,
+	"WEEKEND":                true,
+	"ANY_NODES":              true,
+	"PART_NODES":             true,
+	"STATIC_ALLOC":           true,
+	"REPLACE":                true,
+	"REPLACE_DOWN":           true,
+	"NO_HOLD_JOBS_AFTER_END": true,
+	"PURGE_COMP":             true,
+	"TIME_FLOAT":             true,
+	"MAGNETIC":               true,
+}
+
+// nameListPattern 逗号分隔的用户/账户列表，允许 - 前缀表示排除
+var nameListPattern = regexp.MustCompile(`^-?[A-Za-z0-9_][A-Za-z0-9_.\-]*(,-?[A-Za-z0-9_][A-Za-z0-9_.\-]*)*$`)
+
+// ReservationRequest 创建或更新预约的请求参数，空字段表示不设置（更新时保持原值）
+type ReservationRequest struct {
+	Name      string   `json:"name"`
+	StartTime string   `json:"start_time"` // now 或 2006-01-02T15:04:05
+	Duration  string   `json:"duration"`   // 分钟数或 [DD-]HH:MM:SS
+	Nodes     string   `json:"nodes"`      // 主机列表表达式或 ALL
+	NodeCount int      `json:"node_count"`
+	Partition string   `json:"partition"`
+	Users     string   `json:"users"`
+	Accounts  string   `json:"accounts"`
+	Flags     []string `json:"flags"`
+}
+
+// ReservationConflict 与预约时间窗口冲突的运行中作业
+type ReservationConflict struct {
+	JobID      string    `json:"job_id"`
+	User       string    `json:"user"`
+	NodeList   string    `json:"node_list"`
+	EndTime    time.Time `json:"end_time"`
+	Overlapped []string  `json:"overlapped_nodes,omitempty"`
+}
+
+// GetReservations 获取所有预约
+func GetReservations(cluster *Cluster) ([]models.ReservationModel, error) {
+	output, err := runSlurmCommand(cluster, "scontrol", "show", "reservation")
+	if err != nil {
+		return nil, err
+	}
+	return ParseReservationRecords(output), nil
+}
+
+// ParseReservationRecords 解析 scontrol show reservation 的输出
+func ParseReservationRecords(output string) []models.ReservationModel {
+	reservations := []models.ReservationModel{}
+	for _, record := range ParseScontrolRecords(output) {
+		if record["ReservationName"] == "" {
+			continue
+		}
+		reservations = append(reservations, models.ReservationModel{
+			Name:      record["ReservationName"],
+			State:     record["State"],
+			StartTime: parseSlurmTime(record["StartTime"]),
+			EndTime:   parseSlurmTime(record["EndTime"]),
+			Duration:  record["Duration"],
+			Nodes:     cleanSlurmValue(record["Nodes"]),
+			NodeCount: parseSlurmInt(record["NodeCnt"]),
+			CoreCount: parseSlurmInt(record["CoreCnt"]),
+			Partition: cleanSlurmValue(record["PartitionName"]),
+			Features:  cleanSlurmValue(record["Features"]),
+			Flags:     splitSlurmList(record["Flags"]),
+			Users:     splitSlurmList(record["Users"]),
+			Accounts:  splitSlurmList(record["Accounts"]),
+		})
+	}
+	return reservations
+}
+
+// CreateReservation 创建预约，返回 Slurm 分配的预约名
+func CreateReservation(cluster *Cluster, req ReservationRequest) (string, error) {
+	if req.StartTime == "" || req.Duration == "" {
+		return "", fmt.Errorf("必须指定开始时间和持续时间")
+	}
+	if req.Nodes == "" && req.NodeCount <= 0 {
+		return "", fmt.Errorf("必须指定节点列表或节点数量")
+	}
+	if req.Users == "" && req.Accounts == "" {
+		return "", fmt.Errorf("必须指定允许使用预约的用户或账户")
+	}
+
+	args, err := reservationArgs(req)
+	if err != nil {
+		return "", err
+	}
+	output, err := runSlurmCommand(cluster, "scontrol", append([]string{"create", "reservation"}, args...)...)
+	if err != nil {
+		return "", err
+	}
+
+	// 输出形如 "Reservation created: maint_1"
+	name := req.Name
+	if _, created, ok := strings.Cut(strings.TrimSpace(output), ":"); ok {
+		name = strings.TrimSpace(created)
+	}
+	return name, nil
+}
+
+// UpdateReservation 更新预约，只修改请求中给出的字段
+func UpdateReservation(cluster *Cluster, name string, req ReservationRequest) error {
+	req.Name = name
+	args, err := reservationArgs(req)
+	if err != nil {
+		return err
+	}
+	if len(args) == 1 {
+		return fmt.Errorf("未指定任何需要修改的字段")
+	}
+	_, err = runSlurmCommand(cluster, "scontrol", append([]string{"update", "reservation"}, args...)...)
+	return err
+}
+
+// DeleteReservation 删除预约
+func DeleteReservation(cluster *Cluster, name string) error {
+	if !assocNamePattern.MatchString(name) {
+		return fmt.Errorf("无效的预约名: %q", name)
+	}
+	_, err := runSlurmCommand(cluster, "scontrol", "delete", "ReservationName="+name)
+	return err
+}
+
+// reservationArgs 校验请求并生成 scontrol 参数
+func reservationArgs(req ReservationRequest) ([]string, error) {
+	var args []string
+	if req.Name != "" {
+		if !assocNamePattern.MatchString(req.Name) {
+			return nil, fmt.Errorf("无效的预约名: %q", req.Name)
+		}
+		args = append(args, "ReservationName="+req.Name)
+	}
+
+	if req.StartTime != "" {
+		start, err := reservationStart(req.StartTime)
+		if err != nil {
+			return nil, err
+		}
+		if start.IsZero() {
+			args = append(args, "StartTime=now")
+		} else {
+			args = append(args, "StartTime="+start.Format(slurmTimeLayout))
+		}
+	}
+	if req.Duration != "" {
+		minutes, err := reservationMinutes(req.Duration)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "Duration="+strconv.Itoa(minutes))
+	}
+
+	if req.Nodes != "" {
+		if strings.EqualFold(req.Nodes, "ALL") {
+			args = append(args, "Nodes=ALL")
+		} else {
+			expr, err := normalizeHostlist(req.Nodes)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, "Nodes="+expr)
+		}
+	} else if req.NodeCount > 0 {
+		args = append(args, "NodeCnt="+strconv.Itoa(req.NodeCount))
+	}
+
+	if req.Partition != "" {
+		if !assocNamePattern.MatchString(req.Partition) {
+			return nil, fmt.Errorf("无效的分区名: %q", req.Partition)
+		}
+		args = append(args, "PartitionName="+req.Partition)
+	}
+	if req.Users != "" {
+		if !nameListPattern.MatchString(req.Users) {
+			return nil, fmt.Errorf("无效的用户列表: %q", req.Users)
+		}
+		args = append(args, "Users="+req.Users)
+	}
+	if req.Accounts != "" {
+		if !nameListPattern.MatchString(req.Accounts) {
+			return nil, fmt.Errorf("无效的账户列表: %q", req.Accounts)
+		}
+		args = append(args, "Accounts="+req.Accounts)
+	}
+
+	if len(req.Flags) > 0 {
+		flags := make([]string, 0, len(req.Flags))
+		for _, flag := range req.Flags {
+			flag = strings.ToUpper(strings.TrimSpace(flag))
+			if !reservationFlags[flag] {
+				return nil, fmt.Errorf("不支持的预约标志: %s", flag)
+			}
+			flags = append(flags, flag)
+		}
+		args = append(args, "Flags="+strings.Join(flags, ","))
+	}
+	return args, nil
+}
+
+// reservationStart 解析预约开始时间，now 返回零值
+func reservationStart(value string) (time.Time, error) {
+	if strings.EqualFold(value, "now") {
+		return time.Time{}, nil
+	}
+	for _, layout := range []string{slurmTimeLayout, "2006-01-02 15:04:05", "2006-01-02T15:04"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.Local(), nil
+	}
+	return time.Time{}, fmt.Errorf("无效的开始时间: %q", value)
+}
+
+// reservationMinutes 将持续时间转换为分钟数
+func reservationMinutes(value string) (int, error) {
+	seconds, err := parseSlurmDuration(value)
+	if err != nil || seconds <= 0 {
+		return 0, fmt.Errorf("无效的持续时间: %q", value)
+	}
+	return int((seconds + 59) / 60), nil
+}
+
+// FindReservationConflicts 找出在预约时间窗口内仍会运行、且占用预约节点的作业
+// 未指定节点列表（按数量预约或 ALL）时，窗口内仍在运行的所有作业都视为可能冲突
+func FindReservationConflicts(cluster *Cluster, req ReservationRequest) ([]ReservationConflict, error) {
+	start, err := reservationStart(req.StartTime)
+	if err != nil {
+		return nil, err
+	}
+	if start.IsZero() {
+		start = time.Now()
+	}
+
+	var reserved map[string]bool
+	if req.Nodes != "" && !strings.EqualFold(req.Nodes, "ALL") {
+		names, err := hostlist.Expand(req.Nodes)
+		if err != nil {
+			return nil, fmt.Errorf("无效的节点列表: %v", err)
+		}
+		reserved = make(map[string]bool, len(names))
+		for _, name := range names {
+			reserved[name] = true
+		}
+	}
+
+	output, err := runSlurmCommand(cluster, "squeue", "--noheader", "--states=RUNNING", "--format=%i|%u|%N|%e")
+	if err != nil {
+		return nil, err
+	}
+	return findConflicts(output, start, reserved), nil
+}
+
+// findConflicts 根据 squeue %i|%u|%N|%e 输出计算冲突作业，reserved 为 nil 表示不限节点
+func findConflicts(output string, start time.Time, reserved map[string]bool) []ReservationConflict {
+	conflicts := []ReservationConflict{}
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		parts := strings.Split(line, "|")
+		if len(parts) != 4 {
+			continue
+		}
+
+		// 预计结束时间未知（无时限）的作业一律视为会跨越预约开始时间
+		end := parseSlurmTime(parts[3])
+		if !end.IsZero() && !end.After(start) {
+			continue
+		}
+
+		conflict := ReservationConflict{JobID: parts[0], User: parts[1], NodeList: parts[2], EndTime: end}
+		if reserved != nil {
+			nodes, err := hostlist.Expand(parts[2])
+			if err != nil {
+				continue
+			}
+			for _, node := range nodes {
+				if reserved[node] {
+					conflict.Overlapped = append(conflict.Overlapped, node)
+				}
+			}
+			if len(conflict.Overlapped) == 0 {
+				continue
+			}
+		}
+		conflicts = append(conflicts, conflict)
+	}
+	return conflicts
+}
@@ -0,0 +1,416 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"panel-tool/internal/models"
+	"panel-tool/internal/utils"
+)
+
+// 调度器诊断默认参数，可通过 PANEL_SDIAG_INTERVAL（秒）和 PANEL_RPC_RATE_LIMIT（次/秒）覆盖
+const (
+	defaultSdiagInterval = 60 * time.Second
+	defaultRPCRateLimit  = 20.0
+	sdiagHistorySize     = 120
+)
+
+// rpcExemptUsers 不参与 RPC 滥用检测的用户（slurmd、slurmdbd 等守护进程以这些身份发送 RPC）
+var rpcExemptUsers = map[string]bool{
+	"root":  true,
+	"slurm": true,
+}
+
+// rpcStatPattern 匹配 sdiag 中的 RPC 统计行，如 "REQUEST_JOB_INFO ( 2003) count:10 ave_time:200 total_time:2000"
+var rpcStatPattern = regexp.MustCompile(`^(\S+)\s*\(\s*(\d+)\)\s+count:(\d+)(?:\s+ave_time:(\d+)\s+total_time:(\d+))?`)
+
+// sdiagTimestampPattern 匹配 sdiag 时间行末尾括号中的 Unix 时间戳
+var sdiagTimestampPattern = regexp.MustCompile(`\((\d+)\)\s*$`)
+
+// SchedulerDiagService 定时采集 sdiag，保留短期历史并检测频繁调用 slurmctld 的用户
+type SchedulerDiagService struct {
+	cluster   *Cluster
+	logger    *utils.Logger
+	interval  time.Duration
+	rateLimit float64
+
+	sampleMutex sync.Mutex
+
+	mutex   sync.RWMutex
+	latest  *models.SchedulerDiag
+	alerts  []models.RPCUserAlert
+	history []models.SchedulerDiagPoint
+
+	startOnce sync.Once
+	stop      chan struct{}
+}
+
+// NewSchedulerDiagService 创建新的调度器诊断服务实例
+func NewSchedulerDiagService(cluster *Cluster) *SchedulerDiagService {
+	interval := defaultSdiagInterval
+	if value := os.Getenv("PANEL_SDIAG_INTERVAL"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+			interval = time.Duration(seconds) * time.Second
+		}
+	}
+	rateLimit := defaultRPCRateLimit
+	if value := os.Getenv("PANEL_RPC_RATE_LIMIT"); value != "" {
+		if limit, err := strconv.ParseFloat(value, 64); err == nil && limit > 0 {
+			rateLimit = limit
+		}
+	}
+
+	return &SchedulerDiagService{
+		cluster:   cluster,
+		logger:    utils.NewLogger(),
+		interval:  interval,
+		rateLimit: rateLimit,
+		alerts:    []models.RPCUserAlert{},
+		history:   []models.SchedulerDiagPoint{},
+		stop:      make(chan struct{}),
+	}
+}
+
+// Start 启动后台采集，重复调用无效
+func (s *SchedulerDiagService) Start() {
+	s.startOnce.Do(func() {
+		go s.run()
+	})
+}
+
+// Stop 停止后台采集
+func (s *SchedulerDiagService) Stop() {
+	close(s.stop)
+}
+
+// run 采集循环，Slurm 未安装或 sdiag 失败时只记录日志
+func (s *SchedulerDiagService) run() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.Sample(); err != nil {
+			s.logger.Error(fmt.Sprintf("采集集群 %s 的 sdiag 失败: %v", s.cluster.ClusterName(), err))
+		}
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sample 立即执行一次 sdiag，更新历史和 RPC 告警
+func (s *SchedulerDiagService) Sample() (*models.SchedulerDiag, error) {
+	s.sampleMutex.Lock()
+	defer s.sampleMutex.Unlock()
+
+	output, err := runSlurmCommand(s.cluster, "sdiag")
+	if err != nil {
+		return nil, err
+	}
+	diag := ParseSdiagOutput(output)
+	if diag.Time.IsZero() {
+		diag.Time = time.Now()
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	previous := s.latest
+	s.latest = diag
+	s.alerts = DetectRPCAbuse(previous, diag, s.rateLimit)
+	s.history = append(s.history, diagPoint(previous, diag))
+	if len(s.history) > sdiagHistorySize {
+		s.history = s.history[len(s.history)-sdiagHistorySize:]
+	}
+	return diag, nil
+}
+
+// Latest 返回最新的诊断数据和 RPC 告警，数据过期时重新采集
+func (s *SchedulerDiagService) Latest() (*models.SchedulerDiag, []models.RPCUserAlert, error) {
+	s.mutex.RLock()
+	latest, alerts := s.latest, s.alerts
+	s.mutex.RUnlock()
+
+	if latest == nil || time.Since(latest.Time) > s.interval {
+		if _, err := s.Sample(); err != nil {
+			return nil, nil, err
+		}
+		s.mutex.RLock()
+		latest, alerts = s.latest, s.alerts
+		s.mutex.RUnlock()
+	}
+	return latest, alerts, nil
+}
+
+// History 返回历史趋势点（按时间升序）
+func (s *SchedulerDiagService) History() []models.SchedulerDiagPoint {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	history := make([]models.SchedulerDiagPoint, len(s.history))
+	copy(history, s.history)
+	return history
+}
+
+// diagPoint 由诊断数据生成趋势点，RPC 速率按与上一次采样的差值计算
+func diagPoint(previous, current *models.SchedulerDiag) models.SchedulerDiagPoint {
+	point := models.SchedulerDiagPoint{
+		Time:              current.Time,
+		MainLastCycle:     current.MainSchedule.LastCycle,
+		BackfillLastCycle: current.Backfill.LastCycle,
+		JobsPending:       current.JobsPending,
+		JobsRunning:       current.JobsRunning,
+		AgentQueueSize:    current.AgentQueueSize,
+		ServerThreadCount: current.ServerThreadCount,
+	}
+
+	total := sumRPCCount(current.RPCByType)
+	var previousTotal int64
+	var previousTime time.Time
+	if previous != nil && previous.DataSince.Equal(current.DataSince) {
+		previousTotal = sumRPCCount(previous.RPCByType)
+		previousTime = previous.Time
+	} else {
+		// 计数器已重置（每日零点或 sdiag --reset），从统计起点开始计算
+		previousTime = current.DataSince
+	}
+	if elapsed := current.Time.Sub(previousTime).Seconds(); elapsed >= 1 && total >= previousTotal {
+		point.RPCRate = roundTo(float64(total-previousTotal)/elapsed, 2)
+	}
+	return point
+}
+
+// sumRPCCount 汇总 RPC 次数
+func sumRPCCount(stats []models.RPCStat) int64 {
+	var total int64
+	for _, stat := range stats {
+		total += stat.Count
+	}
+	return total
+}
+
+// DetectRPCAbuse 找出 RPC 速率超过阈值的用户
+// 有上一次采样且计数器未重置时按两次采样的差值计算速率，否则按统计起点以来的平均速率计算
+func DetectRPCAbuse(previous, current *models.SchedulerDiag, rateLimit float64) []models.RPCUserAlert {
+	alerts := []models.RPCUserAlert{}
+
+	previousCounts := make(map[string]int64)
+	since := current.DataSince
+	if previous != nil && previous.DataSince.Equal(current.DataSince) {
+		for _, stat := range previous.RPCByUser {
+			previousCounts[stat.Name] = stat.Count
+		}
+		since = previous.Time
+	}
+	elapsed := current.Time.Sub(since).Seconds()
+	if elapsed < 1 {
+		return alerts
+	}
+
+	deltas := make(map[string]int64, len(current.RPCByUser))
+	var total int64
+	for _, stat := range current.RPCByUser {
+		delta := stat.Count - previousCounts[stat.Name]
+		if delta < 0 {
+			delta = stat.Count
+		}
+		deltas[stat.Name] = delta
+		total += delta
+	}
+
+	for _, stat := range current.RPCByUser {
+		if rpcExemptUsers[stat.Name] || stat.ID == 0 {
+			continue
+		}
+		delta := deltas[stat.Name]
+		rate := float64(delta) / elapsed
+		share := 0.0
+		if total > 0 {
+			share = float64(delta) / float64(total) * 100
+		}
+
+		var reason string
+		switch {
+		case rate > rateLimit:
+			reason = fmt.Sprintf("RPC 速率 %.1f 次/秒，超过阈值 %.1f 次/秒", rate, rateLimit)
+		case share > 50 && rate > rateLimit/4:
+			reason = fmt.Sprintf("占全部用户 RPC 的 %.0f%%，速率 %.1f 次/秒", share, rate)
+		default:
+			continue
+		}
+		alerts = append(alerts, models.RPCUserAlert{
+			User:   stat.Name,
+			UID:    stat.ID,
+			Count:  delta,
+			Rate:   roundTo(rate, 2),
+			Share:  roundTo(share, 1),
+			Reason: reason,
+		})
+	}
+
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].Rate > alerts[j].Rate })
+	return alerts
+}
+
+// ParseSdiagOutput 解析 sdiag 的输出
+func ParseSdiagOutput(output string) *models.SchedulerDiag {
+	diag := &models.SchedulerDiag{
+		RPCByType: []models.RPCStat{},
+		RPCByUser: []models.RPCStat{},
+	}
+
+	section := ""
+	for _, raw := range strings.Split(output, "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" || strings.HasPrefix(line, "***") {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "sdiag output at"):
+			diag.Time = parseSdiagTimestamp(line)
+			continue
+		case strings.HasPrefix(line, "Data since"):
+			diag.DataSince = parseSdiagTimestamp(line)
+			continue
+		case strings.HasPrefix(line, "Main schedule statistics"):
+			section = "main"
+			continue
+		case strings.HasPrefix(line, "Backfilling stats"):
+			section = "backfill"
+			continue
+		case strings.HasPrefix(line, "Remote Procedure Call statistics by message type"):
+			section = "rpc_type"
+			continue
+		case strings.HasPrefix(line, "Remote Procedure Call statistics by user"):
+			section = "rpc_user"
+			continue
+		case strings.HasPrefix(line, "Pending RPC statistics"):
+			section = "rpc_pending"
+			continue
+		}
+
+		switch section {
+		case "rpc_type", "rpc_user", "rpc_pending":
+			if stat, ok := parseRPCStatLine(line); ok {
+				switch section {
+				case "rpc_type":
+					diag.RPCByType = append(diag.RPCByType, stat)
+				case "rpc_user":
+					diag.RPCByUser = append(diag.RPCByUser, stat)
+				default:
+					diag.PendingRPCs += stat.Count
+				}
+				continue
+			}
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		number := parseSlurmInt(strings.TrimSpace(value))
+
+		switch section {
+		case "main":
+			applyCycleStat(&diag.MainSchedule, key, number)
+		case "backfill":
+			if strings.HasPrefix(key, "Total backfilled jobs (since last slurm start)") {
+				diag.TotalBackfilledJobs = number
+				continue
+			}
+			applyCycleStat(&diag.Backfill, key, number)
+		default:
+			applyGeneralStat(diag, key, number)
+		}
+	}
+	return diag
+}
+
+// parseRPCStatLine 解析一行 RPC 统计
+func parseRPCStatLine(line string) (models.RPCStat, bool) {
+	match := rpcStatPattern.FindStringSubmatch(line)
+	if match == nil {
+		return models.RPCStat{}, false
+	}
+	return models.RPCStat{
+		Name:      match[1],
+		ID:        parseSlurmInt(match[2]),
+		Count:     parseSlurmInt(match[3]),
+		AveTime:   parseSlurmInt(match[4]),
+		TotalTime: parseSlurmInt(match[5]),
+	}, true
+}
+
+// parseSdiagTimestamp 读取时间行末尾括号中的 Unix 时间戳
+func parseSdiagTimestamp(line string) time.Time {
+	match := sdiagTimestampPattern.FindStringSubmatch(line)
+	if match == nil {
+		return time.Time{}
+	}
+	seconds, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0)
+}
+
+// applyGeneralStat 设置 sdiag 开头部分的统计项
+func applyGeneralStat(diag *models.SchedulerDiag, key string, value int64) {
+	switch key {
+	case "Server thread count":
+		diag.ServerThreadCount = value
+	case "Agent queue size":
+		diag.AgentQueueSize = value
+	case "Agent count":
+		diag.AgentCount = value
+	case "Agent thread count":
+		diag.AgentThreadCount = value
+	case "DBD Agent queue size":
+		diag.DBDAgentQueueSize = value
+	case "Jobs submitted":
+		diag.JobsSubmitted = value
+	case "Jobs started":
+		diag.JobsStarted = value
+	case "Jobs completed":
+		diag.JobsCompleted = value
+	case "Jobs canceled":
+		diag.JobsCanceled = value
+	case "Jobs failed":
+		diag.JobsFailed = value
+	case "Jobs pending":
+		diag.JobsPending = value
+	case "Jobs running":
+		diag.JobsRunning = value
+	}
+}
+
+// applyCycleStat 设置主调度或回填调度的周期统计项
+func applyCycleStat(stats *models.SchedulerCycleStats, key string, value int64) {
+	switch key {
+	case "Total cycles":
+		stats.TotalCycles = value
+	case "Last cycle":
+		stats.LastCycle = value
+	case "Max cycle":
+		stats.MaxCycle = value
+	case "Mean cycle":
+		stats.MeanCycle = value
+	case "Mean depth cycle":
+		stats.MeanDepthCycle = value
+	case "Last depth cycle":
+		stats.LastDepthCycle = value
+	case "Cycles per minute":
+		stats.CyclesPerMinute = value
+	case "Last queue length":
+		stats.LastQueueLength = value
+	}
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"panel-tool/internal/models"
+)
+
+// fixtureDiags 解析相隔 60 秒的两次 sdiag 输出
+func fixtureDiags(t *testing.T) (*models.SchedulerDiag, *models.SchedulerDiag) {
+	t.Helper()
+	return ParseSdiagOutput(readFixture(t, "sdiag.txt")), ParseSdiagOutput(readFixture(t, "sdiag_later.txt"))
+}
+
+func TestParseSdiagOutput(t *testing.T) {
+	diag := ParseSdiagOutput(readFixture(t, "sdiag.txt"))
+
+	if !diag.Time.Equal(time.Unix(1709632800, 0)) || !diag.DataSince.Equal(time.Unix(1709596800, 0)) {
+		t.Errorf("Time = %v, DataSince = %v", diag.Time, diag.DataSince)
+	}
+	if diag.ServerThreadCount != 3 || diag.AgentQueueSize != 0 || diag.DBDAgentQueueSize != 0 {
+		t.Errorf("threads = %+v", diag)
+	}
+	if diag.JobsSubmitted != 1520 || diag.JobsStarted != 1432 || diag.JobsCompleted != 1380 ||
+		diag.JobsCanceled != 25 || diag.JobsFailed != 5 || diag.JobsPending != 88 || diag.JobsRunning != 52 {
+		t.Errorf("jobs = %+v", diag)
+	}
+
+	wantMain := models.SchedulerCycleStats{
+		TotalCycles: 1523, LastCycle: 1846, MaxCycle: 98213, MeanCycle: 2145,
+		MeanDepthCycle: 47, CyclesPerMinute: 1, LastQueueLength: 88,
+	}
+	if diag.MainSchedule != wantMain {
+		t.Errorf("MainSchedule = %+v, want %+v", diag.MainSchedule, wantMain)
+	}
+	// “Last cycle when” 与 “Total backfilled jobs (since last stats cycle start)” 不能覆盖同名前缀的统计项
+	wantBackfill := models.SchedulerCycleStats{
+		TotalCycles: 285, LastCycle: 120455, MaxCycle: 2304112, MeanCycle: 98231,
+		LastDepthCycle: 88, LastQueueLength: 88,
+	}
+	if diag.Backfill != wantBackfill || diag.TotalBackfilledJobs != 412 {
+		t.Errorf("Backfill = %+v, total %d, want %+v, total 412", diag.Backfill, diag.TotalBackfilledJobs, wantBackfill)
+	}
+
+	if len(diag.RPCByType) != 5 {
+		t.Fatalf("RPCByType = %+v", diag.RPCByType)
+	}
+	if want := (models.RPCStat{Name: "REQUEST_JOB_INFO", ID: 2003, Count: 25000, AveTime: 1250, TotalTime: 38775000}); !reflect.DeepEqual(diag.RPCByType[1], want) {
+		t.Errorf("RPCByType[1] = %+v, want %+v", diag.RPCByType[1], want)
+	}
+	if len(diag.RPCByUser) != 4 {
+		t.Fatalf("RPCByUser = %+v", diag.RPCByUser)
+	}
+	if want := (models.RPCStat{Name: "alice", ID: 1001, Count: 28000, AveTime: 1100, TotalTime: 30800000}); !reflect.DeepEqual(diag.RPCByUser[1], want) {
+		t.Errorf("RPCByUser[1] = %+v, want %+v", diag.RPCByUser[1], want)
+	}
+	// Pending RPCs 列表中的逐条记录不重复计数
+	if diag.PendingRPCs != 4 {
+		t.Errorf("PendingRPCs = %d, want 4", diag.PendingRPCs)
+	}
+
+	if empty := ParseSdiagOutput(""); !empty.Time.IsZero() || len(empty.RPCByType) != 0 || empty.PendingRPCs != 0 {
+		t.Errorf("ParseSdiagOutput(\"\") = %+v", empty)
+	}
+}
+
+func TestDiagPoint(t *testing.T) {
+	previous, current := fixtureDiags(t)
+	reset := *previous
+	reset.DataSince = previous.DataSince.Add(-24 * time.Hour)
+
+	tests := []struct {
+		name     string
+		previous *models.SchedulerDiag
+		rate     float64
+	}{
+		// 60 秒内 RPC 总数增加 2500
+		{"interval", previous, 41.67},
+		// 没有上一次采样或计数器已重置时，按统计起点以来的平均速率计算
+		{"first sample", nil, 2.02},
+		{"counters reset", &reset, 2.02},
+		{"same sample", current, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			point := diagPoint(tt.previous, current)
+			if point.RPCRate != tt.rate {
+				t.Errorf("RPCRate = %v, want %v", point.RPCRate, tt.rate)
+			}
+			if point.MainLastCycle != 2210 || point.BackfillLastCycle != 131002 || point.JobsPending != 95 ||
+				point.AgentQueueSize != 4 || point.ServerThreadCount != 7 {
+				t.Errorf("diagPoint() = %+v", point)
+			}
+		})
+	}
+}
+
+func TestDetectRPCAbuse(t *testing.T) {
+	previous, current := fixtureDiags(t)
+
+	tests := []struct {
+		name      string
+		previous  *models.SchedulerDiag
+		rateLimit float64
+		want      []models.RPCUserAlert
+	}{
+		// 60 秒内 alice 1500 次、bob 300 次，root 与 slurm 不参与判断但计入总数
+		{"rate over limit", previous, 20, []models.RPCUserAlert{
+			{User: "alice", UID: 1001, Count: 1500, Rate: 25, Share: 60, Reason: "RPC 速率 25.0 次/秒，超过阈值 20.0 次/秒"},
+		}},
+		{"dominant share", previous, 40, []models.RPCUserAlert{
+			{User: "alice", UID: 1001, Count: 1500, Rate: 25, Share: 60, Reason: "占全部用户 RPC 的 60%，速率 25.0 次/秒"},
+		}},
+		{"under limit", previous, 120, []models.RPCUserAlert{}},
+		// 按统计起点以来的平均速率，结果按速率降序
+		{"first sample", nil, 0.05, []models.RPCUserAlert{
+			{User: "alice", UID: 1001, Count: 29500, Rate: 0.82, Share: 40.4, Reason: "RPC 速率 0.8 次/秒，超过阈值 0.1 次/秒"},
+			{User: "bob", UID: 1002, Count: 3300, Rate: 0.09, Share: 4.5, Reason: "RPC 速率 0.1 次/秒，超过阈值 0.1 次/秒"},
+		}},
+		{"same sample", current, 1, []models.RPCUserAlert{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectRPCAbuse(tt.previous, current, tt.rateLimit); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DetectRPCAbuse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
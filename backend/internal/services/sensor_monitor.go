@@ -0,0 +1,218 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"panel-tool/internal/models"
+	"panel-tool/internal/utils"
+)
+
+// 传感器监控参数
+const (
+	// sensorAlertLimit 保留的已恢复告警数量
+	sensorAlertLimit = 500
+	// sensorStaleAfter 超过该时间未更新的节点读数标记为过期，其未恢复的告警随之结束
+	sensorStaleAfter = 2 * time.Minute
+	// sensorLocalInterval 管理节点传感器的读取间隔
+	sensorLocalInterval = 30 * time.Second
+)
+
+// SensorMonitor 汇总各节点的传感器读数，读数越过阈值时产生告警，回到阈值以内时恢复
+type SensorMonitor struct {
+	logger *utils.Logger
+
+	mutex sync.Mutex
+	nodes map[string]models.NodeSensors
+	// statuses 按节点记录各传感器上一次的状态，键为 sensorKey
+	statuses map[string]map[string]string
+	active   map[string]*models.SensorAlert
+	resolved []models.SensorAlert
+	nextID   int
+
+	startOnce sync.Once
+}
+
+// NewSensorMonitor 创建新的传感器监控实例
+func NewSensorMonitor() *SensorMonitor {
+	return &SensorMonitor{
+		logger:   utils.NewLogger(),
+		nodes:    make(map[string]models.NodeSensors),
+		statuses: make(map[string]map[string]string),
+		active:   make(map[string]*models.SensorAlert),
+		resolved: []models.SensorAlert{},
+	}
+}
+
+// sensorName 传感器在节点内的名称
+func sensorName(reading models.SensorReading) string {
+	return reading.Device + "/" + reading.Label
+}
+
+// sensorKey 传感器的全局键
+func sensorKey(nodeKey string, reading models.SensorReading) string {
+	return nodeKey + "/" + reading.Type + "/" + sensorName(reading)
+}
+
+// Observe 记录节点的一组读数：结合上一次的状态重新评估（带回差），状态变化时产生或恢复告警
+// 本次没有上报的传感器视为已消失，其未恢复的告警随之结束；返回带最终状态的读数
+func (m *SensorMonitor) Observe(hostname, cluster string, t time.Time, readings []models.SensorReading) []models.SensorReading {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	nodeKey := cluster + "/" + hostname
+	previousStatuses := m.statuses[nodeKey]
+	statuses := make(map[string]string, len(readings))
+	evaluated := make([]models.SensorReading, len(readings))
+	for i, reading := range readings {
+		key := sensorKey(nodeKey, reading)
+		previous := previousStatuses[key]
+		status, threshold := EvaluateSensor(reading, previous)
+		reading.Status = status
+		evaluated[i] = reading
+		statuses[key] = status
+		if status == previous || (previous == "" && status == SensorOK) {
+			continue
+		}
+
+		// 状态变化：先结束旧告警，再按新状态产生告警
+		if alert, ok := m.active[key]; ok {
+			resolvedAt := t
+			alert.ResolvedAt = &resolvedAt
+			m.resolved = append(m.resolved, *alert)
+			delete(m.active, key)
+			if status == SensorOK {
+				m.logger.Info(fmt.Sprintf("节点 %s 传感器 %s 恢复正常: %.1f%s", hostname, sensorName(reading), reading.Value, reading.Unit))
+			}
+		}
+		if status != SensorOK {
+			m.nextID++
+			alert := &models.SensorAlert{
+				ID:        fmt.Sprintf("%d-%d", t.Unix(), m.nextID),
+				Hostname:  hostname,
+				Cluster:   cluster,
+				Sensor:    sensorName(reading),
+				Type:      reading.Type,
+				Status:    status,
+				Value:     reading.Value,
+				Threshold: threshold,
+				Unit:      reading.Unit,
+				Message:   fmt.Sprintf("%s %s %.1f%s 越过阈值 %.1f%s", hostname, reading.Label, reading.Value, reading.Unit, threshold, reading.Unit),
+				StartedAt: t,
+			}
+			m.active[key] = alert
+			m.logger.Error(fmt.Sprintf("传感器告警（%s）: %s", status, alert.Message))
+		}
+	}
+	for key := range previousStatuses {
+		if _, ok := statuses[key]; !ok {
+			m.expireLocked(key, t)
+		}
+	}
+	m.statuses[nodeKey] = statuses
+	m.trimResolvedLocked()
+
+	m.nodes[nodeKey] = models.NodeSensors{Hostname: hostname, Cluster: cluster, Time: t, Sensors: evaluated}
+	return evaluated
+}
+
+// expireLocked 结束不再上报的传感器的未恢复告警，调用方需持有锁
+func (m *SensorMonitor) expireLocked(key string, t time.Time) {
+	alert, ok := m.active[key]
+	if !ok {
+		return
+	}
+	resolvedAt := t
+	alert.ResolvedAt = &resolvedAt
+	alert.Expired = true
+	m.resolved = append(m.resolved, *alert)
+	delete(m.active, key)
+	m.logger.Info(fmt.Sprintf("节点 %s 传感器 %s 不再上报读数，告警结束", alert.Hostname, alert.Sensor))
+}
+
+// expireStaleLocked 结束超过 sensorStaleAfter 未更新的节点的告警，并清除其状态，节点恢复上报后重新评估
+// 调用方需持有锁
+func (m *SensorMonitor) expireStaleLocked(now time.Time) {
+	for nodeKey, node := range m.nodes {
+		if now.Sub(node.Time) <= sensorStaleAfter {
+			continue
+		}
+		for key := range m.statuses[nodeKey] {
+			m.expireLocked(key, now)
+		}
+		delete(m.statuses, nodeKey)
+	}
+	m.trimResolvedLocked()
+}
+
+// trimResolvedLocked 只保留最近 sensorAlertLimit 条已恢复告警，调用方需持有锁
+func (m *SensorMonitor) trimResolvedLocked() {
+	if len(m.resolved) > sensorAlertLimit {
+		m.resolved = m.resolved[len(m.resolved)-sensorAlertLimit:]
+	}
+}
+
+// StartLocal 定时读取管理节点的传感器，重复调用无效
+func (m *SensorMonitor) StartLocal() {
+	m.startOnce.Do(func() {
+		go func() {
+			hostname := getHostname()
+			for {
+				m.Observe(hostname, "", time.Now(), GetManagementSensors())
+				time.Sleep(sensorLocalInterval)
+			}
+		}()
+	})
+}
+
+// Nodes 返回各节点最新的传感器读数，cluster 不为空时只返回该集群（及管理节点）
+func (m *SensorMonitor) Nodes(cluster string) []models.NodeSensors {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := time.Now()
+	m.expireStaleLocked(now)
+	result := []models.NodeSensors{}
+	for _, node := range m.nodes {
+		if cluster != "" && node.Cluster != "" && node.Cluster != cluster {
+			continue
+		}
+		node.Stale = now.Sub(node.Time) > sensorStaleAfter
+		result = append(result, node)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Cluster != result[j].Cluster {
+			return result[i].Cluster < result[j].Cluster
+		}
+		return result[i].Hostname < result[j].Hostname
+	})
+	return result
+}
+
+// Alerts 返回告警，先列出未恢复的告警，再按恢复时间由新到旧列出已恢复的告警
+// 节点超过 sensorStaleAfter 未上报时，其告警作为已过期结束
+func (m *SensorMonitor) Alerts(includeResolved bool, limit int) []models.SensorAlert {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.expireStaleLocked(time.Now())
+
+	result := []models.SensorAlert{}
+	for _, alert := range m.active {
+		result = append(result, *alert)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if sensorSeverity(result[i].Status) != sensorSeverity(result[j].Status) {
+			return sensorSeverity(result[i].Status) > sensorSeverity(result[j].Status)
+		}
+		return result[i].StartedAt.Before(result[j].StartedAt)
+	})
+	if includeResolved {
+		for i := len(m.resolved) - 1; i >= 0 && len(result) < limit; i-- {
+			result = append(result, m.resolved[i])
+		}
+	}
+	return result
+}
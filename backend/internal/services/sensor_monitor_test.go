@@ -0,0 +1,63 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"panel-tool/internal/models"
+)
+
+func fanReading(label string, value, min float64) models.SensorReading {
+	return models.SensorReading{Device: "hwmon2", Label: label, Type: SensorFan, Unit: "RPM", Value: value, Min: &min}
+}
+
+func TestSensorMonitorExpiresMissingSensors(t *testing.T) {
+	m := NewSensorMonitor()
+	start := time.Now()
+	m.Observe("cn001", "alpha", start, []models.SensorReading{fanReading("fan1", 300, 600), fanReading("fan2", 5000, 600)})
+	if alerts := m.Alerts(false, 10); len(alerts) != 1 || alerts[0].Sensor != "hwmon2/fan1" || alerts[0].Status != SensorWarning {
+		t.Fatalf("alerts after low fan = %+v", alerts)
+	}
+
+	// fan1 不再上报：告警作为已过期结束，而不是一直保持未恢复
+	m.Observe("cn001", "alpha", start.Add(30*time.Second), []models.SensorReading{fanReading("fan2", 5000, 600)})
+	alerts := m.Alerts(true, 10)
+	if len(alerts) != 1 || alerts[0].ResolvedAt == nil || !alerts[0].Expired {
+		t.Fatalf("alerts after sensor disappeared = %+v", alerts)
+	}
+
+	// 传感器重新出现且仍然越限时产生新告警
+	m.Observe("cn001", "alpha", start.Add(time.Minute), []models.SensorReading{fanReading("fan1", 300, 600), fanReading("fan2", 5000, 600)})
+	if active := m.Alerts(false, 10); len(active) != 1 || active[0].ResolvedAt != nil {
+		t.Fatalf("alerts after sensor returned = %+v", active)
+	}
+}
+
+func TestSensorMonitorExpiresStaleNodes(t *testing.T) {
+	m := NewSensorMonitor()
+	stale := time.Now().Add(-sensorStaleAfter - time.Minute)
+	m.Observe("cn001", "alpha", stale, []models.SensorReading{fanReading("fan1", 300, 600)})
+	m.Observe("cn002", "alpha", time.Now(), []models.SensorReading{fanReading("fan1", 300, 600)})
+
+	alerts := m.Alerts(true, 10)
+	if len(alerts) != 2 {
+		t.Fatalf("got %d alerts, want 2", len(alerts))
+	}
+	if alerts[0].Hostname != "cn002" || alerts[0].ResolvedAt != nil {
+		t.Errorf("active alert = %+v, want cn002 unresolved", alerts[0])
+	}
+	if alerts[1].Hostname != "cn001" || alerts[1].ResolvedAt == nil || !alerts[1].Expired {
+		t.Errorf("stale alert = %+v, want cn001 expired", alerts[1])
+	}
+
+	nodes := m.Nodes("alpha")
+	if len(nodes) != 2 || !nodes[0].Stale || nodes[1].Stale {
+		t.Errorf("nodes = %+v, want cn001 stale", nodes)
+	}
+
+	// 节点恢复上报后重新评估，仍然越限时产生新告警
+	m.Observe("cn001", "alpha", time.Now(), []models.SensorReading{fanReading("fan1", 300, 600)})
+	if active := m.Alerts(false, 10); len(active) != 2 {
+		t.Errorf("active alerts after node returned = %+v", active)
+	}
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"math"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"panel-tool/internal/models"
+)
+
+// 传感器类型与状态
+const (
+	SensorTemperature = "temperature"
+	SensorFan         = "fan"
+	SensorVoltage     = "voltage"
+
+	SensorOK       = "ok"
+	SensorWarning  = "warning"
+	SensorCritical = "critical"
+)
+
+// sensorHysteresisRatio 已处于告警状态的传感器需回到阈值以内该比例才恢复，避免在阈值附近反复告警
+const sensorHysteresisRatio = 0.02
+
+// hwmonInputPattern 匹配 hwmon 的输入文件，如 temp1_input、fan2_input、in0_input
+var hwmonInputPattern = regexp.MustCompile(`^(temp|fan|in)(\d+)_input$`)
+
+// ReadSensors 读取 /sys/class/hwmon 与 /sys/class/thermal 下的温度、风扇转速和电压，并按阈值评估状态
+func ReadSensors(sysRoot string) []models.SensorReading {
+	readings := readHwmon(filepath.Join(sysRoot, "class", "hwmon"))
+	readings = append(readings, readThermalZones(filepath.Join(sysRoot, "class", "thermal"))...)
+	for i := range readings {
+		readings[i].Status, _ = EvaluateSensor(readings[i], SensorOK)
+	}
+	sort.SliceStable(readings, func(i, j int) bool {
+		if readings[i].Device != readings[j].Device {
+			return readings[i].Device < readings[j].Device
+		}
+		return readings[i].Label < readings[j].Label
+	})
+	return readings
+}
+
+// readSensorValue 读取 hwmon 数值文件并按比例换算，文件不存在或无效时返回 nil
+func readSensorValue(path string, scale float64) *float64 {
+	raw, err := strconv.ParseFloat(readSysValue(path), 64)
+	if err != nil {
+		return nil
+	}
+	value := raw / scale
+	return &value
+}
+
+// readHwmon 读取 hwmon 芯片的 temp*、fan*、in* 传感器：温度单位为毫摄氏度，电压为毫伏
+func readHwmon(dir string) []models.SensorReading {
+	readings := []models.SensorReading{}
+	chips, _ := filepath.Glob(filepath.Join(dir, "hwmon*"))
+	for _, chip := range chips {
+		name := readSysValue(filepath.Join(chip, "name"))
+		// 部分驱动的传感器文件位于 device 子目录
+		files, _ := filepath.Glob(filepath.Join(chip, "*_input"))
+		if len(files) == 0 {
+			files, _ = filepath.Glob(filepath.Join(chip, "device", "*_input"))
+		}
+		for _, file := range files {
+			match := hwmonInputPattern.FindStringSubmatch(filepath.Base(file))
+			if match == nil {
+				continue
+			}
+			base := filepath.Join(filepath.Dir(file), match[1]+match[2])
+			reading := models.SensorReading{Chip: name, Device: filepath.Base(chip)}
+			var scale float64
+			switch match[1] {
+			case "temp":
+				reading.Type, reading.Unit, scale = SensorTemperature, "°C", 1000
+			case "fan":
+				reading.Type, reading.Unit, scale = SensorFan, "RPM", 1
+			case "in":
+				reading.Type, reading.Unit, scale = SensorVoltage, "V", 1000
+			}
+			value := readSensorValue(file, scale)
+			if value == nil {
+				continue
+			}
+			reading.Value = *value
+			reading.Label = readSysValue(base + "_label")
+			if reading.Label == "" {
+				reading.Label = match[1] + match[2]
+			}
+
+			switch reading.Type {
+			case SensorFan:
+				// fan*_min 为警告下限，fan*_lcrit（少数驱动提供）为严重下限
+				// 未接风扇的接口读数为 0 且没有下限，跳过
+				reading.Min = readSensorValue(base+"_min", scale)
+				reading.LowCritical = readSensorValue(base+"_lcrit", scale)
+				if reading.Value == 0 && (reading.Min == nil || *reading.Min == 0) && (reading.LowCritical == nil || *reading.LowCritical == 0) {
+					continue
+				}
+				if reading.Min != nil && *reading.Min == 0 {
+					reading.Min = nil
+				}
+				if reading.LowCritical != nil && *reading.LowCritical == 0 {
+					reading.LowCritical = nil
+				}
+			default:
+				reading.Min = readSensorValue(base+"_min", scale)
+				reading.Max = readSensorValue(base+"_max", scale)
+				reading.LowCritical = readSensorValue(base+"_lcrit", scale)
+				reading.Critical = readSensorValue(base+"_crit", scale)
+				// 未配置的芯片常把上下限都填 0 或填反，这样的阈值会让每个读数都越限
+				reading.Min, reading.Max = validSensorLimits(reading.Min, reading.Max)
+				reading.LowCritical, reading.Critical = validSensorLimits(reading.LowCritical, reading.Critical)
+			}
+			if reading.Type == SensorTemperature {
+				// 温度下限通常无意义（部分芯片填 0 或负值），只保留上限
+				reading.Min, reading.LowCritical = nil, nil
+			}
+			readings = append(readings, reading)
+		}
+	}
+	return readings
+}
+
+// validSensorLimits 上限不大于 0 或下限不小于上限时忽略这组阈值
+func validSensorLimits(low, high *float64) (*float64, *float64) {
+	if high != nil && *high <= 0 {
+		return nil, nil
+	}
+	if low != nil && high != nil && *low >= *high {
+		return nil, nil
+	}
+	return low, high
+}
+
+// readThermalZones 读取 thermal_zone*：critical 触发点为严重阈值，hot（没有时取 passive）为警告阈值
+func readThermalZones(dir string) []models.SensorReading {
+	readings := []models.SensorReading{}
+	zones, _ := filepath.Glob(filepath.Join(dir, "thermal_zone*"))
+	for _, zone := range zones {
+		value := readSensorValue(filepath.Join(zone, "temp"), 1000)
+		if value == nil {
+			continue
+		}
+		zoneType := readSysValue(filepath.Join(zone, "type"))
+		reading := models.SensorReading{
+			Chip:   zoneType,
+			Device: filepath.Base(zone),
+			Label:  zoneType,
+			Type:   SensorTemperature,
+			Value:  *value,
+			Unit:   "°C",
+		}
+
+		var passive *float64
+		trips, _ := filepath.Glob(filepath.Join(zone, "trip_point_*_type"))
+		for _, trip := range trips {
+			temp := readSensorValue(strings.TrimSuffix(trip, "_type")+"_temp", 1000)
+			if temp == nil || *temp <= 0 {
+				continue
+			}
+			switch readSysValue(trip) {
+			case "critical":
+				reading.Critical = temp
+			case "hot":
+				reading.Max = temp
+			case "passive":
+				if passive == nil || *temp < *passive {
+					passive = temp
+				}
+			}
+		}
+		if reading.Max == nil {
+			reading.Max = passive
+		}
+		readings = append(readings, reading)
+	}
+	return readings
+}
+
+// sensorSeverity 状态的严重程度，用于比较
+func sensorSeverity(status string) int {
+	switch status {
+	case SensorCritical:
+		return 2
+	case SensorWarning:
+		return 1
+	}
+	return 0
+}
+
+// EvaluateSensor 按阈值评估读数状态，返回状态及越过的阈值
+// previous 为上一次的状态：已处于同级或更严重状态时，需回到阈值以内 sensorHysteresisRatio 才降级
+func EvaluateSensor(reading models.SensorReading, previous string) (string, float64) {
+	checks := []struct {
+		status string
+		limit  *float64
+		upper  bool
+	}{
+		{SensorCritical, reading.Critical, true},
+		{SensorCritical, reading.LowCritical, false},
+		{SensorWarning, reading.Max, true},
+		{SensorWarning, reading.Min, false},
+	}
+	for _, check := range checks {
+		if check.limit == nil {
+			continue
+		}
+		margin := 0.0
+		if sensorSeverity(previous) >= sensorSeverity(check.status) {
+			margin = math.Abs(*check.limit) * sensorHysteresisRatio
+		}
+		if check.upper && reading.Value >= *check.limit-margin {
+			return check.status, *check.limit
+		}
+		if !check.upper && reading.Value <= *check.limit+margin {
+			return check.status, *check.limit
+		}
+	}
+	return SensorOK, 0
+}
+
+// GetManagementSensors 读取管理节点的传感器
+func GetManagementSensors() []models.SensorReading {
+	return ReadSensors("/sys")
+}
+
+// maxTemperature 返回读数中的最高温度，没有温度传感器时返回 false
+func maxTemperature(readings []models.SensorReading) (float64, bool) {
+	max, found := 0.0, false
+	for _, reading := range readings {
+		if reading.Type == SensorTemperature && (!found || reading.Value > max) {
+			max, found = reading.Value, true
+		}
+	}
+	return max, found
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"panel-tool/internal/models"
+)
+
+// sensorLimit 格式化阈值，未设置时为 "-"
+func sensorLimit(limit *float64) string {
+	if limit == nil {
+		return "-"
+	}
+	return fmt.Sprint(*limit)
+}
+
+func floatPtr(value float64) *float64 {
+	return &value
+}
+
+func TestReadSensors(t *testing.T) {
+	readings := ReadSensors(filepath.Join("testdata", "host", "sys"))
+
+	tests := []struct {
+		device, label string
+		typ           string
+		value         float64
+		limits        string // min max lcrit crit
+		status        string
+	}{
+		{"hwmon0", "Core 0", SensorTemperature, 87, "- 84 - 100", SensorWarning},
+		{"hwmon0", "Package id 0", SensorTemperature, 52, "- 84 - 100", SensorOK},
+		// 上限为 0 的阈值无效
+		{"hwmon0", "temp3", SensorTemperature, 45, "- - - -", SensorOK},
+		{"hwmon1", "+12V", SensorVoltage, 11, "- - 11.4 12.6", SensorCritical},
+		// 上下限填反时忽略
+		{"hwmon1", "+3.3V", SensorVoltage, 3.3, "- - - -", SensorOK},
+		{"hwmon1", "fan1", SensorFan, 1200, "600 - - -", SensorOK},
+		// fan2 未接风扇被跳过；fan3 低于 fan3_min 只是警告
+		{"hwmon1", "fan3", SensorFan, 300, "600 - 200 -", SensorWarning},
+		{"hwmon1", "in0", SensorVoltage, 1.2, "1.1 1.3 - -", SensorOK},
+		// 传感器文件位于 device 子目录
+		{"hwmon2", "Composite", SensorTemperature, 38, "- 80 - -", SensorOK},
+		// 取最低的 passive 触发点作为警告阈值
+		{"thermal_zone0", "x86_pkg_temp", SensorTemperature, 55, "- 80 - 105", SensorOK},
+		{"thermal_zone1", "acpitz", SensorTemperature, 27.8, "- 95 - -", SensorOK},
+	}
+	if len(readings) != len(tests) {
+		for _, reading := range readings {
+			t.Logf("%s/%s = %v", reading.Device, reading.Label, reading.Value)
+		}
+		t.Fatalf("got %d readings, want %d", len(readings), len(tests))
+	}
+	for i, tt := range tests {
+		t.Run(tt.device+"/"+tt.label, func(t *testing.T) {
+			got := readings[i]
+			limits := fmt.Sprintf("%s %s %s %s", sensorLimit(got.Min), sensorLimit(got.Max), sensorLimit(got.LowCritical), sensorLimit(got.Critical))
+			if got.Device != tt.device || got.Label != tt.label || got.Type != tt.typ || got.Value != tt.value || limits != tt.limits || got.Status != tt.status {
+				t.Errorf("reading = %s/%s %s %v [%s] %s, want %s/%s %s %v [%s] %s",
+					got.Device, got.Label, got.Type, got.Value, limits, got.Status, tt.device, tt.label, tt.typ, tt.value, tt.limits, tt.status)
+			}
+		})
+	}
+	if chip := readings[0].Chip; chip != "coretemp" {
+		t.Errorf("hwmon0 chip = %q, want coretemp", chip)
+	}
+
+	if readings := ReadSensors(filepath.Join("testdata", "missing")); len(readings) != 0 {
+		t.Errorf("ReadSensors() without /sys = %+v", readings)
+	}
+}
+
+func TestValidSensorLimits(t *testing.T) {
+	tests := []struct {
+		low, high *float64
+		want      string
+	}{
+		{nil, nil, "- -"},
+		{floatPtr(1.1), floatPtr(1.3), "1.1 1.3"},
+		{nil, floatPtr(84), "- 84"},
+		{floatPtr(-5), nil, "-5 -"},
+		{floatPtr(0), floatPtr(0), "- -"},
+		{nil, floatPtr(-1), "- -"},
+		{floatPtr(3.5), floatPtr(3.1), "- -"},
+		{floatPtr(12), floatPtr(12), "- -"},
+	}
+	for _, tt := range tests {
+		low, high := validSensorLimits(tt.low, tt.high)
+		if got := sensorLimit(low) + " " + sensorLimit(high); got != tt.want {
+			t.Errorf("validSensorLimits(%s, %s) = %s, want %s", sensorLimit(tt.low), sensorLimit(tt.high), got, tt.want)
+		}
+	}
+}
+
+func TestEvaluateSensor(t *testing.T) {
+	temperature := models.SensorReading{Type: SensorTemperature, Max: floatPtr(80), Critical: floatPtr(100)}
+	fan := models.SensorReading{Type: SensorFan, Min: floatPtr(600), LowCritical: floatPtr(200)}
+
+	tests := []struct {
+		name     string
+		reading  models.SensorReading
+		value    float64
+		previous string
+		status   string
+		limit    float64
+	}{
+		{"below max", temperature, 79, SensorOK, SensorOK, 0},
+		{"at max", temperature, 80, SensorOK, SensorWarning, 80},
+		// 已告警时需降到 80 - 1.6 以下才恢复
+		{"within hysteresis", temperature, 79, SensorWarning, SensorWarning, 80},
+		{"recovered", temperature, 78, SensorWarning, SensorOK, 0},
+		// 警告状态不对严重阈值放宽
+		{"below critical from warning", temperature, 99, SensorWarning, SensorWarning, 80},
+		{"below critical from critical", temperature, 99, SensorCritical, SensorCritical, 100},
+		{"above critical", temperature, 100, SensorOK, SensorCritical, 100},
+		{"fan ok", fan, 615, SensorOK, SensorOK, 0},
+		{"fan below min", fan, 600, SensorOK, SensorWarning, 600},
+		{"fan within hysteresis", fan, 610, SensorWarning, SensorWarning, 600},
+		{"fan recovered", fan, 615, SensorWarning, SensorOK, 0},
+		{"fan stopped", fan, 0, SensorWarning, SensorCritical, 200},
+		{"no limits", models.SensorReading{Type: SensorVoltage}, 3.3, SensorCritical, SensorOK, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reading := tt.reading
+			reading.Value = tt.value
+			if status, limit := EvaluateSensor(reading, tt.previous); status != tt.status || limit != tt.limit {
+				t.Errorf("EvaluateSensor(%v, %s) = %s, %v, want %s, %v", tt.value, tt.previous, status, limit, tt.status, tt.limit)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"panel-tool/internal/models"
+	"panel-tool/internal/utils"
+)
+
+// defaultManagedServices 默认管理的 HPC 相关服务，可通过 PANEL_MANAGED_SERVICES（逗号分隔）覆盖
+var defaultManagedServices = []string{"slurmctld", "slurmdbd", "munge", "slurmd", "sshd", "nfs-server"}
+
+// 服务日志默认与最大返回行数
+const (
+	defaultJournalLines = 20
+	maxJournalLines     = 500
+)
+
+// serviceActions 支持的服务操作
+var serviceActions = map[string]bool{
+	"start":   true,
+	"stop":    true,
+	"restart": true,
+	"reload":  true,
+	"enable":  true,
+	"disable": true,
+}
+
+// serviceNamePattern 合法的服务名
+var serviceNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9@._-]*$`)
+
+// serviceStatusProperties systemctl show 查询的属性
+var serviceStatusProperties = []string{
+	"Description", "LoadState", "ActiveState", "SubState", "UnitFileState",
+	"MainPID", "StateChangeTimestampMonotonic",
+}
+
+// ServiceManager 基于 systemctl 的服务管理器，只允许操作配置列表中的服务
+type ServiceManager struct {
+	logger   *utils.Logger
+	services []string
+}
+
+// NewServiceManager 创建新的服务管理器实例
+func NewServiceManager() *ServiceManager {
+	services := defaultManagedServices
+	if value := os.Getenv("PANEL_MANAGED_SERVICES"); value != "" {
+		services = nil
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if serviceNamePattern.MatchString(name) {
+				services = append(services, name)
+			}
+		}
+	}
+	return &ServiceManager{
+		logger:   utils.NewLogger(),
+		services: services,
+	}
+}
+
+// Services 返回受管理的服务列表
+func (m *ServiceManager) Services() []string {
+	return m.services
+}
+
+// isManaged 判断服务是否在受管理列表中
+func (m *ServiceManager) isManaged(name string) bool {
+	for _, service := range m.services {
+		if service == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ListStatus 获取所有受管理服务的状态（不含日志）
+func (m *ServiceManager) ListStatus() []models.ServiceStatus {
+	statuses := make([]models.ServiceStatus, 0, len(m.services))
+	for _, name := range m.services {
+		status, err := queryServiceStatus(name)
+		if err != nil {
+			m.logger.Error(fmt.Sprintf("获取服务 %s 状态失败: %v", name, err))
+			status = &models.ServiceStatus{Name: name, LoadState: "unknown", ActiveState: "unknown"}
+		}
+		statuses = append(statuses, *status)
+	}
+	return statuses
+}
+
+// GetStatus 获取单个服务的状态及最近的日志，journalLines 为 0 时使用默认行数，小于 0 时不读取日志
+func (m *ServiceManager) GetStatus(name string, journalLines int) (*models.ServiceStatus, error) {
+	if !m.isManaged(name) {
+		return nil, fmt.Errorf("服务 %s 不在管理列表中", name)
+	}
+	status, err := queryServiceStatus(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if journalLines == 0 {
+		journalLines = defaultJournalLines
+	}
+	if journalLines > maxJournalLines {
+		journalLines = maxJournalLines
+	}
+	if journalLines > 0 && status.LoadState != "not-found" {
+		journal, err := queryServiceJournal(name, journalLines)
+		if err != nil {
+			m.logger.Error(fmt.Sprintf("读取服务 %s 日志失败: %v", name, err))
+		}
+		status.Journal = journal
+	}
+	return status, nil
+}
+
+// Control 对服务执行 start/stop/restart/reload/enable/disable 操作
+func (m *ServiceManager) Control(name, action string) (string, error) {
+	if !m.isManaged(name) {
+		return "", fmt.Errorf("服务 %s 不在管理列表中", name)
+	}
+	if !serviceActions[action] {
+		return "", fmt.Errorf("不支持的服务操作: %s", action)
+	}
+
+	m.logger.Info(fmt.Sprintf("执行 systemctl %s %s", action, name))
+	output, err := exec.Command("systemctl", action, serviceUnit(name)).CombinedOutput()
+	if err != nil {
+		msg := strings.TrimSpace(string(output))
+		if msg == "" {
+			return string(output), fmt.Errorf("systemctl %s %s 失败: %v", action, name, err)
+		}
+		return string(output), fmt.Errorf("systemctl %s %s 失败: %s", action, name, msg)
+	}
+	return string(output), nil
+}
+
+// serviceUnit 补全 systemd 单元名
+func serviceUnit(name string) string {
+	if strings.Contains(name, ".") {
+		return name
+	}
+	return name + ".service"
+}
+
+// queryServiceStatus 通过 systemctl show 获取服务状态
+func queryServiceStatus(name string) (*models.ServiceStatus, error) {
+	output, err := exec.Command("systemctl", "show", serviceUnit(name),
+		"--property="+strings.Join(serviceStatusProperties, ",")).Output()
+	if err != nil {
+		return nil, fmt.Errorf("执行 systemctl show %s 失败: %v", name, err)
+	}
+	return parseServiceStatus(name, string(output)), nil
+}
+
+// parseServiceStatus 解析 systemctl show 输出的 Key=Value 行
+func parseServiceStatus(name, output string) *models.ServiceStatus {
+	props := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		if key, value, ok := strings.Cut(strings.TrimSpace(line), "="); ok {
+			props[key] = value
+		}
+	}
+
+	status := &models.ServiceStatus{
+		Name:          name,
+		Description:   props["Description"],
+		LoadState:     props["LoadState"],
+		ActiveState:   props["ActiveState"],
+		SubState:      props["SubState"],
+		UnitFileState: props["UnitFileState"],
+	}
+	status.MainPID, _ = strconv.Atoi(props["MainPID"])
+
+	// 使用单调时钟时间戳换算，避免解析 systemd 本地化的时间格式
+	if usec, err := strconv.ParseInt(props["StateChangeTimestampMonotonic"], 10, 64); err == nil && usec > 0 {
+		if boot := bootTime(); !boot.IsZero() {
+			since := boot.Add(time.Duration(usec) * time.Microsecond)
+			status.Since = &since
+		}
+	}
+	return status
+}
+
+// queryServiceJournal 读取服务最近的日志
+func queryServiceJournal(name string, lines int) ([]string, error) {
+	output, err := exec.Command("journalctl", "--unit="+serviceUnit(name), "--lines="+strconv.Itoa(lines),
+		"--no-pager", "--output=short-iso").Output()
+	if err != nil {
+		return []string{}, fmt.Errorf("执行 journalctl 失败: %v", err)
+	}
+
+	journal := []string{}
+	for _, line := range strings.Split(strings.TrimRight(string(output), "\n"), "\n") {
+		// 跳过 "-- No entries --" 等提示行
+		if line == "" || strings.HasPrefix(line, "-- ") {
+			continue
+		}
+		journal = append(journal, line)
+	}
+	return journal, nil
+}
+
+// bootTime 从 /proc/stat 读取系统启动时间
+func bootTime() time.Time {
+	file, err := os.Open("/proc/stat")
+	if err != nil {
+		return time.Time{}
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == "btime" {
+			if seconds, err := strconv.ParseInt(fields[1], 10, 64); err == nil {
+				return time.Unix(seconds, 0)
+			}
+		}
+	}
+	return time.Time{}
+}
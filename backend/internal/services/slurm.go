@@ -207,21 +207,14 @@ func formatDuration(seconds int) string {
 	return fmt.Sprintf("%02d:%02d:%02d", hours, minutes, secs)
 }
 
-// ControlSlurmService 控制Slurm服务
+// ControlSlurmService 控制Slurm服务（start/stop/restart），其他服务及操作请使用 ServiceManager
 func ControlSlurmService(action string) (string, error) {
-	var cmd *exec.Cmd
-	
 	switch action {
-	case "start":
-		cmd = exec.Command("systemctl", "start", "slurmctld")
-	case "stop":
-		cmd = exec.Command("systemctl", "stop", "slurmctld")
-	case "restart":
-		cmd = exec.Command("systemctl", "restart", "slurmctld")
+	case "start", "stop", "restart":
 	default:
-		return "", &exec.ExitError{}
+		return "", fmt.Errorf("不支持的 Slurm 服务操作: %s", action)
 	}
-	
-	output, err := cmd.CombinedOutput()
+
+	output, err := exec.Command("systemctl", action, "slurmctld").CombinedOutput()
 	return string(output), err
 }
\ No newline at end of file
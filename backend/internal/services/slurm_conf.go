@@ -0,0 +1,848 @@
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"panel-tool/internal/hostlist"
+	"panel-tool/internal/models"
+)
+
+// defaultSlurmConfPath slurm.conf 的默认路径，可通过 PANEL_SLURM_CONF 覆盖
+const defaultSlurmConfPath = "/etc/slurm/slurm.conf"
+
+// maxSlurmConfIncludeDepth Include 的最大嵌套层数
+const maxSlurmConfIncludeDepth = 10
+
+// maxSlurmConfBackups 每个配置文件保留的备份数量
+const maxSlurmConfBackups = 10
+
+// 配置问题级别
+const (
+	SlurmConfError   = "error"
+	SlurmConfWarning = "warning"
+)
+
+// slurmConfRecordKeys 以记录形式出现的配置行（整行属于同一条定义），值保留该行其余部分
+var slurmConfRecordKeys = map[string]bool{
+	"downnodes":    true,
+	"frontendname": true,
+	"nodeset":      true,
+	"switchname":   true,
+}
+
+// slurmConfRepeatableKeys 允许重复出现的全局参数
+var slurmConfRepeatableKeys = map[string]bool{
+	"slurmctldhost": true,
+	"downnodes":     true,
+	"frontendname":  true,
+	"nodeset":       true,
+	"switchname":    true,
+}
+
+// lowRealMemoryPattern 匹配 slurmd 上报内存不足时的 Reason，如 "Low RealMemory (reported:7800 < 100.00% of configured:8000)"
+var lowRealMemoryPattern = regexp.MustCompile(`Low RealMemory \(reported:(\d+)`)
+
+// slurmConfMutex 串行化配置文件的写入
+var slurmConfMutex sync.Mutex
+
+// SlurmConfUpdate 配置文件修改请求
+type SlurmConfUpdate struct {
+	File        string `json:"file"` // 为空表示主配置文件
+	Content     string `json:"content"`
+	Reconfigure bool   `json:"reconfigure"` // 写入后执行 scontrol reconfigure
+	Force       bool   `json:"force"`       // 校验存在错误时仍然写入
+}
+
+// SlurmConfUpdateResult 配置文件修改结果
+type SlurmConfUpdateResult struct {
+	File              string                  `json:"file"`
+	Backup            string                  `json:"backup,omitempty"`
+	Applied           bool                    `json:"applied"`
+	Issues            []models.SlurmConfIssue `json:"issues"`
+	ReconfigureOutput string                  `json:"reconfigure_output,omitempty"`
+}
+
+// SlurmConfPath 返回 slurm.conf 的路径
+func SlurmConfPath() string {
+	if path := os.Getenv("PANEL_SLURM_CONF"); path != "" {
+		return path
+	}
+	return defaultSlurmConfPath
+}
+
+// GetSlurmConfig 解析集群的 slurm.conf 并附带校验结果
+func GetSlurmConfig(cluster *Cluster) (*models.SlurmConfig, error) {
+	if cluster.restOnly() {
+		return nil, cluster.errRESTOnly("slurm.conf 管理")
+	}
+	cfg, err := ParseSlurmConf(cluster.confPath())
+	if err != nil {
+		return nil, err
+	}
+	cfg.Issues = append(cfg.Issues, ValidateSlurmConf(cfg, collectNodeMemory(cluster))...)
+	return cfg, nil
+}
+
+// ParseSlurmConf 解析 slurm.conf 及其 Include 的文件
+// 解析过程中发现的问题（如无法识别的行、缺失的 Include 文件）记录在 Issues 中
+func ParseSlurmConf(path string) (*models.SlurmConfig, error) {
+	return parseSlurmConf(path, os.ReadFile)
+}
+
+// slurmConfParser slurm.conf 解析状态
+type slurmConfParser struct {
+	cfg               *models.SlurmConfig
+	read              func(path string) ([]byte, error)
+	nodeDefaults      map[string]string
+	partitionDefaults map[string]string
+	visited           map[string]bool
+}
+
+// slurmConfLine 去除注释、合并续行后的逻辑行
+type slurmConfLine struct {
+	Number int
+	Text   string
+}
+
+// parseSlurmConf 使用指定的读取函数解析配置，便于在写入前校验修改后的内容
+func parseSlurmConf(path string, read func(path string) ([]byte, error)) (*models.SlurmConfig, error) {
+	path = filepath.Clean(path)
+	data, err := read(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取 %s 失败: %v", path, err)
+	}
+
+	p := &slurmConfParser{
+		cfg: &models.SlurmConfig{
+			Path:       path,
+			Files:      []string{},
+			Parameters: []models.SlurmConfParam{},
+			Nodes:      []models.SlurmConfNode{},
+			Partitions: []models.SlurmConfPartition{},
+			Issues:     []models.SlurmConfIssue{},
+		},
+		read:              read,
+		nodeDefaults:      make(map[string]string),
+		partitionDefaults: make(map[string]string),
+		visited:           make(map[string]bool),
+	}
+	p.parseFile(path, data, 0)
+	return p.cfg, nil
+}
+
+// parseFile 解析单个配置文件
+func (p *slurmConfParser) parseFile(path string, data []byte, depth int) {
+	p.cfg.Files = append(p.cfg.Files, path)
+	p.visited[path] = true
+	for _, line := range splitSlurmConfLines(string(data)) {
+		p.parseLine(path, line, depth)
+	}
+}
+
+// parseLine 解析一个逻辑行
+func (p *slurmConfParser) parseLine(path string, line slurmConfLine, depth int) {
+	tokens := splitSlurmConfTokens(line.Text)
+	if len(tokens) == 0 {
+		return
+	}
+	if strings.EqualFold(tokens[0], "include") {
+		p.include(path, line.Number, tokens[1:], depth)
+		return
+	}
+
+	key, value, ok := strings.Cut(tokens[0], "=")
+	if !ok {
+		p.issue(SlurmConfWarning, path, line.Number, "无法识别的配置行: %s", line.Text)
+		return
+	}
+
+	lowerKey := strings.ToLower(key)
+	if slurmConfRecordKeys[lowerKey] {
+		rest := strings.TrimSpace(strings.TrimPrefix(line.Text, tokens[0]))
+		p.cfg.Parameters = append(p.cfg.Parameters, models.SlurmConfParam{
+			Key:   key,
+			Value: strings.TrimSpace(value + " " + rest),
+			File:  path,
+			Line:  line.Number,
+		})
+		return
+	}
+
+	if lowerKey != "nodename" && lowerKey != "partitionname" {
+		// 普通全局参数，一行中可以有多个 Key=Value
+		for _, token := range tokens {
+			k, v, ok := strings.Cut(token, "=")
+			if !ok {
+				p.issue(SlurmConfWarning, path, line.Number, "无法识别的配置项: %s", token)
+				continue
+			}
+			p.cfg.Parameters = append(p.cfg.Parameters, models.SlurmConfParam{Key: k, Value: v, File: path, Line: line.Number})
+		}
+		return
+	}
+
+	params := make(map[string]string)
+	for _, token := range tokens[1:] {
+		k, v, ok := strings.Cut(token, "=")
+		if !ok {
+			p.issue(SlurmConfWarning, path, line.Number, "无法识别的配置项: %s", token)
+			continue
+		}
+		params[k] = v
+	}
+
+	if lowerKey == "nodename" {
+		if strings.EqualFold(value, "DEFAULT") {
+			mergeSlurmConfDefaults(p.nodeDefaults, params)
+			return
+		}
+		p.cfg.Nodes = append(p.cfg.Nodes, models.SlurmConfNode{
+			NodeName: value,
+			Params:   withSlurmConfDefaults(p.nodeDefaults, params),
+			File:     path,
+			Line:     line.Number,
+		})
+		return
+	}
+
+	if strings.EqualFold(value, "DEFAULT") {
+		mergeSlurmConfDefaults(p.partitionDefaults, params)
+		return
+	}
+	merged := withSlurmConfDefaults(p.partitionDefaults, params)
+	p.cfg.Partitions = append(p.cfg.Partitions, models.SlurmConfPartition{
+		PartitionName: value,
+		Nodes:         slurmConfValue(merged, "Nodes"),
+		Params:        merged,
+		File:          path,
+		Line:          line.Number,
+	})
+}
+
+// include 处理 Include 指令，相对路径以主配置文件所在目录为基准，支持通配符
+func (p *slurmConfParser) include(path string, number int, args []string, depth int) {
+	if len(args) == 0 {
+		p.issue(SlurmConfError, path, number, "Include 缺少文件路径")
+		return
+	}
+	if depth >= maxSlurmConfIncludeDepth {
+		p.issue(SlurmConfError, path, number, "Include 嵌套超过 %d 层", maxSlurmConfIncludeDepth)
+		return
+	}
+
+	pattern := strings.Join(args, " ")
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(filepath.Dir(p.cfg.Path), pattern)
+	}
+	pattern = filepath.Clean(pattern)
+
+	files := []string{pattern}
+	if strings.ContainsAny(pattern, "*?[") {
+		matches, err := filepath.Glob(pattern)
+		if err != nil || len(matches) == 0 {
+			p.issue(SlurmConfWarning, path, number, "Include %s 没有匹配到任何文件", pattern)
+			return
+		}
+		files = matches
+	}
+
+	for _, file := range files {
+		if p.visited[file] {
+			p.issue(SlurmConfError, path, number, "文件 %s 被重复或循环 Include", file)
+			continue
+		}
+		data, err := p.read(file)
+		if err != nil {
+			p.issue(SlurmConfError, path, number, "无法读取 Include 文件 %s: %v", file, err)
+			continue
+		}
+		p.parseFile(file, data, depth+1)
+	}
+}
+
+// issue 记录解析问题
+func (p *slurmConfParser) issue(severity, path string, line int, format string, args ...interface{}) {
+	p.cfg.Issues = append(p.cfg.Issues, models.SlurmConfIssue{
+		Severity: severity,
+		File:     path,
+		Line:     line,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// splitSlurmConfLines 去除注释并合并以反斜杠结尾的续行，Number 为逻辑行起始的行号
+func splitSlurmConfLines(content string) []slurmConfLine {
+	var lines []slurmConfLine
+	var current strings.Builder
+	start := 0
+
+	for i, raw := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		text := strings.TrimRight(stripSlurmConfComment(raw), " \t")
+		if current.Len() == 0 {
+			start = i + 1
+		}
+		if strings.HasSuffix(text, "\\") {
+			current.WriteString(strings.TrimSuffix(text, "\\"))
+			current.WriteString(" ")
+			continue
+		}
+		current.WriteString(text)
+		if text := strings.TrimSpace(current.String()); text != "" {
+			lines = append(lines, slurmConfLine{Number: start, Text: text})
+		}
+		current.Reset()
+	}
+	if text := strings.TrimSpace(current.String()); text != "" {
+		lines = append(lines, slurmConfLine{Number: start, Text: text})
+	}
+	return lines
+}
+
+// stripSlurmConfComment 去除 # 开始的注释，\# 表示字面的 #
+func stripSlurmConfComment(line string) string {
+	var b strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '#':
+			b.WriteByte('#')
+			i++
+		case line[i] == '#':
+			return b.String()
+		default:
+			b.WriteByte(line[i])
+		}
+	}
+	return b.String()
+}
+
+// splitSlurmConfTokens 按空白拆分配置项，双引号内的空白不拆分，引号本身被去除
+func splitSlurmConfTokens(text string) []string {
+	var tokens []string
+	var current strings.Builder
+	inQuote := false
+	for _, r := range text {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+		case (r == ' ' || r == '\t') && !inQuote:
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+	return tokens
+}
+
+// mergeSlurmConfDefaults 将 DEFAULT 行的参数合并到默认值中（键不区分大小写）
+func mergeSlurmConfDefaults(defaults, params map[string]string) {
+	for key, value := range params {
+		for existing := range defaults {
+			if strings.EqualFold(existing, key) {
+				delete(defaults, existing)
+			}
+		}
+		defaults[key] = value
+	}
+}
+
+// withSlurmConfDefaults 返回合并默认值后的参数，行内参数优先
+func withSlurmConfDefaults(defaults, params map[string]string) map[string]string {
+	merged := make(map[string]string, len(defaults)+len(params))
+	for key, value := range defaults {
+		merged[key] = value
+	}
+	mergeSlurmConfDefaults(merged, params)
+	return merged
+}
+
+// slurmConfValue 不区分大小写地读取参数值
+func slurmConfValue(params map[string]string, key string) string {
+	if value, ok := params[key]; ok {
+		return value
+	}
+	for k, value := range params {
+		if strings.EqualFold(k, key) {
+			return value
+		}
+	}
+	return ""
+}
+
+// slurmConfNodeCPUs 节点定义的 CPU 数，未设置 CPUs 时按 Sockets×CoresPerSocket×ThreadsPerCore 计算
+func slurmConfNodeCPUs(params map[string]string) int64 {
+	if cpus := parseSlurmInt(slurmConfValue(params, "CPUs")); cpus > 0 {
+		return cpus
+	}
+	product := int64(1)
+	for _, key := range []string{"Sockets", "CoresPerSocket", "ThreadsPerCore"} {
+		if n := parseSlurmInt(slurmConfValue(params, key)); n > 0 {
+			product *= n
+		}
+	}
+	return product
+}
+
+// ValidateSlurmConf 检查配置中的常见错误
+// hardwareMemory 为节点实际内存（MB），用于发现 RealMemory 超过硬件的节点
+func ValidateSlurmConf(cfg *models.SlurmConfig, hardwareMemory map[string]int64) []models.SlurmConfIssue {
+	issues := []models.SlurmConfIssue{}
+	add := func(severity, file string, line int, format string, args ...interface{}) {
+		issues = append(issues, models.SlurmConfIssue{Severity: severity, File: file, Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// 全局参数：必填项与重复定义
+	seen := make(map[string]models.SlurmConfParam)
+	nodeSets := make(map[string]bool)
+	for _, param := range cfg.Parameters {
+		key := strings.ToLower(param.Key)
+		if fields := strings.Fields(param.Value); key == "nodeset" && len(fields) > 0 {
+			nodeSets[fields[0]] = true
+		}
+		if first, ok := seen[key]; ok && !slurmConfRepeatableKeys[key] {
+			add(SlurmConfWarning, param.File, param.Line, "参数 %s 重复定义（首次定义于 %s:%d），以最后一次为准", param.Key, first.File, first.Line)
+			continue
+		}
+		seen[key] = param
+	}
+	if _, ok := seen["clustername"]; !ok {
+		add(SlurmConfError, cfg.Path, 0, "缺少必需参数 ClusterName")
+	}
+	_, hasHost := seen["slurmctldhost"]
+	_, hasControlMachine := seen["controlmachine"]
+	if !hasHost && !hasControlMachine {
+		add(SlurmConfError, cfg.Path, 0, "缺少必需参数 SlurmctldHost")
+	}
+	memoryScheduling := strings.Contains(strings.ToUpper(seen["selecttypeparameters"].Value), "MEMORY")
+
+	// 节点定义：重复、CPU 拓扑与内存
+	type nodeInfo struct {
+		node       models.SlurmConfNode
+		cpus       int64
+		realMemory int64
+	}
+	nodes := make(map[string]nodeInfo)
+	var nodeOrder []string
+	for _, node := range cfg.Nodes {
+		hosts, err := hostlist.Expand(node.NodeName)
+		if err != nil {
+			add(SlurmConfError, node.File, node.Line, "NodeName=%s 无法解析: %v", node.NodeName, err)
+			continue
+		}
+
+		info := nodeInfo{
+			node:       node,
+			cpus:       slurmConfNodeCPUs(node.Params),
+			realMemory: parseSlurmInt(slurmConfValue(node.Params, "RealMemory")),
+		}
+
+		cpus := parseSlurmInt(slurmConfValue(node.Params, "CPUs"))
+		sockets := parseSlurmInt(slurmConfValue(node.Params, "Sockets"))
+		cores := parseSlurmInt(slurmConfValue(node.Params, "CoresPerSocket"))
+		threads := parseSlurmInt(slurmConfValue(node.Params, "ThreadsPerCore"))
+		if cpus > 0 && sockets > 0 && cores > 0 && threads > 0 &&
+			cpus != sockets*cores*threads && cpus != sockets*cores {
+			add(SlurmConfWarning, node.File, node.Line, "NodeName=%s 的 CPUs=%d 与 Sockets×CoresPerSocket×ThreadsPerCore=%d 不一致",
+				node.NodeName, cpus, sockets*cores*threads)
+		}
+		if info.realMemory == 0 && memoryScheduling {
+			add(SlurmConfWarning, node.File, node.Line, "NodeName=%s 未设置 RealMemory，按内存调度时节点内存视为 1 MB", node.NodeName)
+		}
+
+		var exceeded []string
+		var hardwareMin int64
+		for _, host := range hosts {
+			if first, ok := nodes[host]; ok {
+				add(SlurmConfError, node.File, node.Line, "节点 %s 重复定义（首次定义于 %s:%d）", host, first.node.File, first.node.Line)
+				continue
+			}
+			nodes[host] = info
+			nodeOrder = append(nodeOrder, host)
+
+			if hw, ok := hardwareMemory[host]; ok && info.realMemory > hw {
+				exceeded = append(exceeded, host)
+				if hardwareMin == 0 || hw < hardwareMin {
+					hardwareMin = hw
+				}
+			}
+		}
+		if len(exceeded) > 0 {
+			add(SlurmConfError, node.File, node.Line, "节点 %s 配置的 RealMemory=%d MB 超过实际内存（最小 %d MB），会因 Low RealMemory 被置为 DRAIN",
+				hostlist.Compress(exceeded), info.realMemory, hardwareMin)
+		}
+	}
+
+	// 分区定义：重复、未定义的节点与内存限制
+	partitions := make(map[string]models.SlurmConfPartition)
+	inPartition := make(map[string]bool)
+	var defaults []string
+	for _, partition := range cfg.Partitions {
+		if first, ok := partitions[partition.PartitionName]; ok {
+			add(SlurmConfError, partition.File, partition.Line, "分区 %s 重复定义（首次定义于 %s:%d）", partition.PartitionName, first.File, first.Line)
+			continue
+		}
+		partitions[partition.PartitionName] = partition
+		if strings.EqualFold(slurmConfValue(partition.Params, "Default"), "YES") {
+			defaults = append(defaults, partition.PartitionName)
+		}
+
+		var members []string
+		switch {
+		case partition.Nodes == "":
+			add(SlurmConfWarning, partition.File, partition.Line, "分区 %s 没有配置节点", partition.PartitionName)
+		case strings.EqualFold(partition.Nodes, "ALL"):
+			members = nodeOrder
+		default:
+			hosts, err := hostlist.Expand(partition.Nodes)
+			if err != nil {
+				add(SlurmConfError, partition.File, partition.Line, "分区 %s 的 Nodes=%s 无法解析: %v", partition.PartitionName, partition.Nodes, err)
+				break
+			}
+			var undefined []string
+			for _, host := range hosts {
+				if nodeSets[host] {
+					continue
+				}
+				if _, ok := nodes[host]; !ok {
+					undefined = append(undefined, host)
+					continue
+				}
+				members = append(members, host)
+			}
+			if len(undefined) > 0 {
+				add(SlurmConfError, partition.File, partition.Line, "分区 %s 引用了未定义的节点 %s", partition.PartitionName, hostlist.Compress(undefined))
+			}
+		}
+
+		limits := []struct {
+			key     string
+			perCPU  bool
+			message string
+		}{
+			{"DefMemPerCPU", true, "默认内存"},
+			{"MaxMemPerCPU", true, "最大内存"},
+			{"DefMemPerNode", false, "默认内存"},
+			{"MaxMemPerNode", false, "最大内存"},
+		}
+		for _, limit := range limits {
+			value := parseSlurmInt(slurmConfValue(partition.Params, limit.key))
+			if value <= 0 {
+				continue
+			}
+			var exceeded []string
+			for _, host := range members {
+				info := nodes[host]
+				if info.realMemory == 0 {
+					continue
+				}
+				required := value
+				if limit.perCPU {
+					required = value * info.cpus
+				}
+				if required > info.realMemory {
+					exceeded = append(exceeded, host)
+				}
+			}
+			if len(exceeded) > 0 {
+				add(SlurmConfWarning, partition.File, partition.Line, "分区 %s 的 %s=%d MB 对应的%s超过节点 %s 的 RealMemory",
+					partition.PartitionName, limit.key, value, limit.message, hostlist.Compress(exceeded))
+			}
+		}
+
+		for _, host := range members {
+			inPartition[host] = true
+		}
+	}
+	if len(defaults) > 1 {
+		add(SlurmConfWarning, cfg.Path, 0, "多个分区设置了 Default=YES: %s", strings.Join(defaults, ", "))
+	}
+
+	if len(cfg.Partitions) > 0 {
+		var orphans []string
+		for _, host := range nodeOrder {
+			if !inPartition[host] {
+				orphans = append(orphans, host)
+			}
+		}
+		if len(orphans) > 0 {
+			add(SlurmConfWarning, cfg.Path, 0, "节点 %s 不属于任何分区", hostlist.Compress(orphans))
+		}
+	}
+	return issues
+}
+
+// collectNodeMemory 收集已知的节点实际内存（MB）
+// 计算节点来自 slurmd 上报的 Low RealMemory 原因，本机集群的管理节点自身读取 /proc/meminfo
+func collectNodeMemory(cluster *Cluster) map[string]int64 {
+	memory := make(map[string]int64)
+	nodes, _ := FetchComputeNodes(cluster)
+	for _, node := range nodes {
+		if match := lowRealMemoryPattern.FindStringSubmatch(node.Reason); match != nil {
+			memory[node.Hostname] = parseSlurmInt(match[1])
+		}
+	}
+	if !cluster.isLocal() {
+		return memory
+	}
+	if total := localMemoryMB(); total > 0 {
+		memory[getHostname()] = total
+	}
+	return memory
+}
+
+// localMemoryMB 读取本机内存总量（MB）
+func localMemoryMB() int64 {
+	file, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "MemTotal:" {
+			return parseSlurmInt(fields[1]) / 1024
+		}
+	}
+	return 0
+}
+
+// countSlurmConfErrors 统计 error 级别的问题数量
+func countSlurmConfErrors(issues []models.SlurmConfIssue) int {
+	count := 0
+	for _, issue := range issues {
+		if issue.Severity == SlurmConfError {
+			count++
+		}
+	}
+	return count
+}
+
+// resolveSlurmConfFile 只允许访问主配置文件及其 Include 的文件，空值表示主配置文件
+func resolveSlurmConfFile(cluster *Cluster, file string) (string, error) {
+	if cluster.restOnly() {
+		return "", cluster.errRESTOnly("slurm.conf 管理")
+	}
+	mainPath := filepath.Clean(cluster.confPath())
+	if file == "" {
+		return mainPath, nil
+	}
+	file = filepath.Clean(file)
+	if file == mainPath {
+		return file, nil
+	}
+
+	cfg, err := ParseSlurmConf(mainPath)
+	if err != nil {
+		return "", err
+	}
+	for _, f := range cfg.Files {
+		if f == file {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("文件 %s 不属于 Slurm 配置", file)
+}
+
+// ReadSlurmConfFile 读取主配置文件或其 Include 的文件内容
+func ReadSlurmConfFile(cluster *Cluster, file string) (string, string, error) {
+	path, err := resolveSlurmConfFile(cluster, file)
+	if err != nil {
+		return "", "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", fmt.Errorf("读取 %s 失败: %v", path, err)
+	}
+	return path, string(data), nil
+}
+
+// ValidateSlurmConfChange 在不写入文件的情况下校验修改后的配置
+func ValidateSlurmConfChange(cluster *Cluster, update SlurmConfUpdate) ([]models.SlurmConfIssue, error) {
+	path, err := resolveSlurmConfFile(cluster, update.File)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := validateSlurmConfContent(cluster, path, update.Content)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.Issues, nil
+}
+
+// validateSlurmConfContent 用新内容替换指定文件后重新解析并校验整个配置
+func validateSlurmConfContent(cluster *Cluster, path, content string) (*models.SlurmConfig, error) {
+	read := func(file string) ([]byte, error) {
+		if file == path {
+			return []byte(content), nil
+		}
+		return os.ReadFile(file)
+	}
+	cfg, err := parseSlurmConf(cluster.confPath(), read)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Issues = append(cfg.Issues, ValidateSlurmConf(cfg, collectNodeMemory(cluster))...)
+	return cfg, nil
+}
+
+// UpdateSlurmConfFile 校验并写入配置文件
+// 存在错误时默认拒绝写入；写入前备份原文件，并通过临时文件加重命名保证原子性
+func UpdateSlurmConfFile(cluster *Cluster, update SlurmConfUpdate) (*SlurmConfUpdateResult, error) {
+	slurmConfMutex.Lock()
+	defer slurmConfMutex.Unlock()
+
+	path, err := resolveSlurmConfFile(cluster, update.File)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := validateSlurmConfContent(cluster, path, update.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SlurmConfUpdateResult{File: path, Issues: cfg.Issues}
+	if count := countSlurmConfErrors(cfg.Issues); count > 0 && !update.Force {
+		return result, fmt.Errorf("配置校验发现 %d 个错误，未写入", count)
+	}
+
+	backup, err := backupSlurmConfFile(path)
+	if err != nil {
+		return result, err
+	}
+	result.Backup = backup
+
+	if err := writeFileAtomic(path, []byte(update.Content)); err != nil {
+		return result, err
+	}
+	result.Applied = true
+
+	if update.Reconfigure {
+		output, err := ReconfigureSlurm(cluster)
+		result.ReconfigureOutput = output
+		if err != nil {
+			return result, fmt.Errorf("配置已写入，但 scontrol reconfigure 失败: %v", err)
+		}
+	}
+	return result, nil
+}
+
+// ReconfigureSlurm 执行 scontrol reconfigure 使配置生效
+func ReconfigureSlurm(cluster *Cluster) (string, error) {
+	if cluster.restOnly() {
+		return "", cluster.errRESTOnly("scontrol reconfigure")
+	}
+	output, err := cluster.command("scontrol", "reconfigure").CombinedOutput()
+	if err != nil {
+		return string(output), fmt.Errorf("%v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return string(output), nil
+}
+
+// backupSlurmConfFile 将配置文件复制为 <文件>.bak.<时间戳>，并清理多余的旧备份
+func backupSlurmConfFile(path string) (string, error) {
+	src, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("打开 %s 失败: %v", path, err)
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return "", fmt.Errorf("读取 %s 属性失败: %v", path, err)
+	}
+
+	// 纳秒时间戳加 O_EXCL，同一时刻的多次保存追加序号，不会覆盖已有的备份
+	base := path + ".bak." + time.Now().Format("20060102-150405.000000000")
+	backup := base
+	dst, err := os.OpenFile(backup, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	for i := 1; os.IsExist(err) && i < 100; i++ {
+		backup = fmt.Sprintf("%s-%d", base, i)
+		dst, err = os.OpenFile(backup, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	}
+	if err != nil {
+		return "", fmt.Errorf("创建备份 %s 失败: %v", backup, err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return "", fmt.Errorf("写入备份 %s 失败: %v", backup, err)
+	}
+	if err := dst.Close(); err != nil {
+		return "", fmt.Errorf("写入备份 %s 失败: %v", backup, err)
+	}
+
+	// 时间戳格式按字典序即时间顺序，保留最新的若干份
+	if backups, err := filepath.Glob(path + ".bak.*"); err == nil && len(backups) > maxSlurmConfBackups {
+		sort.Strings(backups)
+		for _, old := range backups[:len(backups)-maxSlurmConfBackups] {
+			os.Remove(old)
+		}
+	}
+	return backup, nil
+}
+
+// writeFileAtomic 先写入同目录下的临时文件再重命名，保留原文件的权限和属主
+func writeFileAtomic(path string, data []byte) error {
+	mode := os.FileMode(0644)
+	info, statErr := os.Stat(path)
+	if statErr == nil {
+		mode = info.Mode().Perm()
+	}
+
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %v", err)
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入临时文件失败: %v", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("同步临时文件失败: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("关闭临时文件失败: %v", err)
+	}
+	if err := os.Chmod(tmpName, mode); err != nil {
+		return fmt.Errorf("设置文件权限失败: %v", err)
+	}
+	if statErr == nil {
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+			os.Chown(tmpName, int(stat.Uid), int(stat.Gid))
+		}
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		return fmt.Errorf("替换 %s 失败: %v", path, err)
+	}
+
+	// 同步目录，确保重命名落盘
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"panel-tool/internal/models"
+)
+
+// 作业历史分页参数默认值
+const (
+	defaultHistoryPageSize = 50
+	maxHistoryPageSize     = 500
+	defaultHistoryRange    = 7 * 24 * time.Hour
+)
+
+// JobHistoryQuery 作业历史查询条件
+type JobHistoryQuery struct {
+	// Cluster 执行 sacct 的集群，为 nil 时使用本机默认配置
+	Cluster *Cluster
+	// Clusters 通过 sacct --clusters 查询的集群（联邦/多集群共享 slurmdbd），为空时只查询本集群
+	Clusters  []string
+	Start     time.Time
+	End       time.Time
+	User      string
+	Account   string
+	Partition string
+	State     string // 逗号分隔，如 COMPLETED,FAILED
+	ExitCode  string // 精确匹配（如 1:0），或 nonzero 表示非零退出
+	// IncludeSteps 为 true 时同时返回作业步记录（如 123.batch），默认只返回作业分配记录
+	IncludeSteps bool
+	SortBy       string
+	Order        string // asc 或 desc
+	Page         int
+	PageSize     int
+}
+
+// JobHistoryPage 作业历史分页结果
+type JobHistoryPage struct {
+	Total    int                          `json:"total"`
+	Page     int                          `json:"page"`
+	PageSize int                          `json:"page_size"`
+	Jobs     []models.JobAccountingRecord `json:"jobs"`
+}
+
+// normalize 填充默认值并校验参数
+func (q *JobHistoryQuery) normalize() error {
+	if q.End.IsZero() {
+		q.End = time.Now()
+	}
+	if q.Start.IsZero() {
+		q.Start = q.End.Add(-defaultHistoryRange)
+	}
+	if q.Start.After(q.End) {
+		return fmt.Errorf("开始时间不能晚于结束时间")
+	}
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.PageSize < 1 {
+		q.PageSize = defaultHistoryPageSize
+	}
+	if q.PageSize > maxHistoryPageSize {
+		q.PageSize = maxHistoryPageSize
+	}
+	if q.SortBy == "" {
+		q.SortBy = "submit"
+	}
+	if _, ok := historySorters[q.SortBy]; !ok {
+		return fmt.Errorf("不支持的排序字段: %s", q.SortBy)
+	}
+	if q.Order != "asc" {
+		q.Order = "desc"
+	}
+	return nil
+}
+
+// sacctArgs 根据查询条件构造 sacct 参数
+func (q *JobHistoryQuery) sacctArgs() []string {
+	args := append([]string{
+		"--allusers",
+		"--starttime=" + q.Start.Format(slurmTimeLayout),
+		"--endtime=" + q.End.Format(slurmTimeLayout),
+	}, sacctOutputArgs()...)
+	if len(q.Clusters) > 0 {
+		args = append(args, "--clusters="+strings.Join(q.Clusters, ","))
+	}
+	if !q.IncludeSteps {
+		args = append(args, "--allocations")
+	}
+	if q.User != "" {
+		args = append(args, "--user="+q.User)
+	}
+	if q.Account != "" {
+		args = append(args, "--accounts="+q.Account)
+	}
+	if q.Partition != "" {
+		args = append(args, "--partition="+q.Partition)
+	}
+	if q.State != "" {
+		args = append(args, "--state="+q.State)
+	}
+	return args
+}
+
+// historySorters 支持的排序字段
+var historySorters = map[string]func(a, b models.JobAccountingRecord) bool{
+	"job_id":    func(a, b models.JobAccountingRecord) bool { return compareJobIDs(a.JobID, b.JobID) < 0 },
+	"user":      func(a, b models.JobAccountingRecord) bool { return a.User < b.User },
+	"cluster":   func(a, b models.JobAccountingRecord) bool { return a.Cluster < b.Cluster },
+	"account":   func(a, b models.JobAccountingRecord) bool { return a.Account < b.Account },
+	"partition": func(a, b models.JobAccountingRecord) bool { return a.Partition < b.Partition },
+	"state":     func(a, b models.JobAccountingRecord) bool { return a.State < b.State },
+	"submit":    func(a, b models.JobAccountingRecord) bool { return a.Submit.Before(b.Submit) },
+	"start":     func(a, b models.JobAccountingRecord) bool { return a.Start.Before(b.Start) },
+	"end":       func(a, b models.JobAccountingRecord) bool { return a.End.Before(b.End) },
+	"elapsed":   func(a, b models.JobAccountingRecord) bool { return a.Elapsed < b.Elapsed },
+	"cpus":      func(a, b models.JobAccountingRecord) bool { return a.AllocCPUs < b.AllocCPUs },
+}
+
+// compareJobIDs 按数值比较作业 ID，数组任务按任务号次序排列
+func compareJobIDs(a, b string) int {
+	splitID := func(id string) (int64, int64) {
+		base, task, _ := strings.Cut(id, "_")
+		return parseSlurmInt(base), parseSlurmInt(strings.Trim(task, "[]"))
+	}
+	aBase, aTask := splitID(a)
+	bBase, bTask := splitID(b)
+	switch {
+	case aBase != bBase:
+		if aBase < bBase {
+			return -1
+		}
+		return 1
+	case aTask != bTask:
+		if aTask < bTask {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// QueryJobHistory 查询作业历史记录（已筛选、排序，但未分页）
+func QueryJobHistory(q *JobHistoryQuery) ([]models.JobAccountingRecord, error) {
+	if err := q.normalize(); err != nil {
+		return nil, err
+	}
+
+	output, err := runSlurmCommand(q.Cluster, "sacct", q.sacctArgs()...)
+	if err != nil {
+		return nil, err
+	}
+
+	records := filterByExitCode(ParseSacctOutput(output), q.ExitCode)
+
+	less := historySorters[q.SortBy]
+	sort.SliceStable(records, func(i, j int) bool {
+		if q.Order == "asc" {
+			return less(records[i], records[j])
+		}
+		return less(records[j], records[i])
+	})
+	return records, nil
+}
+
+// GetJobHistoryPage 查询作业历史并返回指定页
+func GetJobHistoryPage(q *JobHistoryQuery) (*JobHistoryPage, error) {
+	records, err := QueryJobHistory(q)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &JobHistoryPage{
+		Total:    len(records),
+		Page:     q.Page,
+		PageSize: q.PageSize,
+		Jobs:     []models.JobAccountingRecord{},
+	}
+	start := (q.Page - 1) * q.PageSize
+	if start < len(records) {
+		end := start + q.PageSize
+		if end > len(records) {
+			end = len(records)
+		}
+		page.Jobs = records[start:end]
+	}
+	return page, nil
+}
+
+// filterByExitCode 按退出码筛选
+func filterByExitCode(records []models.JobAccountingRecord, exitCode string) []models.JobAccountingRecord {
+	if exitCode == "" {
+		return records
+	}
+	filtered := records[:0]
+	for _, record := range records {
+		if exitCode == "nonzero" {
+			if record.ExitCode != "0:0" {
+				filtered = append(filtered, record)
+			}
+		} else if record.ExitCode == exitCode {
+			filtered = append(filtered, record)
+		}
+	}
+	return filtered
+}
+
+// WriteJobHistoryCSV 将作业历史导出为 CSV
+func WriteJobHistoryCSV(w io.Writer, records []models.JobAccountingRecord) error {
+	writer := csv.NewWriter(w)
+	header := []string{
+		"job_id", "job_name", "user", "account", "partition", "state", "exit_code",
+		"submit", "start", "end", "elapsed_seconds", "total_cpu_seconds", "alloc_cpus",
+		"alloc_tres", "node_list",
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, r := range records {
+		row := []string{
+			r.JobID, r.JobName, r.User, r.Account, r.Partition, r.State, r.ExitCode,
+			formatCSVTime(r.Submit), formatCSVTime(r.Start), formatCSVTime(r.End),
+			strconv.FormatInt(r.Elapsed, 10),
+			strconv.FormatFloat(r.TotalCPU, 'f', 0, 64),
+			strconv.FormatInt(r.AllocCPUs, 10),
+			r.AllocTRES, r.NodeList,
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// formatCSVTime 格式化导出时间，零值输出为空
+func formatCSVTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
@@ -0,0 +1,379 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"panel-tool/internal/models"
+)
+
+// jobIDPattern 合法的作业 ID：普通作业、数组任务（123_4）或异构作业组件（123+0）
+var jobIDPattern = regexp.MustCompile(`^\d+(_\d+)?(\+\d+)?$`)
+
+// sacctFields sacct --format 使用的字段，顺序与 parseSacctLine 对应
+var sacctFields = []string{
+	"JobID", "JobName", "User", "Account", "Partition", "State", "ExitCode",
+	"Submit", "Start", "End", "ElapsedRaw", "TotalCPU", "AllocCPUS", "AllocTRES",
+	"ReqMem", "MaxRSS", "NodeList", "Cluster", "UID",
+}
+
+// sacctDelimiter sacct 输出的字段分隔符，作业名中可能含有 "|"，因此使用不会出现在作业名中的单元分隔符
+const sacctDelimiter = "\x1f"
+
+// sacctOutputArgs 与 ParseSacctOutput 对应的 sacct 输出格式参数
+func sacctOutputArgs() []string {
+	return []string{"--parsable2", "--noheader", "--delimiter=" + sacctDelimiter, "--format=" + strings.Join(sacctFields, ",")}
+}
+
+// jobOutputPollInterval 跟踪作业输出文件时的轮询间隔
+const jobOutputPollInterval = time.Second
+
+// jobOutputTailBytes 开始跟踪时回放的输出文件末尾字节数
+const jobOutputTailBytes = 64 * 1024
+
+// ValidJobID 检查作业 ID 格式是否合法
+func ValidJobID(jobID string) bool {
+	return jobIDPattern.MatchString(jobID)
+}
+
+// GetJobDetail 获取单个作业的完整信息
+// 作业仍在队列中时以 scontrol 为准，已离开队列时改用 sacct 的记账数据
+func GetJobDetail(cluster *Cluster, jobID string) (*models.JobDetail, error) {
+	if !ValidJobID(jobID) {
+		return nil, fmt.Errorf("无效的作业 ID: %s", jobID)
+	}
+
+	detail, _ := getQueuedJob(cluster, jobID)
+	accounting, acctErr := GetJobAccounting(cluster, jobID)
+	if detail == nil {
+		if acctErr != nil || len(accounting) == 0 {
+			return nil, fmt.Errorf("作业 %s 不存在", jobID)
+		}
+		detail = jobDetailFromAccounting(accounting)
+	}
+	detail.Accounting = accounting
+
+	// 批处理脚本需要作业所有者或管理员权限，获取失败时忽略
+	if script, err := runSlurmCommand(cluster, "scontrol", "write", "batch_script", jobID, "-"); err == nil {
+		detail.BatchScript = script
+	}
+
+	return detail, nil
+}
+
+// getQueuedJob 通过 scontrol 获取仍在队列中的作业
+func getQueuedJob(cluster *Cluster, jobID string) (*models.JobDetail, error) {
+	output, err := runSlurmCommand(cluster, "scontrol", "show", "job", "-dd", jobID)
+	if err != nil {
+		return nil, err
+	}
+	records := ParseScontrolRecords(output)
+	if len(records) == 0 {
+		return nil, fmt.Errorf("作业 %s 不在队列中", jobID)
+	}
+	return jobDetailFromScontrol(records[0]), nil
+}
+
+// JobOwner 返回作业所属用户，不读取批处理脚本等额外信息
+func JobOwner(cluster *Cluster, jobID string) (string, error) {
+	if !ValidJobID(jobID) {
+		return "", fmt.Errorf("无效的作业 ID: %s", jobID)
+	}
+	if detail, err := getQueuedJob(cluster, jobID); err == nil && detail.User != "" {
+		return detail.User, nil
+	}
+	records, err := GetJobAccounting(cluster, jobID)
+	if err != nil || len(records) == 0 || records[0].User == "" {
+		return "", fmt.Errorf("作业 %s 不存在", jobID)
+	}
+	return records[0].User, nil
+}
+
+// jobDetailFromScontrol 由 scontrol show job 记录构造作业详情
+func jobDetailFromScontrol(record map[string]string) *models.JobDetail {
+	detail := &models.JobDetail{
+		JobID:      record["JobId"],
+		Name:       record["JobName"],
+		User:       stripSlurmID(record["UserId"]),
+		Group:      stripSlurmID(record["GroupId"]),
+		Account:    record["Account"],
+		Partition:  record["Partition"],
+		QOS:        record["QOS"],
+		State:      record["JobState"],
+		Reason:     record["Reason"],
+		Dependency: record["Dependency"],
+		Priority:   parseSlurmInt(record["Priority"]),
+		ExitCode:   record["ExitCode"],
+		NodeList:   record["NodeList"],
+		BatchHost:  record["BatchHost"],
+		NumNodes:   parseSlurmInt(strings.SplitN(record["NumNodes"], "-", 2)[0]),
+		NumCPUs:    parseSlurmInt(strings.SplitN(record["NumCPUs"], "-", 2)[0]),
+		NumTasks:   parseSlurmInt(record["NumTasks"]),
+		TRES:       record["TRES"],
+		TimeLimit:  record["TimeLimit"],
+		RunTime:    record["RunTime"],
+		SubmitTime: parseSlurmTime(record["SubmitTime"]),
+		StartTime:  parseSlurmTime(record["StartTime"]),
+		EndTime:    parseSlurmTime(record["EndTime"]),
+		WorkDir:    record["WorkDir"],
+		Command:    record["Command"],
+		StdOut:     record["StdOut"],
+		StdErr:     record["StdErr"],
+		InQueue:    true,
+		Fields:     record,
+	}
+	if taskID, ok := record["ArrayTaskId"]; ok {
+		detail.ArrayJobID = record["ArrayJobId"]
+		detail.ArrayTaskID = taskID
+	}
+	if detail.NodeList == "(null)" {
+		detail.NodeList = ""
+	}
+	applyJobGres(detail)
+	return detail
+}
+
+// jobDetailFromAccounting 由 sacct 记录构造已离开队列的作业详情，第一条为作业本身，其余为作业步
+func jobDetailFromAccounting(records []models.JobAccountingRecord) *models.JobDetail {
+	job := records[0]
+	detail := &models.JobDetail{
+		JobID:      job.JobID,
+		Name:       job.JobName,
+		User:       job.User,
+		Account:    job.Account,
+		Partition:  job.Partition,
+		State:      job.State,
+		ExitCode:   job.ExitCode,
+		NodeList:   job.NodeList,
+		NumCPUs:    job.AllocCPUs,
+		TRES:       job.AllocTRES,
+		RunTime:    formatDuration(int(job.Elapsed)),
+		SubmitTime: job.Submit,
+		StartTime:  job.Start,
+		EndTime:    job.End,
+	}
+	if idx := strings.Index(job.JobID, "_"); idx > 0 {
+		detail.ArrayJobID = job.JobID[:idx]
+		detail.ArrayTaskID = job.JobID[idx+1:]
+	}
+	applyJobGres(detail)
+	return detail
+}
+
+// applyJobGres 从作业的 TRES 中提取 GRES 和 GPU 总数
+// 运行中的作业为已分配 TRES，排队中的作业为请求的 TRES
+func applyJobGres(detail *models.JobDetail) {
+	detail.Gres = GresFromTRES(detail.TRES)
+	detail.GPUs, _ = gresTotals(detail.Gres, "gpu")
+}
+
+// stripSlurmID 去掉 scontrol 中 user(1000) 形式的数字 ID
+func stripSlurmID(value string) string {
+	if idx := strings.Index(value, "("); idx > 0 {
+		return value[:idx]
+	}
+	return value
+}
+
+// GetJobAccounting 获取作业及其作业步的 sacct 记账数据
+func GetJobAccounting(cluster *Cluster, jobID string) ([]models.JobAccountingRecord, error) {
+	if !ValidJobID(jobID) {
+		return nil, fmt.Errorf("无效的作业 ID: %s", jobID)
+	}
+
+	output, err := runSlurmCommand(cluster, "sacct", append([]string{"-j", jobID}, sacctOutputArgs()...)...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSacctOutput(output), nil
+}
+
+// ParseSacctOutput 解析以 sacctOutputArgs 查询的 sacct 输出，字段顺序为 sacctFields
+func ParseSacctOutput(output string) []models.JobAccountingRecord {
+	var records []models.JobAccountingRecord
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if line == "" {
+			continue
+		}
+		if record, ok := parseSacctLine(line); ok {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// parseSacctLine 解析单行 sacct 输出，字段数与 sacctFields 不一致的行视为无效
+func parseSacctLine(line string) (models.JobAccountingRecord, bool) {
+	parts := strings.Split(line, sacctDelimiter)
+	if len(parts) != len(sacctFields) {
+		return models.JobAccountingRecord{}, false
+	}
+
+	totalCPU, _ := parseSlurmDuration(parts[11])
+	return models.JobAccountingRecord{
+		JobID:     parts[0],
+		JobName:   parts[1],
+		User:      parts[2],
+		Account:   parts[3],
+		Partition: parts[4],
+		State:     parts[5],
+		ExitCode:  parts[6],
+		Submit:    parseSlurmTime(parts[7]),
+		Start:     parseSlurmTime(parts[8]),
+		End:       parseSlurmTime(parts[9]),
+		Elapsed:   parseSlurmInt(parts[10]),
+		TotalCPU:  totalCPU,
+		AllocCPUs: parseSlurmInt(parts[12]),
+		AllocTRES: parts[13],
+		ReqMem:    parseSlurmSize(parts[14], 'M'),
+		MaxRSS:    parseSlurmSize(parts[15], 'K'),
+		NodeList:  parts[16],
+		Cluster:   parts[17],
+		UID:       parts[18],
+	}, true
+}
+
+// FollowJobOutput 持续读取作业的 stdout/stderr 文件并写入 logChan，作业结束或 stop 关闭时返回
+func FollowJobOutput(cluster *Cluster, jobID, stream string, stop <-chan struct{}, logChan chan<- string) error {
+	defer close(logChan)
+
+	detail, err := GetJobDetail(cluster, jobID)
+	if err != nil {
+		return err
+	}
+
+	path := detail.StdOut
+	if stream == "stderr" {
+		path = detail.StdErr
+	}
+	if path == "" || path == "/dev/null" {
+		return fmt.Errorf("作业 %s 没有可读取的 %s 文件", jobID, stream)
+	}
+	// 输出路径由作业所有者指定，面板以 root 运行，只读取属于作业所有者的文件
+	uid, ok := jobOwnerUID(detail)
+	if !ok {
+		return fmt.Errorf("无法确定作业 %s 的所有者", jobID)
+	}
+
+	var file *os.File
+	defer func() {
+		if file != nil {
+			file.Close()
+		}
+	}()
+
+	buf := make([]byte, 32*1024)
+	ticker := time.NewTicker(jobOutputPollInterval)
+	defer ticker.Stop()
+	lastCheck := time.Now()
+
+	for {
+		// 作业排队期间输出文件可能尚未创建
+		if file == nil {
+			// O_NONBLOCK 避免输出路径为 FIFO 时阻塞在打开上
+			file, err = os.OpenFile(path, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+			if err != nil {
+				file = nil
+				if !detail.InQueue || isJobFinished(detail.State) {
+					return fmt.Errorf("打开输出文件失败: %v", err)
+				}
+			} else if info, err := checkJobOutputFile(file, uid); err != nil {
+				return err
+			} else if info.Size() > jobOutputTailBytes {
+				// 先回放文件末尾的一段内容
+				file.Seek(info.Size()-jobOutputTailBytes, io.SeekStart)
+			}
+		}
+
+		// 读尽当前已有的内容
+		for file != nil {
+			n, err := file.Read(buf)
+			if n > 0 {
+				select {
+				case logChan <- string(buf[:n]):
+				case <-stop:
+					return nil
+				}
+			}
+			if err != nil {
+				break
+			}
+		}
+
+		if !detail.InQueue || isJobFinished(detail.State) {
+			return nil
+		}
+
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+
+		// 定期刷新作业状态，作业离开队列后再读一次即退出
+		if time.Since(lastCheck) >= 5*jobOutputPollInterval {
+			lastCheck = time.Now()
+			latest, err := getQueuedJob(cluster, jobID)
+			if err != nil {
+				detail.InQueue = false
+			} else {
+				detail = latest
+			}
+		}
+	}
+}
+
+// jobOwnerUID 从 scontrol 的 UserId=user(1000) 中取出作业所有者的 UID，没有时使用 sacct 记录的 UID
+func jobOwnerUID(detail *models.JobDetail) (uint32, bool) {
+	value := detail.Fields["UserId"]
+	if start, end := strings.Index(value, "("), strings.LastIndex(value, ")"); start >= 0 && end > start {
+		value = value[start+1 : end]
+	} else {
+		// 已离开队列的作业只有 sacct 记账数据，取作业记录（非作业步）的 UID
+		value = ""
+		for _, record := range detail.Accounting {
+			if record.UID != "" {
+				value = record.UID
+				break
+			}
+		}
+	}
+	uid, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return uint32(uid), true
+}
+
+// checkJobOutputFile 检查已打开的输出文件是普通文件且属于作业所有者，防止通过 --output 指向其他文件读取任意内容
+func checkJobOutputFile(file *os.File, uid uint32) (os.FileInfo, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("读取输出文件信息失败: %v", err)
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !info.Mode().IsRegular() || !ok || stat.Uid != uid {
+		return nil, fmt.Errorf("输出文件 %s 不属于作业所有者", file.Name())
+	}
+	return info, nil
+}
+
+// isJobFinished 判断作业状态是否已结束，状态为空时视为未结束
+func isJobFinished(state string) bool {
+	fields := strings.Fields(state)
+	if len(fields) == 0 {
+		return false
+	}
+	switch fields[0] {
+	case "PENDING", "RUNNING", "SUSPENDED", "COMPLETING", "CONFIGURING",
+		"REQUEUED", "RESIZING", "SIGNALING", "STAGE_OUT", "STOPPED":
+		return false
+	}
+	return true
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// slurmTimeLayout Slurm 命令输出中使用的时间格式（本地时间）
+const slurmTimeLayout = "2006-01-02T15:04:05"
+
+// scontrolKeyPattern 匹配 scontrol 输出中的 Key= 前缀
+var scontrolKeyPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_:/]*=`)
+
+// ParseScontrolRecords 解析 scontrol show 系列命令的输出
+// 每条记录以空行分隔，字段为空白分隔的 Key=Value，值中可能含有空格
+func ParseScontrolRecords(output string) []map[string]string {
+	var records []map[string]string
+	for _, block := range strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n\n") {
+		if strings.TrimSpace(block) == "" {
+			continue
+		}
+		record := ParseScontrolRecord(block)
+		if len(record) > 0 {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// ParseScontrolRecord 解析单条 scontrol 记录，重复出现的键保留第一次的值
+func ParseScontrolRecord(block string) map[string]string {
+	record := make(map[string]string)
+	lastKey := ""
+	for _, token := range strings.Fields(block) {
+		if loc := scontrolKeyPattern.FindStringIndex(token); loc != nil {
+			key := token[:loc[1]-1]
+			if _, exists := record[key]; exists {
+				// 重复键（如 -dd 输出中的每节点明细）不覆盖，后续值也不拼接
+				lastKey = ""
+				continue
+			}
+			record[key] = token[loc[1]:]
+			lastKey = key
+			continue
+		}
+		if lastKey != "" {
+			record[lastKey] += " " + token
+		}
+	}
+	return record
+}
+
+// parseSlurmTime 解析 Slurm 时间，Unknown/None 等返回零值
+func parseSlurmTime(value string) time.Time {
+	switch value {
+	case "", "Unknown", "None", "N/A", "(null)":
+		return time.Time{}
+	}
+	t, err := time.ParseInLocation(slurmTimeLayout, value, time.Local)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// parseSlurmDuration 解析 Slurm 时长为秒数
+// 不带天数时为 MM、MM:SS（可带 .mmm）或 HH:MM:SS；带天数时为 D-HH、D-HH:MM 或 D-HH:MM:SS
+func parseSlurmDuration(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	switch value {
+	case "", "UNLIMITED", "Partition_Limit", "INVALID", "NOT_SET":
+		return 0, fmt.Errorf("无效的时长: %q", value)
+	}
+
+	days := 0.0
+	hasDays := false
+	if idx := strings.Index(value, "-"); idx >= 0 {
+		d, err := strconv.Atoi(value[:idx])
+		if err != nil {
+			return 0, fmt.Errorf("无效的时长: %q", value)
+		}
+		days = float64(d)
+		hasDays = true
+		value = value[idx+1:]
+	}
+
+	parts := strings.Split(value, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("无效的时长: %q", value)
+	}
+	// 带天数时剩余部分从小时开始，"1-02" 为 1 天 2 小时，"1-02:03" 为 1 天 2 小时 3 分钟
+	if hasDays {
+		for len(parts) < 3 {
+			parts = append(parts, "0")
+		}
+	}
+
+	total := 0.0
+	for _, part := range parts {
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0, fmt.Errorf("无效的时长: %q", value)
+		}
+		total = total*60 + n
+	}
+	// 仅一段时表示分钟
+	if !hasDays && len(parts) == 1 {
+		total *= 60
+	}
+	return days*86400 + total, nil
+}
+
+// parseSlurmSize 解析 Slurm 容量（如 1234K、4G、4000M、4Gn），单位缺省为 defaultUnit，返回字节数
+func parseSlurmSize(value string, defaultUnit byte) int64 {
+	value = strings.TrimSpace(value)
+	// 旧版本 ReqMem 以 n/c 后缀表示每节点/每 CPU
+	value = strings.TrimRight(value, "nc")
+	if value == "" || value == "0" {
+		return 0
+	}
+
+	unit := defaultUnit
+	last := value[len(value)-1]
+	if last < '0' || last > '9' {
+		unit = last
+		value = value[:len(value)-1]
+	}
+
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+
+	switch unit {
+	case 'K', 'k':
+		n *= 1 << 10
+	case 'M', 'm':
+		n *= 1 << 20
+	case 'G', 'g':
+		n *= 1 << 30
+	case 'T', 't':
+		n *= 1 << 40
+	case 'P', 'p':
+		n *= 1 << 50
+	}
+	return int64(n)
+}
+
+// parseSlurmInt 解析整数字段，失败返回 0
+func parseSlurmInt(value string) int64 {
+	n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// parseTRES 解析 TRES 字符串（如 cpu=4,mem=16G,gres/gpu=2）为键值表
+func parseTRES(value string) map[string]string {
+	tres := make(map[string]string)
+	for _, item := range strings.Split(value, ",") {
+		key, val, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok || key == "" {
+			continue
+		}
+		tres[key] = val
+	}
+	return tres
+}
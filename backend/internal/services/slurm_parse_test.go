@@ -0,0 +1,75 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSlurmDuration(t *testing.T) {
+	tests := []struct {
+		value string
+		want  float64
+	}{
+		{"45", 45 * 60},
+		{"12:03", 12*60 + 3},
+		{"02:03.500", 2*60 + 3.5},
+		{"1:02:03", 3723},
+		{"00:00:00", 0},
+		{"1-02", 86400 + 2*3600},
+		{"1-02:03", 93780},
+		{"1-02:03:04", 93784},
+		{"10-00:00:00", 864000},
+	}
+	for _, tt := range tests {
+		got, err := parseSlurmDuration(tt.value)
+		if err != nil || got != tt.want {
+			t.Errorf("parseSlurmDuration(%q) = %v, %v, want %v", tt.value, got, err, tt.want)
+		}
+	}
+
+	for _, value := range []string{"", "UNLIMITED", "Partition_Limit", "1:2:3:4", "x-01:00", "ab:cd"} {
+		if _, err := parseSlurmDuration(value); err == nil {
+			t.Errorf("parseSlurmDuration(%q) = nil error", value)
+		}
+	}
+}
+
+func TestParseSacctOutput(t *testing.T) {
+	records := ParseSacctOutput(readFixture(t, "sacct_parsable.txt"))
+
+	var ids []string
+	for _, record := range records {
+		ids = append(ids, record.JobID)
+	}
+	// 4244 少了 UID 字段，字段数不一致的行不解析
+	if want := []string{"4242", "4242.batch", "4243_7"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("job ids = %v, want %v", ids, want)
+	}
+
+	job, step, failed := records[0], records[1], records[2]
+	if job.JobName != "train|resnet" || job.User != "alice" || job.UID != "1001" || job.Cluster != "alpha" {
+		t.Errorf("job = %+v", job)
+	}
+	if job.TotalCPU != 93784 || job.Elapsed != 8024 || job.AllocCPUs != 16 || job.ReqMem != 64<<30 {
+		t.Errorf("job usage = cpu %v elapsed %d alloc %d mem %d", job.TotalCPU, job.Elapsed, job.AllocCPUs, job.ReqMem)
+	}
+	if want := time.Date(2024, 3, 5, 10, 13, 49, 0, time.Local); !job.End.Equal(want) {
+		t.Errorf("job end = %v, want %v", job.End, want)
+	}
+	if step.UID != "" || step.TotalCPU != 123.5 || step.MaxRSS != 48213<<10 {
+		t.Errorf("step = %+v", step)
+	}
+	if failed.State != "FAILED" || failed.ExitCode != "1:0" || failed.TotalCPU != 600 || failed.ReqMem != 2000<<20 || failed.NodeList != "cn[001-002]" {
+		t.Errorf("failed = %+v", failed)
+	}
+}
+
+func TestSacctOutputArgs(t *testing.T) {
+	args := sacctOutputArgs()
+	joined := strings.Join(args, " ")
+	if !strings.Contains(joined, "--delimiter="+sacctDelimiter) || !strings.Contains(joined, "--format="+strings.Join(sacctFields, ",")) {
+		t.Errorf("sacctOutputArgs() = %q", args)
+	}
+}
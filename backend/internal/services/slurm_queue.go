@@ -0,0 +1,212 @@
+package services
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"panel-tool/internal/models"
+)
+
+// squeueFormat 队列查询使用的 squeue 输出格式，顺序与 ParseSqueueOutput 对应
+// %b 为每节点请求的 TRES（如 gres/gpu:a100:4），%D 为节点数
+const squeueFormat = "%i|%j|%u|%T|%M|%l|%N|%P|%r|%Q|%V|%b|%D"
+
+// pendingReasons 常见排队原因及其说明
+var pendingReasons = map[string]string{
+	"None":                          "暂无排队原因，调度器尚未评估该作业",
+	"Priority":                      "分区中存在优先级更高的作业，正在等待它们先调度",
+	"Resources":                     "作业优先级最高，正在等待所需的节点、CPU 或内存资源释放",
+	"Dependency":                    "正在等待所依赖的作业满足条件",
+	"DependencyNeverSatisfied":      "依赖的作业已失败或取消，该作业永远不会开始，需要取消或修改依赖",
+	"BeginTime":                     "作业指定的最早开始时间尚未到达",
+	"JobHeldUser":                   "作业被用户挂起，需执行 scontrol release 释放",
+	"JobHeldAdmin":                  "作业被管理员挂起",
+	"ReqNodeNotAvail":               "请求的节点当前不可用（宕机、排空或被预约）",
+	"PartitionDown":                 "分区处于 DOWN 状态",
+	"PartitionInactive":             "分区处于 INACTIVE 状态",
+	"PartitionNodeLimit":            "请求的节点数超出分区限制",
+	"PartitionTimeLimit":            "请求的运行时间超出分区的最大时限",
+	"PartitionConfig":               "请求的资源不符合分区配置",
+	"Reservation":                   "正在等待预约时间窗口开始",
+	"Licenses":                      "正在等待许可证（License）释放",
+	"AssocGrpCpuLimit":              "账户关联的 CPU 总量已达上限",
+	"AssocGrpJobsLimit":             "账户关联的运行作业数已达上限",
+	"AssocGrpNodeLimit":             "账户关联的节点总量已达上限",
+	"AssocGrpMemLimit":              "账户关联的内存总量已达上限",
+	"AssocGrpGRES":                  "账户关联的 GRES 总量已达上限",
+	"AssocMaxJobsLimit":             "用户在该关联下的最大运行作业数已达上限",
+	"AssocGrpCPUMinutesLimit":       "账户关联的 CPU 分钟配额已用尽",
+	"QOSMaxCpuPerUserLimit":         "用户在该 QoS 下使用的 CPU 数已达上限",
+	"QOSMaxJobsPerUserLimit":        "用户在该 QoS 下的运行作业数已达上限",
+	"QOSMaxNodePerUserLimit":        "用户在该 QoS 下使用的节点数已达上限",
+	"QOSMaxGRESPerUser":             "用户在该 QoS 下使用的 GRES 已达上限",
+	"QOSMaxWallDurationPerJobLimit": "请求的运行时间超出 QoS 允许的最大时长",
+	"QOSGrpCpuLimit":                "QoS 的 CPU 总量已达上限",
+	"QOSGrpNodeLimit":               "QoS 的节点总量已达上限",
+	"QOSGrpJobsLimit":               "QoS 的运行作业总数已达上限",
+	"QOSJobLimit":                   "QoS 的作业数已达上限",
+	"QOSResourceLimit":              "QoS 的资源限制已达上限",
+	"QOSNotAllowed":                 "作业使用的 QoS 不被允许",
+	"AccountNotAllowed":             "作业使用的账户不被允许提交到该分区",
+	"InvalidAccount":                "作业使用的账户无效",
+	"InvalidQOS":                    "作业使用的 QoS 无效",
+	"NodeDown":                      "作业所需的节点已宕机",
+	"BadConstraints":                "作业的约束条件无法被任何节点满足",
+	"launch failed requeued held":   "作业启动失败后被重新排队并挂起",
+	"Prolog":                        "正在执行 Prolog 脚本",
+	"Cleaning":                      "作业正在清理，稍后将重新排队",
+	"SystemFailure":                 "Slurm 系统故障导致作业无法调度",
+	"FrontEndDown":                  "前端节点不可用",
+	"MaxRequeue":                    "作业重新排队次数已达上限",
+}
+
+// ExplainPendingReason 将 Slurm 排队原因转换为可读说明
+// 形如 "ReqNodeNotAvail, UnavailableNodes:cn01" 的原因按逗号前的部分匹配
+func ExplainPendingReason(reason string) string {
+	if reason == "" {
+		return ""
+	}
+	if text, ok := pendingReasons[reason]; ok {
+		return text
+	}
+	key := strings.TrimSpace(strings.SplitN(reason, ",", 2)[0])
+	if text, ok := pendingReasons[key]; ok {
+		return text
+	}
+	// 未收录的 QoS/关联限制按前缀给出通用说明
+	switch {
+	case strings.HasPrefix(key, "QOS"):
+		return "作业受 QoS 限制，需等待资源使用量下降"
+	case strings.HasPrefix(key, "Assoc"):
+		return "作业受账户关联限制，需等待资源使用量下降"
+	}
+	return "Slurm 排队原因: " + reason
+}
+
+// GetQueueJobs 获取队列中的作业，包含排队原因、分区内排队位置和预计开始时间
+// 只配置 slurmrestd 的集群从 /jobs 接口读取
+func GetQueueJobs(cluster *Cluster) ([]models.JobModel, error) {
+	if cluster.restOnly() {
+		return fetchRESTJobs(cluster)
+	}
+	output, err := runSlurmCommand(cluster, "squeue", "--all", "--states=all", "--noheader", "--format="+squeueFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := ParseSqueueOutput(output)
+	AssignQueuePositions(jobs)
+	for i := range jobs {
+		jobs[i].Cluster = cluster.ClusterName()
+	}
+
+	// 预计开始时间来自调度器（squeue --start），查询失败时不影响其他字段
+	if starts, err := getExpectedStartTimes(cluster); err == nil {
+		for i := range jobs {
+			if t, ok := starts[jobs[i].JobID]; ok {
+				jobs[i].ExpectedStart = &t
+			}
+		}
+	}
+	return jobs, nil
+}
+
+// ParseSqueueOutput 解析 squeue --format=squeueFormat 的输出
+func ParseSqueueOutput(output string) []models.JobModel {
+	jobs := []models.JobModel{}
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		parts := strings.Split(line, "|")
+		if len(parts) != 13 {
+			continue
+		}
+
+		job := models.JobModel{
+			JobID:          parts[0],
+			Name:           parts[1],
+			User:           parts[2],
+			Status:         normalizeJobState(parts[3]),
+			ComputeTime:    parts[4],
+			NodeList:       parts[6],
+			Partition:      parts[7],
+			Priority:       parseSlurmInt(parts[9]),
+			SubmissionTime: parseSlurmTime(parts[10]),
+		}
+		// %l 为时间限制而非等待时间；squeue 不提供开始运行前的等待时长，只为排队中的作业计算
+		if job.Status == "pending" && !job.SubmissionTime.IsZero() {
+			job.WaitTime = formatDuration(int(time.Since(job.SubmissionTime).Seconds()))
+		}
+		if gres := ParseGres(parts[11]); len(gres) > 0 {
+			job.Gres = gres
+			perNode, _ := gresTotals(gres, "gpu")
+			nodes := parseSlurmInt(strings.SplitN(parts[12], "-", 2)[0])
+			if nodes < 1 {
+				nodes = 1
+			}
+			job.GPUs = perNode * nodes
+		}
+		if job.Status == "pending" && parts[8] != "" {
+			job.Reason = parts[8]
+			job.ReasonDescription = ExplainPendingReason(parts[8])
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs
+}
+
+// normalizeJobState 将 Slurm 状态转换为小写形式，PD/R 等缩写展开为完整名称
+func normalizeJobState(state string) string {
+	switch strings.ToLower(state) {
+	case "pending", "pd":
+		return "pending"
+	case "running", "r":
+		return "running"
+	}
+	return strings.ToLower(state)
+}
+
+// AssignQueuePositions 计算排队作业在各自分区中的位置（按优先级降序、提交时间升序）
+// 作业提交到多个分区时（如 a,b）按第一个分区计算
+func AssignQueuePositions(jobs []models.JobModel) {
+	byPartition := make(map[string][]int)
+	for i, job := range jobs {
+		if job.Status != "pending" {
+			continue
+		}
+		partition := strings.SplitN(job.Partition, ",", 2)[0]
+		byPartition[partition] = append(byPartition[partition], i)
+	}
+
+	for _, indexes := range byPartition {
+		sort.SliceStable(indexes, func(a, b int) bool {
+			ja, jb := jobs[indexes[a]], jobs[indexes[b]]
+			if ja.Priority != jb.Priority {
+				return ja.Priority > jb.Priority
+			}
+			return ja.SubmissionTime.Before(jb.SubmissionTime)
+		})
+		for pos, idx := range indexes {
+			jobs[idx].QueuePosition = pos + 1
+		}
+	}
+}
+
+// getExpectedStartTimes 通过 squeue --start 获取调度器估算的作业开始时间
+func getExpectedStartTimes(cluster *Cluster) (map[string]time.Time, error) {
+	output, err := runSlurmCommand(cluster, "squeue", "--start", "--noheader", "--format=%i|%S")
+	if err != nil {
+		return nil, err
+	}
+
+	starts := make(map[string]time.Time)
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		id, start, ok := strings.Cut(line, "|")
+		if !ok {
+			continue
+		}
+		if t := parseSlurmTime(strings.TrimSpace(start)); !t.IsZero() {
+			starts[strings.TrimSpace(id)] = t
+		}
+	}
+	return starts, nil
+}
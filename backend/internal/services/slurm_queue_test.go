@@ -0,0 +1,76 @@
+package services
+
+import "testing"
+
+func TestParseSqueueOutput(t *testing.T) {
+	jobs := ParseSqueueOutput(readFixture(t, "squeue_pending.txt"))
+	AssignQueuePositions(jobs)
+
+	// 字段数不足的 211 不解析
+	tests := []struct {
+		jobID    string
+		status   string
+		reason   string
+		explain  string
+		position int
+		gpus     int64
+	}{
+		{"201", "running", "", "", 0, 0},
+		{"202", "pending", "Priority", pendingReasons["Priority"], 3, 1},
+		// 节点数为范围时按最小值计算 GPU 数
+		{"203", "pending", "Resources", pendingReasons["Resources"], 1, 8},
+		// 提交到多个分区时按第一个分区排队；优先级相同时先提交的在前
+		{"204", "pending", "Priority", pendingReasons["Priority"], 2, 1},
+		{"205", "pending", "ReqNodeNotAvail, UnavailableNodes:cn[005-006]", pendingReasons["ReqNodeNotAvail"], 1, 0},
+		{"206", "pending", "QOSMaxBillingPerUser", "作业受 QoS 限制，需等待资源使用量下降", 2, 0},
+		{"207", "pending", "AssocGrpBillingMinutes", "作业受账户关联限制，需等待资源使用量下降", 3, 0},
+		{"208", "pending", "SomethingNew", "Slurm 排队原因: SomethingNew", 1, 0},
+		{"209", "pending", "Dependency", pendingReasons["Dependency"], 4, 0},
+		{"210", "completing", "", "", 0, 0},
+	}
+	if len(jobs) != len(tests) {
+		t.Fatalf("got %d jobs, want %d", len(jobs), len(tests))
+	}
+	for i, tt := range tests {
+		t.Run(tt.jobID, func(t *testing.T) {
+			job := jobs[i]
+			if job.JobID != tt.jobID || job.Status != tt.status || job.QueuePosition != tt.position || job.GPUs != tt.gpus {
+				t.Errorf("job = %s %s position %d gpus %d, want %s %s %d %d", job.JobID, job.Status, job.QueuePosition, job.GPUs, tt.jobID, tt.status, tt.position, tt.gpus)
+			}
+			if job.Reason != tt.reason || job.ReasonDescription != tt.explain {
+				t.Errorf("reason = %q (%s), want %q (%s)", job.Reason, job.ReasonDescription, tt.reason, tt.explain)
+			}
+			// 等待时间只为排队中的作业计算
+			if (job.WaitTime != "") != (tt.status == "pending") {
+				t.Errorf("wait time = %q for %s job", job.WaitTime, job.Status)
+			}
+		})
+	}
+
+	running := jobs[0]
+	if running.Name != "lammps" || running.User != "alice" || running.ComputeTime != "3:12:09" || running.NodeList != "cn[001-004]" || running.Priority != 12000 {
+		t.Errorf("running job = %+v", running)
+	}
+	if running.SubmissionTime.IsZero() || running.SubmissionTime.Hour() != 6 {
+		t.Errorf("running job submitted at %v", running.SubmissionTime)
+	}
+}
+
+func TestExplainPendingReason(t *testing.T) {
+	tests := []struct {
+		reason, want string
+	}{
+		{"", ""},
+		{"Resources", pendingReasons["Resources"]},
+		{"launch failed requeued held", pendingReasons["launch failed requeued held"]},
+		{"ReqNodeNotAvail, Reserved for maintenance", pendingReasons["ReqNodeNotAvail"]},
+		{"QOSGrpGRES", "作业受 QoS 限制，需等待资源使用量下降"},
+		{"AssocMaxWallDurationPerJobLimit", "作业受账户关联限制，需等待资源使用量下降"},
+		{"WaitingForScheduling", "Slurm 排队原因: WaitingForScheduling"},
+	}
+	for _, tt := range tests {
+		if got := ExplainPendingReason(tt.reason); got != tt.want {
+			t.Errorf("ExplainPendingReason(%q) = %q, want %q", tt.reason, got, tt.want)
+		}
+	}
+}
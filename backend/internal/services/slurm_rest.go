@@ -0,0 +1,439 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"panel-tool/internal/models"
+)
+
+// defaultRestVersion 未配置 rest_version 时使用的 slurmrestd 接口版本
+const defaultRestVersion = "v0.0.40"
+
+// restTimeout 访问 slurmrestd 的超时时间
+const restTimeout = 15 * time.Second
+
+var restClient = &http.Client{Timeout: restTimeout}
+
+// restNumber slurmrestd 的数值字段，v0.0.40 起为 {"set":true,"infinite":false,"number":N}，旧版本为普通数字
+type restNumber struct {
+	Set      bool
+	Infinite bool
+	Number   int64
+}
+
+func (n *restNumber) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var plain int64
+	if err := json.Unmarshal(data, &plain); err == nil {
+		*n = restNumber{Set: true, Number: plain}
+		return nil
+	}
+	var wrapped struct {
+		Set      bool  `json:"set"`
+		Infinite bool  `json:"infinite"`
+		Number   int64 `json:"number"`
+	}
+	if err := json.Unmarshal(data, &wrapped); err != nil {
+		return err
+	}
+	*n = restNumber(wrapped)
+	return nil
+}
+
+// restStrings slurmrestd 的字符串列表字段，旧版本可能为逗号分隔的字符串
+type restStrings []string
+
+func (s *restStrings) UnmarshalJSON(data []byte) error {
+	var list []string
+	if err := json.Unmarshal(data, &list); err == nil {
+		*s = list
+		return nil
+	}
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	*s = splitSlurmList(value)
+	return nil
+}
+
+// restErrors slurmrestd 响应中的错误列表
+type restErrors struct {
+	Errors []struct {
+		Error       string `json:"error"`
+		Description string `json:"description"`
+	} `json:"errors"`
+}
+
+// err 将响应中的第一条错误转换为 error，没有错误时返回 nil
+func (e restErrors) err() error {
+	for _, item := range e.Errors {
+		msg := item.Description
+		if msg == "" {
+			msg = item.Error
+		}
+		if msg != "" {
+			return fmt.Errorf("slurmrestd 返回错误: %s", msg)
+		}
+	}
+	return nil
+}
+
+// restJob slurmrestd /jobs 返回的作业，只包含面板使用的字段
+type restJob struct {
+	JobID           int64       `json:"job_id"`
+	ArrayJobID      restNumber  `json:"array_job_id"`
+	ArrayTaskID     restNumber  `json:"array_task_id"`
+	ArrayTaskString string      `json:"array_task_string"`
+	Name            string      `json:"name"`
+	UserName        string      `json:"user_name"`
+	JobState        restStrings `json:"job_state"`
+	Partition       string      `json:"partition"`
+	Nodes           string      `json:"nodes"`
+	NodeCount       restNumber  `json:"node_count"`
+	Priority        restNumber  `json:"priority"`
+	SubmitTime      restNumber  `json:"submit_time"`
+	StartTime       restNumber  `json:"start_time"`
+	EndTime         restNumber  `json:"end_time"`
+	StateReason     string      `json:"state_reason"`
+	TRESPerNode     string      `json:"tres_per_node"`
+}
+
+// restNode slurmrestd /nodes 返回的节点
+type restNode struct {
+	Name           string      `json:"name"`
+	Address        string      `json:"address"`
+	Architecture   string      `json:"architecture"`
+	State          restStrings `json:"state"`
+	Partitions     restStrings `json:"partitions"`
+	CPUs           int64       `json:"cpus"`
+	AllocCPUs      int64       `json:"alloc_cpus"`
+	CPULoad        restNumber  `json:"cpu_load"`
+	RealMemory     int64       `json:"real_memory"`
+	AllocMemory    int64       `json:"alloc_memory"`
+	FreeMem        restNumber  `json:"free_mem"`
+	Gres           string      `json:"gres"`
+	GresUsed       string      `json:"gres_used"`
+	ActiveFeatures restStrings `json:"active_features"`
+	Reason         string      `json:"reason"`
+	BootTime       restNumber  `json:"boot_time"`
+}
+
+// restPartition slurmrestd /partitions 返回的分区
+type restPartition struct {
+	Name  string      `json:"name"`
+	Flags restStrings `json:"flags"`
+	Nodes struct {
+		Configured string `json:"configured"`
+		Total      int64  `json:"total"`
+	} `json:"nodes"`
+	CPUs struct {
+		Total int64 `json:"total"`
+	} `json:"cpus"`
+	Partition struct {
+		State restStrings `json:"state"`
+	} `json:"partition"`
+	Defaults restPartitionLimits `json:"defaults"`
+	Maximums restPartitionLimits `json:"maximums"`
+	Accounts struct {
+		Allowed string `json:"allowed"`
+		Deny    string `json:"deny"`
+	} `json:"accounts"`
+	QOS struct {
+		Allowed  string `json:"allowed"`
+		Assigned string `json:"assigned"`
+	} `json:"qos"`
+}
+
+// restPartitionLimits 分区的默认值或上限，时间单位为分钟，内存单位为 MB
+type restPartitionLimits struct {
+	Time          restNumber `json:"time"`
+	MemoryPerCPU  restNumber `json:"partition_memory_per_cpu"`
+	MemoryPerNode restNumber `json:"partition_memory_per_node"`
+}
+
+// restGet 请求集群 slurmrestd 的 /slurm/<版本>/<resource> 接口并解析 JSON 响应
+func (c *Cluster) restGet(resource string, v interface{}) error {
+	url := strings.TrimRight(c.RestURL, "/") + "/slurm/" + c.restVersion() + "/" + resource
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("创建 slurmrestd 请求失败: %v", err)
+	}
+	if token := c.restToken(); token != "" {
+		req.Header.Set("X-SLURM-USER-TOKEN", token)
+	}
+	if c.RestUser != "" {
+		req.Header.Set("X-SLURM-USER-NAME", c.RestUser)
+	}
+
+	resp, err := restClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("访问 slurmrestd 失败: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取 slurmrestd 响应失败: %v", err)
+	}
+
+	var errs restErrors
+	json.Unmarshal(body, &errs)
+	if err := errs.err(); err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slurmrestd 返回 %s", resp.Status)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("解析 slurmrestd 响应失败: %v", err)
+	}
+	return nil
+}
+
+// restVersion 返回 slurmrestd 接口版本
+func (c *Cluster) restVersion() string {
+	if c.RestVersion != "" {
+		return c.RestVersion
+	}
+	return defaultRestVersion
+}
+
+// restToken 返回访问 slurmrestd 的 JWT，未配置 rest_token 时使用 SLURM_JWT 环境变量
+func (c *Cluster) restToken() string {
+	if c.RestToken != "" {
+		return c.RestToken
+	}
+	return os.Getenv("SLURM_JWT")
+}
+
+// fetchRESTJobs 通过 slurmrestd 获取队列中的作业
+func fetchRESTJobs(cluster *Cluster) ([]models.JobModel, error) {
+	var resp struct {
+		Jobs []restJob `json:"jobs"`
+	}
+	if err := cluster.restGet("jobs", &resp); err != nil {
+		return nil, err
+	}
+	jobs := make([]models.JobModel, 0, len(resp.Jobs))
+	for _, rj := range resp.Jobs {
+		job := jobFromREST(rj, time.Now())
+		job.Cluster = cluster.ClusterName()
+		jobs = append(jobs, job)
+	}
+	AssignQueuePositions(jobs)
+	return jobs, nil
+}
+
+// jobFromREST 将 slurmrestd 作业转换为与 squeue 输出一致的作业信息
+func jobFromREST(rj restJob, now time.Time) models.JobModel {
+	job := models.JobModel{
+		JobID:     strconv.FormatInt(rj.JobID, 10),
+		Name:      rj.Name,
+		User:      rj.UserName,
+		NodeList:  rj.Nodes,
+		Partition: rj.Partition,
+		Priority:  rj.Priority.Number,
+	}
+	// 与 squeue %i 一致，数组任务显示为 <数组作业>_<任务>，尚未展开的任务显示为 <数组作业>_[范围]
+	if rj.ArrayJobID.Number > 0 {
+		switch {
+		case rj.ArrayTaskString != "":
+			job.JobID = fmt.Sprintf("%d_[%s]", rj.ArrayJobID.Number, rj.ArrayTaskString)
+		case rj.ArrayTaskID.Set && !rj.ArrayTaskID.Infinite:
+			job.JobID = fmt.Sprintf("%d_%d", rj.ArrayJobID.Number, rj.ArrayTaskID.Number)
+		}
+	}
+	if len(rj.JobState) > 0 {
+		job.Status = normalizeJobState(rj.JobState[0])
+	}
+	if rj.SubmitTime.Number > 0 {
+		job.SubmissionTime = time.Unix(rj.SubmitTime.Number, 0)
+	}
+
+	// ComputeTime 对应 squeue %M：运行中为已运行时长，已结束为实际运行时长
+	start := rj.StartTime.Number
+	switch {
+	case job.Status == "pending":
+		job.ComputeTime = formatDuration(0)
+		if !job.SubmissionTime.IsZero() {
+			job.WaitTime = formatDuration(int(now.Sub(job.SubmissionTime).Seconds()))
+		}
+		if start > 0 {
+			expected := time.Unix(start, 0)
+			job.ExpectedStart = &expected
+		}
+		if reason := cleanSlurmValue(rj.StateReason); reason != "" {
+			job.Reason = reason
+			job.ReasonDescription = ExplainPendingReason(reason)
+		}
+	case job.Status == "running" && start > 0:
+		job.ComputeTime = formatDuration(int(now.Unix() - start))
+	case start > 0 && rj.EndTime.Number >= start:
+		job.ComputeTime = formatDuration(int(rj.EndTime.Number - start))
+	default:
+		job.ComputeTime = formatDuration(0)
+	}
+
+	if gres := ParseGres(rj.TRESPerNode); len(gres) > 0 {
+		job.Gres = gres
+		perNode, _ := gresTotals(gres, "gpu")
+		nodes := rj.NodeCount.Number
+		if nodes < 1 {
+			nodes = 1
+		}
+		job.GPUs = perNode * nodes
+	}
+	return job
+}
+
+// fetchRESTNodes 通过 slurmrestd 获取计算节点信息
+func fetchRESTNodes(cluster *Cluster) ([]models.NodeModel, error) {
+	var resp struct {
+		Nodes []restNode `json:"nodes"`
+	}
+	if err := cluster.restGet("nodes", &resp); err != nil {
+		return []models.NodeModel{}, err
+	}
+	nodes := make([]models.NodeModel, 0, len(resp.Nodes))
+	for _, rn := range resp.Nodes {
+		node := nodeFromScontrol(restNodeRecord(rn))
+		node.Cluster = cluster.ClusterName()
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+// restNodeRecord 将 slurmrestd 节点转换为 scontrol show node 的字段，以便复用同一套解析逻辑
+func restNodeRecord(rn restNode) map[string]string {
+	record := map[string]string{
+		"NodeName":       rn.Name,
+		"NodeAddr":       rn.Address,
+		"Arch":           rn.Architecture,
+		"State":          strings.Join(rn.State, "+"),
+		"Partitions":     strings.Join(rn.Partitions, ","),
+		"CPUTot":         strconv.FormatInt(rn.CPUs, 10),
+		"CPUAlloc":       strconv.FormatInt(rn.AllocCPUs, 10),
+		"RealMemory":     strconv.FormatInt(rn.RealMemory, 10),
+		"AllocMem":       strconv.FormatInt(rn.AllocMemory, 10),
+		"Gres":           rn.Gres,
+		"GresUsed":       rn.GresUsed,
+		"ActiveFeatures": strings.Join(rn.ActiveFeatures, ","),
+		"Reason":         rn.Reason,
+		"FreeMem":        "N/A",
+		"CPULoad":        "N/A",
+	}
+	if rn.FreeMem.Set && !rn.FreeMem.Infinite {
+		record["FreeMem"] = strconv.FormatInt(rn.FreeMem.Number, 10)
+	}
+	// slurmrestd 的 cpu_load 为负载乘以 100
+	if rn.CPULoad.Set && !rn.CPULoad.Infinite {
+		record["CPULoad"] = strconv.FormatFloat(float64(rn.CPULoad.Number)/100, 'f', 2, 64)
+	}
+	if rn.BootTime.Number > 0 {
+		record["BootTime"] = time.Unix(rn.BootTime.Number, 0).Format(slurmTimeLayout)
+	}
+	return record
+}
+
+// fetchRESTPartitions 通过 slurmrestd 获取分区配置
+func fetchRESTPartitions(cluster *Cluster) ([]models.PartitionModel, error) {
+	var resp struct {
+		Partitions []restPartition `json:"partitions"`
+	}
+	if err := cluster.restGet("partitions", &resp); err != nil {
+		return nil, err
+	}
+	partitions := make([]models.PartitionModel, 0, len(resp.Partitions))
+	for _, rp := range resp.Partitions {
+		partitions = append(partitions, partitionFromScontrol(restPartitionRecord(rp)))
+	}
+	return partitions, nil
+}
+
+// restPartitionRecord 将 slurmrestd 分区转换为 scontrol show partition 的字段
+func restPartitionRecord(rp restPartition) map[string]string {
+	record := map[string]string{
+		"PartitionName": rp.Name,
+		"State":         strings.Join(rp.Partition.State, ","),
+		"Default":       "NO",
+		"Nodes":         rp.Nodes.Configured,
+		"TotalNodes":    strconv.FormatInt(rp.Nodes.Total, 10),
+		"TotalCPUs":     strconv.FormatInt(rp.CPUs.Total, 10),
+		"MaxTime":       formatRESTMinutes(rp.Maximums.Time, "UNLIMITED"),
+		"DefaultTime":   formatRESTMinutes(rp.Defaults.Time, "NONE"),
+		"DefMemPerCPU":  strconv.FormatInt(rp.Defaults.MemoryPerCPU.Number, 10),
+		"DefMemPerNode": strconv.FormatInt(rp.Defaults.MemoryPerNode.Number, 10),
+		"MaxMemPerCPU":  strconv.FormatInt(rp.Maximums.MemoryPerCPU.Number, 10),
+		"MaxMemPerNode": strconv.FormatInt(rp.Maximums.MemoryPerNode.Number, 10),
+		"AllowAccounts": rp.Accounts.Allowed,
+		"DenyAccounts":  rp.Accounts.Deny,
+		"AllowQos":      rp.QOS.Allowed,
+		"QoS":           rp.QOS.Assigned,
+	}
+	for _, flag := range rp.Flags {
+		if strings.EqualFold(flag, "DEFAULT") {
+			record["Default"] = "YES"
+		}
+	}
+	return record
+}
+
+// formatRESTMinutes 将分钟数格式化为 scontrol 的时长格式（[D-]HH:MM:SS），未设置时返回 unset，无限制时返回 UNLIMITED
+func formatRESTMinutes(n restNumber, unset string) string {
+	if n.Infinite {
+		return "UNLIMITED"
+	}
+	if !n.Set {
+		return unset
+	}
+	days, minutes := n.Number/(24*60), n.Number%(24*60)
+	value := fmt.Sprintf("%02d:%02d:00", minutes/60, minutes%60)
+	if days > 0 {
+		value = fmt.Sprintf("%d-%s", days, value)
+	}
+	return value
+}
+
+// applyPartitionNodeCPUs 按节点状态汇总分区 CPU 使用情况，对应 sinfo %C 的已分配/空闲/其他/总计
+// slurmrestd 的分区接口不提供这些数据，宕机或排空的节点计入其他
+func applyPartitionNodeCPUs(partitions []models.PartitionModel, nodes []models.NodeModel) {
+	index := partitionIndex(partitions)
+	reset := make(map[int]bool)
+	for _, node := range nodes {
+		unavailable := node.State == "down" || node.State == "drain" || node.State == "drained" || node.State == "fail"
+		for _, flag := range node.StateFlags {
+			switch strings.ToUpper(flag) {
+			case "DRAIN", "DOWN", "FAIL", "NOT_RESPONDING":
+				unavailable = true
+			}
+		}
+		for _, name := range node.Partitions {
+			i, ok := index[name]
+			if !ok {
+				continue
+			}
+			p := &partitions[i]
+			if !reset[i] {
+				p.AllocCPUs, p.IdleCPUs, p.OtherCPUs, p.TotalCPUs = 0, 0, 0, 0
+				reset[i] = true
+			}
+			total := int64(node.CPUTotal)
+			p.TotalCPUs += total
+			if unavailable {
+				p.OtherCPUs += total
+				continue
+			}
+			p.AllocCPUs += int64(node.CPUAlloc)
+			p.IdleCPUs += total - int64(node.CPUAlloc)
+		}
+	}
+}
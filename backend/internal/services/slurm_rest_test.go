@@ -0,0 +1,178 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// restFixtureServer 以 testdata 中采集的 slurmrestd 响应模拟只配置 rest_url 的集群
+func restFixtureServer(t *testing.T) *Cluster {
+	t.Helper()
+	fixtures := map[string]string{
+		"/slurm/v0.0.40/jobs":       readFixture(t, "slurmrestd_jobs.json"),
+		"/slurm/v0.0.40/nodes":      readFixture(t, "slurmrestd_nodes.json"),
+		"/slurm/v0.0.40/partitions": readFixture(t, "slurmrestd_partitions.json"),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-SLURM-USER-TOKEN") != "jwt" || r.Header.Get("X-SLURM-USER-NAME") != "slurm" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"errors":[{"error":"Authentication failure"}]}`))
+			return
+		}
+		body, ok := fixtures[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return &Cluster{Name: "beta", RestURL: server.URL + "/", RestToken: "jwt", RestUser: "slurm"}
+}
+
+func TestRESTClusterJobs(t *testing.T) {
+	cluster := restFixtureServer(t)
+	jobs, err := GetQueueJobs(cluster)
+	if err != nil {
+		t.Fatalf("GetQueueJobs() error: %v", err)
+	}
+
+	var ids []string
+	for _, job := range jobs {
+		ids = append(ids, job.JobID)
+		if job.Cluster != "beta" {
+			t.Errorf("job %s cluster = %q", job.JobID, job.Cluster)
+		}
+	}
+	if want := []string{"101", "104", "106_[3-10]", "106_1"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("job ids = %v, want %v", ids, want)
+	}
+
+	running, pending, array := jobs[0], jobs[1], jobs[2]
+	if running.Status != "running" || running.GPUs != 2 || running.Reason != "" {
+		t.Errorf("running job = %+v", running)
+	}
+	if pending.Status != "pending" || pending.GPUs != 8 || pending.Reason != "Resources" || pending.ReasonDescription == "" {
+		t.Errorf("pending job = %+v", pending)
+	}
+	if pending.ExpectedStart == nil || pending.ExpectedStart.Unix() != 1709640000 {
+		t.Errorf("pending expected start = %v", pending.ExpectedStart)
+	}
+	// 数组作业优先级更高，在 gpu 分区排第一
+	if array.QueuePosition != 1 || pending.QueuePosition != 2 {
+		t.Errorf("queue positions = %d, %d, want 1, 2", array.QueuePosition, pending.QueuePosition)
+	}
+	if array.ExpectedStart != nil || array.ComputeTime != "00:00:00" {
+		t.Errorf("array job = %+v", array)
+	}
+}
+
+func TestRESTClusterNodes(t *testing.T) {
+	cluster := restFixtureServer(t)
+	nodes, err := FetchComputeNodes(cluster)
+	if err != nil {
+		t.Fatalf("FetchComputeNodes() error: %v", err)
+	}
+	if len(nodes) != 2 {
+		t.Fatalf("got %d nodes, want 2", len(nodes))
+	}
+
+	gpu, cpu := nodes[0], nodes[1]
+	if gpu.Hostname != "gpu001" || gpu.IP != "10.0.0.11" || gpu.State != "mixed" || gpu.Cluster != "beta" {
+		t.Errorf("gpu001 = %+v", gpu)
+	}
+	if gpu.CPULoad != 12.5 || gpu.CPUTotal != 64 || gpu.CPUAlloc != 16 || gpu.CPUUsage != 25 {
+		t.Errorf("gpu001 cpu = load %v, %d/%d (%v%%)", gpu.CPULoad, gpu.CPUAlloc, gpu.CPUTotal, gpu.CPUUsage)
+	}
+	if gpu.UsedMemory != 256000 || gpu.GPUTotal != 4 || gpu.GPUAlloc != 2 {
+		t.Errorf("gpu001 memory/gpu = %d MB, %d/%d GPUs", gpu.UsedMemory, gpu.GPUAlloc, gpu.GPUTotal)
+	}
+	if !reflect.DeepEqual(gpu.Features, []string{"a100", "ib"}) || gpu.BootTime.Unix() != 1709539200 {
+		t.Errorf("gpu001 features/boot = %v, %v", gpu.Features, gpu.BootTime)
+	}
+
+	if cpu.State != "idle" || !reflect.DeepEqual(cpu.StateFlags, []string{"DRAIN"}) || cpu.Reason != "disk replacement" {
+		t.Errorf("cn001 state = %s %v %q", cpu.State, cpu.StateFlags, cpu.Reason)
+	}
+	// free_mem 未设置时与 scontrol 的 FreeMem=N/A 一样退回到已分配内存
+	if cpu.UsedMemory != 0 || !cpu.BootTime.IsZero() || !reflect.DeepEqual(cpu.Partitions, []string{"cpu", "gpu"}) {
+		t.Errorf("cn001 = %+v", cpu)
+	}
+}
+
+func TestRESTClusterPartitions(t *testing.T) {
+	cluster := restFixtureServer(t)
+	jobs, err := GetQueueJobs(cluster)
+	if err != nil {
+		t.Fatalf("GetQueueJobs() error: %v", err)
+	}
+	nodes, err := FetchComputeNodes(cluster)
+	if err != nil {
+		t.Fatalf("FetchComputeNodes() error: %v", err)
+	}
+	partitions, err := FetchPartitions(cluster, jobs, nodes)
+	if err != nil {
+		t.Fatalf("FetchPartitions() error: %v", err)
+	}
+	if len(partitions) != 2 {
+		t.Fatalf("got %d partitions, want 2", len(partitions))
+	}
+
+	gpu, cpu := partitions[0], partitions[1]
+	if !gpu.Default || gpu.State != "UP" || gpu.Nodes != "gpu001,cn001" || gpu.TotalNodes != 2 || gpu.Cluster != "beta" {
+		t.Errorf("gpu partition = %+v", gpu)
+	}
+	if gpu.MaxTime != "2-00:00:00" || gpu.DefaultTime != "01:00:00" || gpu.DefMemPerCPU != 4000 || gpu.MaxMemPerNode != 0 {
+		t.Errorf("gpu limits = max %s default %s mem %d/%d", gpu.MaxTime, gpu.DefaultTime, gpu.DefMemPerCPU, gpu.MaxMemPerNode)
+	}
+	if !reflect.DeepEqual(gpu.AllowAccounts, []string{"ml", "vision"}) || gpu.QOS != "gpu_normal" {
+		t.Errorf("gpu access = %v %q", gpu.AllowAccounts, gpu.QOS)
+	}
+	// 排空节点 cn001 的 CPU 计入其他
+	if gpu.AllocCPUs != 16 || gpu.IdleCPUs != 48 || gpu.OtherCPUs != 32 || gpu.TotalCPUs != 96 {
+		t.Errorf("gpu cpus = %d/%d/%d/%d", gpu.AllocCPUs, gpu.IdleCPUs, gpu.OtherCPUs, gpu.TotalCPUs)
+	}
+	if gpu.JobsPending != 2 || gpu.JobsRunning != 1 || gpu.GPUTotal != 4 || gpu.GPUAlloc != 2 {
+		t.Errorf("gpu usage = %d pending, %d running, %d/%d GPUs", gpu.JobsPending, gpu.JobsRunning, gpu.GPUAlloc, gpu.GPUTotal)
+	}
+
+	if cpu.Default || cpu.MaxTime != "UNLIMITED" || cpu.DefaultTime != "NONE" || len(cpu.AllowAccounts) != 0 {
+		t.Errorf("cpu partition = %+v", cpu)
+	}
+	if cpu.OtherCPUs != 32 || cpu.TotalCPUs != 32 || cpu.JobsRunning != 1 {
+		t.Errorf("cpu usage = %+v", cpu)
+	}
+}
+
+func TestRESTClusterErrors(t *testing.T) {
+	cluster := restFixtureServer(t)
+
+	// 依赖命令行工具的操作不能落到本机默认配置上
+	if _, err := runSlurmCommand(cluster, "sacct"); err == nil || !strings.Contains(err.Error(), "slurmrestd") {
+		t.Errorf("runSlurmCommand() error = %v, want slurmrestd-only error", err)
+	}
+	if _, err := GetSlurmConfig(cluster); err == nil {
+		t.Error("GetSlurmConfig() = nil error for rest-only cluster")
+	}
+	if cluster.isLocal() {
+		t.Error("rest-only cluster reported as local")
+	}
+
+	cluster.RestToken = "expired"
+	if _, err := GetQueueJobs(cluster); err == nil || !strings.Contains(err.Error(), "Authentication failure") {
+		t.Errorf("GetQueueJobs() error = %v, want slurmrestd error message", err)
+	}
+}
+
+func TestSlurmName(t *testing.T) {
+	r := &ClusterRegistry{clusters: []*Cluster{
+		{Name: "alpha", SlurmCluster: "hpc-alpha"},
+		{Name: "beta", RestURL: "http://beta-ctl:6820"},
+	}}
+	if got, want := r.SlurmNames(), []string{"hpc-alpha", "beta"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SlurmNames() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"fmt"
+	"net"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LocalMail 本地 SMTP 替身收到的邮件
+type LocalMail struct {
+	Time time.Time
+	From string
+	To   []string
+	Data []byte
+}
+
+// LocalSMTPServer 最小化的本地 SMTP 服务，只接收邮件并保存，不做转发
+// 用于未配置真实邮件服务器时测试邮件通知
+type LocalSMTPServer struct {
+	listener net.Listener
+	mailDir  string
+
+	mutex sync.Mutex
+	mails []LocalMail
+}
+
+// StartLocalSMTPServer 在 addr 上启动本地 SMTP 替身，mailDir 不为空时每封邮件另存为 .eml 文件
+func StartLocalSMTPServer(addr, mailDir string) (*LocalSMTPServer, error) {
+	if mailDir != "" {
+		if err := os.MkdirAll(mailDir, 0755); err != nil {
+			return nil, fmt.Errorf("创建邮件目录失败: %v", err)
+		}
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &LocalSMTPServer{listener: listener, mailDir: mailDir}
+	go s.serve()
+	return s, nil
+}
+
+// Addr 返回实际监听的地址
+func (s *LocalSMTPServer) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Close 停止监听
+func (s *LocalSMTPServer) Close() error {
+	return s.listener.Close()
+}
+
+// Mails 返回已收到的邮件
+func (s *LocalSMTPServer) Mails() []LocalMail {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]LocalMail{}, s.mails...)
+}
+
+// serve 接受连接
+func (s *LocalSMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+// handle 处理一个 SMTP 会话，支持 HELO/EHLO、MAIL、RCPT、DATA、RSET、NOOP 和 QUIT
+func (s *LocalSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	text.PrintfLine("220 %s panel SMTP stand-in", getHostname())
+
+	var mail LocalMail
+	for {
+		conn.SetDeadline(time.Now().Add(time.Minute))
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "HELO", "EHLO":
+			text.PrintfLine("250 %s", getHostname())
+		case "MAIL":
+			mail = LocalMail{From: smtpAddress(arg)}
+			text.PrintfLine("250 OK")
+		case "RCPT":
+			mail.To = append(mail.To, smtpAddress(arg))
+			text.PrintfLine("250 OK")
+		case "DATA":
+			if mail.From == "" || len(mail.To) == 0 {
+				text.PrintfLine("503 Bad sequence of commands")
+				continue
+			}
+			text.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			mail.Time = time.Now()
+			mail.Data = data
+			s.store(mail)
+			mail = LocalMail{}
+			text.PrintfLine("250 OK")
+		case "RSET":
+			mail = LocalMail{}
+			text.PrintfLine("250 OK")
+		case "NOOP":
+			text.PrintfLine("250 OK")
+		case "QUIT":
+			text.PrintfLine("221 Bye")
+			return
+		default:
+			text.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+// store 保存邮件到内存，并在配置了目录时写入文件
+func (s *LocalSMTPServer) store(mail LocalMail) {
+	s.mutex.Lock()
+	s.mails = append(s.mails, mail)
+	count := len(s.mails)
+	s.mutex.Unlock()
+
+	if s.mailDir != "" {
+		name := fmt.Sprintf("%s-%d.eml", mail.Time.Format("20060102-150405"), count)
+		os.WriteFile(filepath.Join(s.mailDir, name), mail.Data, 0640)
+	}
+}
+
+// smtpAddress 从 "FROM:<a@b>" 或 "TO:<a@b>" 参数中提取邮箱地址
+func smtpAddress(arg string) string {
+	if _, value, ok := strings.Cut(arg, ":"); ok {
+		arg = value
+	}
+	arg = strings.TrimSpace(arg)
+	if idx := strings.Index(arg, ">"); idx >= 0 {
+		arg = arg[:idx]
+	}
+	return strings.TrimPrefix(arg, "<")
+}
@@ -0,0 +1,604 @@
+package services
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"panel-tool/internal/models"
+)
+
+// 采样参数
+const (
+	// telemetryWarmup 首次采样时两次读取计数器之间的间隔
+	telemetryWarmup = 250 * time.Millisecond
+	// telemetryMinInterval 两次采样的最小间隔，间隔过短时直接返回上一次的结果
+	telemetryMinInterval = time.Second
+	// diskSectorSize /proc/diskstats 中扇区数的单位固定为 512 字节
+	diskSectorSize = 512
+	// telemetryStatfsTimeout 统计单个挂载点容量的超时，避免无响应的网络文件系统阻塞采样
+	telemetryStatfsTimeout = 2 * time.Second
+)
+
+// pseudoFSTypes 不统计容量的虚拟文件系统
+var pseudoFSTypes = map[string]bool{
+	"proc": true, "sysfs": true, "devtmpfs": true, "devpts": true, "tmpfs": true,
+	"cgroup": true, "cgroup2": true, "securityfs": true, "pstore": true, "bpf": true,
+	"debugfs": true, "tracefs": true, "configfs": true, "fusectl": true, "mqueue": true,
+	"hugetlbfs": true, "autofs": true, "binfmt_misc": true, "rpc_pipefs": true,
+	"nsfs": true, "overlay": true, "squashfs": true, "efivarfs": true, "ramfs": true,
+}
+
+// cpuTimes /proc/stat 中一个 CPU 的累计时间（单位为 USER_HZ）
+type cpuTimes struct {
+	user, nice, system, idle, iowait, irq, softirq, steal uint64
+}
+
+// total 返回累计总时间（guest 已包含在 user 中，不重复计算）
+func (t cpuTimes) total() uint64 {
+	return t.user + t.nice + t.system + t.idle + t.iowait + t.irq + t.softirq + t.steal
+}
+
+// diskCounters /proc/diskstats 中一个设备的累计计数
+type diskCounters struct {
+	reads, sectorsRead, writes, sectorsWritten, ioMillis, inProgress uint64
+}
+
+// netCounters /proc/net/dev 中一个接口的累计计数
+type netCounters struct {
+	rxBytes, rxPackets, rxErrors, txBytes, txPackets, txErrors uint64
+}
+
+// telemetryCounters 一次读取的全部累计计数
+type telemetryCounters struct {
+	time  time.Time
+	cpu   cpuTimes
+	cores []cpuTimes
+	disks map[string]diskCounters
+	nets  map[string]netCounters
+}
+
+// TelemetrySampler 读取 /proc 与 /sys 计算主机实时指标，速率类指标由相邻两次采样的差值得出
+type TelemetrySampler struct {
+	procRoot string
+	sysRoot  string
+
+	mutex    sync.Mutex
+	previous *telemetryCounters
+	latest   *models.HostTelemetry
+}
+
+// NewTelemetrySampler 创建读取本机 /proc 和 /sys 的采样器
+func NewTelemetrySampler() *TelemetrySampler {
+	return &TelemetrySampler{procRoot: "/proc", sysRoot: "/sys"}
+}
+
+// Sample 采集一次主机指标；首次调用时等待 telemetryWarmup 以获得速率
+// 距上次采样不足 telemetryMinInterval 时返回上一次的结果
+func (s *TelemetrySampler) Sample() (*models.HostTelemetry, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.latest != nil && time.Since(s.latest.Time) < telemetryMinInterval {
+		return s.latest, nil
+	}
+
+	if s.previous == nil {
+		first, err := s.readCounters()
+		if err != nil {
+			return nil, err
+		}
+		s.previous = first
+		time.Sleep(telemetryWarmup)
+	}
+	current, err := s.readCounters()
+	if err != nil {
+		return nil, err
+	}
+
+	telemetry := &models.HostTelemetry{
+		Time:     current.time,
+		Interval: current.time.Sub(s.previous.time).Seconds(),
+		Topology: ReadCPUTopology(s.sysRoot, s.procRoot),
+		CPU:      cpuUsage(s.previous, current),
+		DiskIO:   diskRates(s.previous, current),
+		Network:  networkRates(s.previous, current),
+	}
+	if load, err := readLoadAverage(filepath.Join(s.procRoot, "loadavg")); err == nil {
+		telemetry.Load = load
+	}
+	if memory, err := readMemoryStats(filepath.Join(s.procRoot, "meminfo")); err == nil {
+		telemetry.Memory = memory
+	}
+	telemetry.Disks = readDiskUsage(filepath.Join(s.procRoot, "self", "mounts"))
+
+	s.previous = current
+	s.latest = telemetry
+	return telemetry, nil
+}
+
+// readCounters 读取 CPU、磁盘和网络的累计计数
+func (s *TelemetrySampler) readCounters() (*telemetryCounters, error) {
+	data, err := os.ReadFile(filepath.Join(s.procRoot, "stat"))
+	if err != nil {
+		return nil, fmt.Errorf("读取 /proc/stat 失败: %v", err)
+	}
+	counters := &telemetryCounters{time: time.Now()}
+	counters.cpu, counters.cores = parseProcStat(string(data))
+
+	counters.disks = map[string]diskCounters{}
+	if data, err := os.ReadFile(filepath.Join(s.procRoot, "diskstats")); err == nil {
+		counters.disks = parseDiskstats(string(data), s.isWholeDisk)
+	}
+	counters.nets = map[string]netCounters{}
+	if data, err := os.ReadFile(filepath.Join(s.procRoot, "net", "dev")); err == nil {
+		counters.nets = parseNetDev(string(data))
+	}
+	return counters, nil
+}
+
+// isWholeDisk 判断设备是否为整块磁盘（/sys/block 下存在且不是 loop、ram 设备），分区不单独统计
+func (s *TelemetrySampler) isWholeDisk(name string) bool {
+	if strings.HasPrefix(name, "loop") || strings.HasPrefix(name, "ram") || strings.HasPrefix(name, "zram") {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(s.sysRoot, "block", strings.ReplaceAll(name, "/", "!")))
+	return err == nil
+}
+
+// parseProcStat 解析 /proc/stat 中的 cpu 行，返回总计与各逻辑 CPU 的累计时间
+func parseProcStat(content string) (cpuTimes, []cpuTimes) {
+	var total cpuTimes
+	var cores []cpuTimes
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 || !strings.HasPrefix(fields[0], "cpu") {
+			continue
+		}
+		values := make([]uint64, 8)
+		for i := 0; i < len(values) && i+1 < len(fields); i++ {
+			values[i], _ = strconv.ParseUint(fields[i+1], 10, 64)
+		}
+		times := cpuTimes{
+			user: values[0], nice: values[1], system: values[2], idle: values[3],
+			iowait: values[4], irq: values[5], softirq: values[6], steal: values[7],
+		}
+		if fields[0] == "cpu" {
+			total = times
+			continue
+		}
+		index, err := strconv.Atoi(strings.TrimPrefix(fields[0], "cpu"))
+		if err != nil {
+			continue
+		}
+		for len(cores) <= index {
+			cores = append(cores, cpuTimes{})
+		}
+		cores[index] = times
+	}
+	return total, cores
+}
+
+// cpuPercent 计算两次采样之间的使用率（百分比）
+func cpuPercent(prev, cur cpuTimes) float64 {
+	total := float64(cur.total()) - float64(prev.total())
+	if total <= 0 {
+		return 0
+	}
+	idle := float64(cur.idle+cur.iowait) - float64(prev.idle+prev.iowait)
+	return roundPercent((total - idle) / total * 100)
+}
+
+// cpuDeltaPercent 计算某类 CPU 时间在两次采样间的占比，计数回退时返回 0
+func cpuDeltaPercent(cur, prev uint64, total float64) float64 {
+	delta := float64(cur) - float64(prev)
+	if delta <= 0 {
+		return 0
+	}
+	return roundPercent(delta / total * 100)
+}
+
+// cpuUsage 计算总体和每个逻辑 CPU 的使用率
+func cpuUsage(prev, cur *telemetryCounters) models.CPUUsage {
+	usage := models.CPUUsage{
+		Total:   cpuPercent(prev.cpu, cur.cpu),
+		PerCore: make([]float64, len(cur.cores)),
+	}
+	if total := float64(cur.cpu.total()) - float64(prev.cpu.total()); total > 0 {
+		usage.User = cpuDeltaPercent(cur.cpu.user+cur.cpu.nice, prev.cpu.user+prev.cpu.nice, total)
+		usage.System = cpuDeltaPercent(cur.cpu.system+cur.cpu.irq+cur.cpu.softirq, prev.cpu.system+prev.cpu.irq+prev.cpu.softirq, total)
+		// iowait 计数在 CPU 空闲切换时可能回退，差值按浮点计算并截断为 0，避免无符号减法回绕
+		usage.IOWait = cpuDeltaPercent(cur.cpu.iowait, prev.cpu.iowait, total)
+		usage.Steal = cpuDeltaPercent(cur.cpu.steal, prev.cpu.steal, total)
+	}
+	for i, core := range cur.cores {
+		if i < len(prev.cores) {
+			usage.PerCore[i] = cpuPercent(prev.cores[i], core)
+		}
+	}
+	return usage
+}
+
+// parseDiskstats 解析 /proc/diskstats，include 决定是否统计某个设备
+func parseDiskstats(content string, include func(name string) bool) map[string]diskCounters {
+	disks := make(map[string]diskCounters)
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 14 || !include(fields[2]) {
+			continue
+		}
+		value := func(i int) uint64 {
+			n, _ := strconv.ParseUint(fields[i], 10, 64)
+			return n
+		}
+		disks[fields[2]] = diskCounters{
+			reads:          value(3),
+			sectorsRead:    value(5),
+			writes:         value(7),
+			sectorsWritten: value(9),
+			inProgress:     value(11),
+			ioMillis:       value(12),
+		}
+	}
+	return disks
+}
+
+// diskRates 计算各磁盘的读写速率和忙碌百分比
+func diskRates(prev, cur *telemetryCounters) []models.DiskIO {
+	seconds := cur.time.Sub(prev.time).Seconds()
+	rates := []models.DiskIO{}
+	if seconds <= 0 {
+		return rates
+	}
+	for name, c := range cur.disks {
+		p, ok := prev.disks[name]
+		if !ok {
+			continue
+		}
+		rates = append(rates, models.DiskIO{
+			Device:      name,
+			ReadBytes:   counterRate(p.sectorsRead, c.sectorsRead, seconds) * diskSectorSize,
+			WriteBytes:  counterRate(p.sectorsWritten, c.sectorsWritten, seconds) * diskSectorSize,
+			ReadOps:     counterRate(p.reads, c.reads, seconds),
+			WriteOps:    counterRate(p.writes, c.writes, seconds),
+			Utilization: roundPercent(min(counterRate(p.ioMillis, c.ioMillis, seconds)/10, 100)),
+			InProgress:  int64(c.inProgress),
+		})
+	}
+	sort.Slice(rates, func(i, j int) bool { return rates[i].Device < rates[j].Device })
+	return rates
+}
+
+// parseNetDev 解析 /proc/net/dev，跳过回环接口
+func parseNetDev(content string) map[string]netCounters {
+	nets := make(map[string]netCounters)
+	for _, line := range strings.Split(content, "\n") {
+		name, rest, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		name = strings.TrimSpace(name)
+		fields := strings.Fields(rest)
+		if name == "lo" || len(fields) < 16 {
+			continue
+		}
+		value := func(i int) uint64 {
+			n, _ := strconv.ParseUint(fields[i], 10, 64)
+			return n
+		}
+		nets[name] = netCounters{
+			rxBytes:   value(0),
+			rxPackets: value(1),
+			rxErrors:  value(2),
+			txBytes:   value(8),
+			txPackets: value(9),
+			txErrors:  value(10),
+		}
+	}
+	return nets
+}
+
+// networkRates 计算各网络接口的吞吐量
+func networkRates(prev, cur *telemetryCounters) []models.NetworkIO {
+	seconds := cur.time.Sub(prev.time).Seconds()
+	rates := []models.NetworkIO{}
+	if seconds <= 0 {
+		return rates
+	}
+	for name, c := range cur.nets {
+		p, ok := prev.nets[name]
+		if !ok {
+			continue
+		}
+		rates = append(rates, models.NetworkIO{
+			Interface: name,
+			RxBytes:   counterRate(p.rxBytes, c.rxBytes, seconds),
+			TxBytes:   counterRate(p.txBytes, c.txBytes, seconds),
+			RxPackets: counterRate(p.rxPackets, c.rxPackets, seconds),
+			TxPackets: counterRate(p.txPackets, c.txPackets, seconds),
+			RxErrors:  int64(c.rxErrors),
+			TxErrors:  int64(c.txErrors),
+		})
+	}
+	sort.Slice(rates, func(i, j int) bool { return rates[i].Interface < rates[j].Interface })
+	return rates
+}
+
+// counterRate 计算累计计数的每秒增量，计数器回绕或重置时返回 0
+func counterRate(prev, cur uint64, seconds float64) float64 {
+	if cur < prev {
+		return 0
+	}
+	return float64(cur-prev) / seconds
+}
+
+// roundPercent 保留一位小数
+func roundPercent(value float64) float64 {
+	return float64(int64(value*10+0.5)) / 10
+}
+
+// readLoadAverage 读取 /proc/loadavg，格式如 "0.52 0.58 0.59 2/1234 5678"
+func readLoadAverage(path string) (models.LoadAverage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return models.LoadAverage{}, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 4 {
+		return models.LoadAverage{}, fmt.Errorf("无法解析 %s", path)
+	}
+	load := models.LoadAverage{}
+	load.Load1, _ = strconv.ParseFloat(fields[0], 64)
+	load.Load5, _ = strconv.ParseFloat(fields[1], 64)
+	load.Load15, _ = strconv.ParseFloat(fields[2], 64)
+	if running, total, ok := strings.Cut(fields[3], "/"); ok {
+		load.Running, _ = strconv.Atoi(running)
+		load.Processes, _ = strconv.Atoi(total)
+	}
+	return load, nil
+}
+
+// readMemoryStats 读取 /proc/meminfo，已用内存按 Total - Available 计算
+func readMemoryStats(path string) (models.MemoryStats, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return models.MemoryStats{}, err
+	}
+	defer file.Close()
+
+	values := make(map[string]int64)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, rest, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
+		}
+		kb, _ := strconv.ParseInt(fields[0], 10, 64)
+		values[key] = kb * 1024
+	}
+
+	stats := models.MemoryStats{
+		Total:     values["MemTotal"],
+		Free:      values["MemFree"],
+		Buffers:   values["Buffers"],
+		Cached:    values["Cached"] + values["SReclaimable"],
+		SwapTotal: values["SwapTotal"],
+		SwapUsed:  values["SwapTotal"] - values["SwapFree"],
+	}
+	stats.Available = values["MemAvailable"]
+	if _, ok := values["MemAvailable"]; !ok {
+		// 3.14 之前的内核没有 MemAvailable
+		stats.Available = stats.Free + stats.Buffers + stats.Cached
+	}
+	stats.Used = stats.Total - stats.Available
+	if stats.Total > 0 {
+		stats.UsedPercent = roundPercent(float64(stats.Used) / float64(stats.Total) * 100)
+	}
+	return stats, nil
+}
+
+// mountEntry 挂载表中的一条记录
+type mountEntry struct {
+	device   string
+	mount    string
+	fsType   string
+	readOnly bool
+}
+
+// parseMounts 解析 /proc/self/mounts，跳过虚拟文件系统和重复挂载
+func parseMounts(content string) []mountEntry {
+	var entries []mountEntry
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || pseudoFSTypes[fields[2]] {
+			continue
+		}
+		entry := mountEntry{device: fields[0], mount: unescapeMountPath(fields[1]), fsType: fields[2]}
+		if seen[entry.mount] {
+			continue
+		}
+		seen[entry.mount] = true
+		if len(fields) > 3 {
+			for _, option := range strings.Split(fields[3], ",") {
+				if option == "ro" {
+					entry.readOnly = true
+				}
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// errStatfsInFlight 挂载点上一次 statfs 仍未返回
+var errStatfsInFlight = errors.New("上一次 statfs 仍未返回")
+
+// statfsPending 记录 statfs 仍阻塞的挂载点，每个挂载点最多只有一个阻塞的协程
+var (
+	statfsPendingMutex sync.Mutex
+	statfsPending      = make(map[string]bool)
+)
+
+// statfsTimeout 在超时时间内执行 statfs，NFS 等网络文件系统无响应时不会阻塞调用方
+// 超时的 statfs 返回前，对同一挂载点的后续调用直接返回 errStatfsInFlight，不再启动新的协程
+func statfsTimeout(path string, timeout time.Duration) (*syscall.Statfs_t, error) {
+	statfsPendingMutex.Lock()
+	if statfsPending[path] {
+		statfsPendingMutex.Unlock()
+		return nil, fmt.Errorf("%s 无响应: %w", path, errStatfsInFlight)
+	}
+	statfsPending[path] = true
+	statfsPendingMutex.Unlock()
+
+	type result struct {
+		stat syscall.Statfs_t
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		var r result
+		r.err = syscall.Statfs(path, &r.stat)
+		statfsPendingMutex.Lock()
+		delete(statfsPending, path)
+		statfsPendingMutex.Unlock()
+		done <- r
+	}()
+	select {
+	case r := <-done:
+		if r.err != nil {
+			return nil, r.err
+		}
+		return &r.stat, nil
+	case <-time.After(timeout):
+		return nil, fmt.Errorf("%s 超过 %s 无响应", path, timeout)
+	}
+}
+
+// readDiskUsage 读取挂载表并统计各文件系统的容量，跳过虚拟文件系统、重复挂载和无响应的挂载点
+func readDiskUsage(mountsPath string) []models.DiskUsage {
+	disks := []models.DiskUsage{}
+	data, err := os.ReadFile(mountsPath)
+	if err != nil {
+		return disks
+	}
+
+	for _, entry := range parseMounts(string(data)) {
+		stat, err := statfsTimeout(entry.mount, telemetryStatfsTimeout)
+		if err != nil || stat.Blocks == 0 {
+			continue
+		}
+		blockSize := int64(stat.Bsize)
+		usage := models.DiskUsage{
+			Mount:      entry.mount,
+			Device:     entry.device,
+			FSType:     entry.fsType,
+			Total:      int64(stat.Blocks) * blockSize,
+			Free:       int64(stat.Bavail) * blockSize,
+			Used:       int64(stat.Blocks-stat.Bfree) * blockSize,
+			Inodes:     int64(stat.Files),
+			InodesUsed: int64(stat.Files - stat.Ffree),
+		}
+		// 与 df 一致：使用率 = 已用 / (已用 + 普通用户可用)
+		if denominator := usage.Used + usage.Free; denominator > 0 {
+			usage.UsedPercent = roundPercent(float64(usage.Used) / float64(denominator) * 100)
+		}
+		disks = append(disks, usage)
+	}
+	return disks
+}
+
+// unescapeMountPath 还原挂载表中八进制转义的字符，如 \040 表示空格
+func unescapeMountPath(path string) string {
+	if !strings.Contains(path, `\`) {
+		return path
+	}
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] == '\\' && i+3 < len(path) {
+			if n, err := strconv.ParseUint(path[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(n))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(path[i])
+	}
+	return b.String()
+}
+
+// ReadCPUTopology 由 /sys/devices/system/cpu/cpu*/topology 统计插槽、物理核心和逻辑处理器数量
+// /sys 不可用时退回到 /proc/cpuinfo 中的 physical id 与 core id
+func ReadCPUTopology(sysRoot, procRoot string) models.CPUTopology {
+	type coreKey struct{ socket, core string }
+	sockets := make(map[string]bool)
+	cores := make(map[coreKey]bool)
+	threads := 0
+
+	dirs, _ := filepath.Glob(filepath.Join(sysRoot, "devices", "system", "cpu", "cpu[0-9]*"))
+	for _, dir := range dirs {
+		// 离线的 CPU 没有 topology 目录
+		socket, err1 := os.ReadFile(filepath.Join(dir, "topology", "physical_package_id"))
+		core, err2 := os.ReadFile(filepath.Join(dir, "topology", "core_id"))
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		threads++
+		s := strings.TrimSpace(string(socket))
+		sockets[s] = true
+		cores[coreKey{s, strings.TrimSpace(string(core))}] = true
+	}
+
+	if threads == 0 {
+		if file, err := os.Open(filepath.Join(procRoot, "cpuinfo")); err == nil {
+			defer file.Close()
+			socket := "0"
+			scanner := bufio.NewScanner(file)
+			for scanner.Scan() {
+				key, value, ok := strings.Cut(scanner.Text(), ":")
+				if !ok {
+					continue
+				}
+				value = strings.TrimSpace(value)
+				switch strings.TrimSpace(key) {
+				case "processor":
+					threads++
+					socket = "0"
+				case "physical id":
+					socket = value
+					sockets[socket] = true
+				case "core id":
+					cores[coreKey{socket, value}] = true
+				}
+			}
+		}
+	}
+
+	topology := models.CPUTopology{Sockets: len(sockets), Cores: len(cores), Threads: threads}
+	// 部分虚拟机或架构不提供 core id，此时每个逻辑处理器视为一个核心
+	if topology.Sockets == 0 && threads > 0 {
+		topology.Sockets = 1
+	}
+	if topology.Cores == 0 {
+		topology.Cores = threads
+	}
+	if topology.Sockets > 0 {
+		topology.CoresPerSocket = topology.Cores / topology.Sockets
+	}
+	if topology.Cores > 0 {
+		topology.ThreadsPerCore = topology.Threads / topology.Cores
+	}
+	return topology
+}
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"panel-tool/internal/models"
+)
+
+// fixtureSampler 以 testdata 下的目录树代替 /proc 与 /sys
+func fixtureSampler(dir string) *TelemetrySampler {
+	return &TelemetrySampler{
+		procRoot: filepath.Join("testdata", dir, "proc"),
+		sysRoot:  filepath.Join("testdata", "host", "sys"),
+	}
+}
+
+// fixtureCounters 读取 host 与 host_later 两次采样的累计计数，间隔 10 秒
+func fixtureCounters(t *testing.T) (*telemetryCounters, *telemetryCounters) {
+	t.Helper()
+	prev, err := fixtureSampler("host").readCounters()
+	if err != nil {
+		t.Fatalf("readCounters(host) error: %v", err)
+	}
+	cur, err := fixtureSampler("host_later").readCounters()
+	if err != nil {
+		t.Fatalf("readCounters(host_later) error: %v", err)
+	}
+	cur.time = prev.time.Add(10 * time.Second)
+	return prev, cur
+}
+
+func TestParseProcStat(t *testing.T) {
+	total, cores := parseProcStat(readFixture(t, "host/proc/stat"))
+	if want := (cpuTimes{user: 1000, system: 500, idle: 8000, iowait: 200}); total != want {
+		t.Errorf("total = %+v, want %+v", total, want)
+	}
+	if len(cores) != 2 || cores[1].total() != 4850 {
+		t.Errorf("cores = %+v", cores)
+	}
+	// 离线 CPU 不出现在 /proc/stat 中，按编号对齐
+	_, cores = parseProcStat("cpu  1 0 0 1\ncpu0 1 0 0 1 0\ncpu2 5 0 0 5 0\n")
+	if len(cores) != 3 || cores[1].total() != 0 || cores[2].user != 5 {
+		t.Errorf("cores with gap = %+v", cores)
+	}
+}
+
+func TestCPUUsage(t *testing.T) {
+	prev, cur := fixtureCounters(t)
+	usage := cpuUsage(prev, cur)
+	// iowait 由 200 回退到 150，截断为 0 而不是回绕成极大值
+	want := models.CPUUsage{Total: 51.4, User: 34.3, System: 17.1, PerCore: []float64{61.1, 41.2}}
+	if !reflect.DeepEqual(usage, want) {
+		t.Errorf("cpuUsage() = %+v, want %+v", usage, want)
+	}
+
+	if usage := cpuUsage(cur, cur); usage.Total != 0 || usage.User != 0 || !reflect.DeepEqual(usage.PerCore, []float64{0, 0}) {
+		t.Errorf("cpuUsage() without delta = %+v", usage)
+	}
+}
+
+func TestDiskRates(t *testing.T) {
+	prev, cur := fixtureCounters(t)
+	// 分区 sda1 与 loop 设备不单独统计
+	if len(prev.disks) != 2 {
+		t.Fatalf("disks = %+v, want sda and nvme0n1", prev.disks)
+	}
+
+	want := []models.DiskIO{
+		{Device: "nvme0n1", Utilization: 100},
+		{Device: "sda", ReadBytes: 1 << 20, WriteBytes: 1 << 20, ReadOps: 10, WriteOps: 10, Utilization: 50, InProgress: 2},
+	}
+	if got := diskRates(prev, cur); !reflect.DeepEqual(got, want) {
+		t.Errorf("diskRates() = %+v, want %+v", got, want)
+	}
+	if got := diskRates(cur, cur); len(got) != 0 {
+		t.Errorf("diskRates() without interval = %+v", got)
+	}
+}
+
+func TestNetworkRates(t *testing.T) {
+	prev, cur := fixtureCounters(t)
+	if _, ok := prev.nets["lo"]; ok {
+		t.Error("loopback interface was not skipped")
+	}
+
+	// eth1 计数器回绕时速率为 0，新出现的 ib0 没有上一次的计数
+	want := []models.NetworkIO{
+		{Interface: "eth0", RxBytes: 1000000, TxBytes: 50000, RxPackets: 1000, TxPackets: 50, RxErrors: 1, TxErrors: 2},
+		{Interface: "eth1"},
+	}
+	if got := networkRates(prev, cur); !reflect.DeepEqual(got, want) {
+		t.Errorf("networkRates() = %+v, want %+v", got, want)
+	}
+}
+
+func TestReadLoadAverage(t *testing.T) {
+	load, err := readLoadAverage(filepath.Join("testdata", "host", "proc", "loadavg"))
+	if err != nil {
+		t.Fatalf("readLoadAverage() error: %v", err)
+	}
+	if want := (models.LoadAverage{Load1: 0.52, Load5: 0.58, Load15: 0.59, Running: 2, Processes: 1234}); load != want {
+		t.Errorf("readLoadAverage() = %+v, want %+v", load, want)
+	}
+	if _, err := readLoadAverage(filepath.Join("testdata", "missing", "loadavg")); err == nil {
+		t.Error("readLoadAverage() = nil error for missing file")
+	}
+}
+
+func TestReadMemoryStats(t *testing.T) {
+	tests := []struct {
+		file string
+		want models.MemoryStats
+	}{
+		{"host/proc/meminfo", models.MemoryStats{
+			Total: 16384000 << 10, Available: 8192000 << 10, Used: 8192000 << 10, Free: 2048000 << 10,
+			Buffers: 512000 << 10, Cached: 4352000 << 10, UsedPercent: 50, SwapTotal: 2048000 << 10, SwapUsed: 1024000 << 10,
+		}},
+		// 没有 MemAvailable 时按 Free + Buffers + Cached 估算
+		{"meminfo_no_available.txt", models.MemoryStats{
+			Total: 8192000 << 10, Available: 4096000 << 10, Used: 4096000 << 10, Free: 1024000 << 10,
+			Buffers: 512000 << 10, Cached: 2560000 << 10, UsedPercent: 50,
+		}},
+	}
+	for _, tt := range tests {
+		got, err := readMemoryStats(filepath.Join("testdata", tt.file))
+		if err != nil || got != tt.want {
+			t.Errorf("readMemoryStats(%s) = %+v, %v, want %+v", tt.file, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseMounts(t *testing.T) {
+	want := []mountEntry{
+		{device: "/dev/sda1", mount: "/", fsType: "ext4"},
+		{device: "nfs01:/export/home", mount: "/home", fsType: "nfs4"},
+		{device: "/dev/sdb1", mount: "/mnt/data disk", fsType: "xfs", readOnly: true},
+	}
+	if got := parseMounts(readFixture(t, "host/proc/self/mounts")); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseMounts() = %+v, want %+v", got, want)
+	}
+}
+
+func TestUnescapeMountPath(t *testing.T) {
+	tests := []struct {
+		path, want string
+	}{
+		{"/scratch", "/scratch"},
+		{`/mnt/data\040disk`, "/mnt/data disk"},
+		{`/a\011b\134c`, "/a\tb\\c"},
+		{`/end\04`, `/end\04`},
+		{`/bad\999`, `/bad\999`},
+	}
+	for _, tt := range tests {
+		if got := unescapeMountPath(tt.path); got != tt.want {
+			t.Errorf("unescapeMountPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestReadCPUTopology(t *testing.T) {
+	tests := []struct {
+		name string
+		dir  string
+		want models.CPUTopology
+	}{
+		// 离线的 cpu8 没有 topology 目录，不计入
+		{"sysfs", "host", models.CPUTopology{Sockets: 2, Cores: 4, Threads: 8, CoresPerSocket: 2, ThreadsPerCore: 2}},
+		{"cpuinfo", "cpuinfo_only", models.CPUTopology{Sockets: 1, Cores: 2, Threads: 4, CoresPerSocket: 2, ThreadsPerCore: 2}},
+		// ARM 的 cpuinfo 没有 physical id 与 core id
+		{"cpuinfo without ids", "cpuinfo_arm", models.CPUTopology{Sockets: 1, Cores: 3, Threads: 3, CoresPerSocket: 3, ThreadsPerCore: 1}},
+		{"missing", "missing", models.CPUTopology{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := filepath.Join("testdata", tt.dir)
+			if got := ReadCPUTopology(filepath.Join(root, "sys"), filepath.Join(root, "proc")); got != tt.want {
+				t.Errorf("ReadCPUTopology() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTelemetrySamplerSample(t *testing.T) {
+	s := fixtureSampler("host")
+	previous, err := s.readCounters()
+	if err != nil {
+		t.Fatalf("readCounters() error: %v", err)
+	}
+	previous.time = previous.time.Add(-5 * time.Second)
+	s.previous = previous
+
+	telemetry, err := s.Sample()
+	if err != nil {
+		t.Fatalf("Sample() error: %v", err)
+	}
+	if telemetry.Interval < 5 || telemetry.Topology.Threads != 8 || telemetry.Load.Load1 != 0.52 || telemetry.Memory.UsedPercent != 50 {
+		t.Errorf("Sample() = interval %v topology %+v load %+v memory %+v", telemetry.Interval, telemetry.Topology, telemetry.Load, telemetry.Memory)
+	}
+	if len(telemetry.CPU.PerCore) != 2 || len(telemetry.DiskIO) != 2 || len(telemetry.Network) != 2 {
+		t.Errorf("Sample() rates = %+v %+v %+v", telemetry.CPU, telemetry.DiskIO, telemetry.Network)
+	}
+	// 挂载表中的 / 在测试环境中一定存在，/proc、/run 等虚拟文件系统不统计
+	mounts := make(map[string]models.DiskUsage)
+	for _, disk := range telemetry.Disks {
+		mounts[disk.Mount] = disk
+	}
+	if root := mounts["/"]; root.Device != "/dev/sda1" || root.FSType != "ext4" || root.Total == 0 {
+		t.Errorf("Sample() disks = %+v, want / from the fixture mount table", telemetry.Disks)
+	}
+	if _, ok := mounts["/proc"]; ok {
+		t.Error("Sample() reported pseudo filesystem /proc")
+	}
+
+	// 间隔不足 telemetryMinInterval 时返回上一次的结果
+	if again, err := s.Sample(); err != nil || again != telemetry {
+		t.Errorf("second Sample() = %p, %v, want cached %p", again, err, telemetry)
+	}
+
+	if _, err := fixtureSampler("missing").Sample(); err == nil {
+		t.Error("Sample() = nil error without /proc/stat")
+	}
+}
@@ -21,6 +21,20 @@ apiClient.interceptors.request.use(
   }
 )
 
+// 会话过期或服务重启后 token 失效，清除登录信息并返回登录页
+apiClient.interceptors.response.use(
+  response => response,
+  error => {
+    if (error.response?.status === 401 && localStorage.getItem('authToken')) {
+      localStorage.removeItem('authToken')
+      localStorage.removeItem('user')
+      localStorage.removeItem('lastActivity')
+      window.location.href = '/login'
+    }
+    return Promise.reject(error)
+  }
+)
+
 export async function fetchManagementNode() {
   try {
     const response = await apiClient.get('/management-node')
@@ -30,6 +44,15 @@ export async function fetchManagementNode() {
   }
 }
 
+export async function fetchManagementTelemetry() {
+  try {
+    const response = await apiClient.get('/management-node/telemetry')
+    return response.data
+  } catch (error) {
+    throw new Error('Failed to fetch management node telemetry')
+  }
+}
+
 export async function fetchComputeNodes() {
   try {
     const response = await apiClient.get('/compute-nodes')
@@ -48,6 +71,42 @@ export async function fetchSlurmJobs() {
   }
 }
 
+export async function fetchSlurmJobDetail(jobId) {
+  try {
+    const response = await apiClient.get(`/slurm/jobs/${jobId}`)
+    return response.data
+  } catch (error) {
+    throw new Error('Failed to fetch SLURM job detail')
+  }
+}
+
+export async function fetchSlurmJobHistory(params) {
+  try {
+    const response = await apiClient.get('/slurm/jobs/history', { params })
+    return response.data
+  } catch (error) {
+    throw new Error('Failed to fetch SLURM job history')
+  }
+}
+
+export async function fetchPartitions() {
+  try {
+    const response = await apiClient.get('/slurm/partitions')
+    return response.data
+  } catch (error) {
+    throw new Error('Failed to fetch partitions')
+  }
+}
+
+export async function fetchJobDependencyGraph(params = {}) {
+  try {
+    const response = await apiClient.get('/slurm/jobs/graph', { params })
+    return response.data
+  } catch (error) {
+    throw new Error('Failed to fetch job dependency graph')
+  }
+}
+
 // 登录API
 export async function login(username, password) {
   try {
@@ -96,4 +155,181 @@ export const changeFilePermissions = (filePath, permissions) => {
     path: filePath,
     permissions: permissions
   })
-}
\ No newline at end of file
+}
+// 订阅集群变更事件（SSE），onEvent 接收事件类型和事件数据，返回 EventSource 以便关闭
+export function subscribeClusterEvents(onEvent) {
+  const source = new EventSource(`${API_BASE}/events`)
+  const types = [
+    'job.submitted', 'job.started', 'job.finished', 'job.state_changed',
+    'node.added', 'node.removed', 'node.state_changed'
+  ]
+  types.forEach(type => {
+    source.addEventListener(type, event => {
+      onEvent(type, JSON.parse(event.data))
+    })
+  })
+  return source
+}
+
+export async function fetchLicenses() {
+  try {
+    const response = await apiClient.get('/slurm/licenses')
+    return response.data
+  } catch (error) {
+    throw new Error('Failed to fetch licenses')
+  }
+}
+
+export async function fetchClusters() {
+  try {
+    const response = await apiClient.get('/clusters')
+    return response.data
+  } catch (error) {
+    throw new Error('Failed to fetch clusters')
+  }
+}
+
+export async function fetchNotifications(unreadOnly = false) {
+  try {
+    const response = await apiClient.get('/notifications', { params: { unread: unreadOnly } })
+    return response.data
+  } catch (error) {
+    throw new Error('Failed to fetch notifications')
+  }
+}
+
+export async function markNotificationsRead(id = 'all') {
+  try {
+    const response = await apiClient.post(`/notifications/read/${id}`)
+    return response.data
+  } catch (error) {
+    throw new Error('Failed to mark notifications as read')
+  }
+}
+
+export async function fetchNotificationSubscriptions() {
+  try {
+    const response = await apiClient.get('/notifications/subscriptions')
+    return response.data
+  } catch (error) {
+    throw new Error('Failed to fetch notification subscriptions')
+  }
+}
+
+export async function createNotificationSubscription(subscription) {
+  try {
+    const response = await apiClient.post('/notifications/subscriptions', subscription)
+    return response.data
+  } catch (error) {
+    throw new Error('Failed to create notification subscription')
+  }
+}
+
+export async function deleteNotificationSubscription(id) {
+  try {
+    const response = await apiClient.delete(`/notifications/subscriptions/${id}`)
+    return response.data
+  } catch (error) {
+    throw new Error('Failed to delete notification subscription')
+  }
+}
+
+export async function fetchMetricSeries(params) {
+  try {
+    const response = await apiClient.get('/metrics/query', { params })
+    return response.data
+  } catch (error) {
+    throw new Error('Failed to fetch metrics')
+  }
+}
+
+export async function fetchInventory(params = {}) {
+  try {
+    const response = await apiClient.get('/inventory', { params })
+    return response.data
+  } catch (error) {
+    throw new Error('Failed to fetch inventory')
+  }
+}
+
+export async function fetchInventoryChanges(params = {}) {
+  try {
+    const response = await apiClient.get('/inventory/changes', { params })
+    return response.data
+  } catch (error) {
+    throw new Error('Failed to fetch inventory changes')
+  }
+}
+
+export async function fetchSensors(params = {}) {
+  try {
+    const response = await apiClient.get('/sensors', { params })
+    return response.data
+  } catch (error) {
+    throw new Error('Failed to fetch sensors')
+  }
+}
+
+export async function fetchSensorAlerts(params = {}) {
+  try {
+    const response = await apiClient.get('/sensors/alerts', { params })
+    return response.data
+  } catch (error) {
+    throw new Error('Failed to fetch sensor alerts')
+  }
+}
+
+// 告警规则引擎：params.state 可为 pending、firing、resolved
+export async function fetchAlerts(params = {}) {
+  try {
+    const response = await apiClient.get('/alerts', { params })
+    return response.data
+  } catch (error) {
+    throw new Error('Failed to fetch alerts')
+  }
+}
+
+export async function fetchAlertRules() {
+  try {
+    const response = await apiClient.get('/alerts/rules')
+    return response.data
+  } catch (error) {
+    throw new Error('Failed to fetch alert rules')
+  }
+}
+
+export async function updateAlertRules(rules) {
+  try {
+    const response = await apiClient.put('/alerts/rules', rules)
+    return response.data
+  } catch (error) {
+    throw new Error('Failed to update alert rules')
+  }
+}
+
+export async function fetchAlertSilences() {
+  try {
+    const response = await apiClient.get('/alerts/silences')
+    return response.data
+  } catch (error) {
+    throw new Error('Failed to fetch alert silences')
+  }
+}
+
+export async function createAlertSilence(silence) {
+  try {
+    const response = await apiClient.post('/alerts/silences', silence)
+    return response.data
+  } catch (error) {
+    throw new Error('Failed to create alert silence')
+  }
+}
+
+export async function deleteAlertSilence(id) {
+  try {
+    const response = await apiClient.delete(`/alerts/silences/${id}`)
+    return response.data
+  } catch (error) {
+    throw new Error('Failed to delete alert silence')
+  }
+}
@@ -19,6 +19,13 @@ export function formatPercentage(value) {
   return `${value.toFixed(2)}%`
 }
 
+// 格式化内存使用量（输入单位为MB），如 "5.4GB/16GB"
+export function formatMemoryUsage(usedMB, totalMB) {
+  const toGB = value => (typeof value === 'number' ? value / 1024 : 0)
+  const format = value => (Number.isInteger(value) ? `${value}` : value.toFixed(1))
+  return `${format(toGB(usedMB))}GB/${format(toGB(totalMB))}GB`
+}
+
 // 格式化等待时间和计算时间
 export function formatDuration(duration) {
   if (!duration) return '0m'
@@ -28,5 +35,6 @@ export function formatDuration(duration) {
 export default {
   formatDateTime,
   formatPercentage,
+  formatMemoryUsage,
   formatDuration
 }
\ No newline at end of file